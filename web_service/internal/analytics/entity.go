@@ -0,0 +1,62 @@
+package analytics
+
+import "time"
+
+// Dimensi tambahan agregasi dwell time (selalu dikelompokkan per stage)
+const (
+	GroupByStage    = "stage"
+	GroupByDriver   = "driver"
+	GroupByCustomer = "customer"
+	GroupByWeek     = "week"
+)
+
+type DwellFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+// DwellStat lama SJ berada di satu stage (status ADW_STS) = selisih CREATED dua event berurutan.
+// Stage yang masih berjalan tidak dihitung di sini, lihat SLABreach.
+type DwellStat struct {
+	Stage      string  `db:"STAGE" json:"stage"`
+	GroupKey   *string `db:"GROUPKEY" json:"group_key,omitempty"` // nil untuk groupBy=stage
+	GroupLabel *string `db:"GROUPLABEL" json:"group_label,omitempty"`
+	Count      int     `db:"CNT" json:"count"`
+	P50Seconds int64   `db:"P50SECONDS" json:"p50_seconds"`
+	P90Seconds int64   `db:"P90SECONDS" json:"p90_seconds"`
+	MaxSeconds int64   `db:"MAXSECONDS" json:"max_seconds"`
+	SLASeconds *int64  `db:"SLASECONDS" json:"sla_seconds"` // nil jika stage tidak punya target
+	Breached   int     `db:"BREACHED" json:"breached"`      // jumlah yang melewati SLA
+}
+
+// SLATarget batas waktu satu stage dari config SLA_TARGETS
+type SLATarget struct {
+	Stage   string `json:"stage"`
+	Seconds int64  `json:"sla_seconds"`
+}
+
+// SLABreach SJ yang saat ini masih di stage-nya melebihi target SLA
+type SLABreach struct {
+	MInOutID       int64     `db:"M_INOUT_ID" json:"m_inout_id"`
+	DocumentNo     string    `db:"DOCUMENTNO" json:"document_no"`
+	MovementDate   time.Time `db:"MOVEMENTDATE" json:"movement_date"`
+	Customer       string    `db:"CUSTOMER" json:"customer_name"`
+	Driver         *string   `db:"DRIVER" json:"driver_name"`
+	Stage          string    `db:"STAGE" json:"stage"`
+	Since          time.Time `db:"SINCE" json:"since"`
+	ElapsedSeconds int64     `db:"ELAPSEDSECONDS" json:"elapsed_seconds"`
+	SLASeconds     int64     `db:"SLASECONDS" json:"sla_seconds"`
+}
+
+type SLAReport struct {
+	Targets  []SLATarget `json:"targets"`
+	Breaches []SLABreach `json:"breaches"`
+}
+
+type APIResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Count   int         `json:"count"`
+	Data    interface{} `json:"data,omitempty"`
+}
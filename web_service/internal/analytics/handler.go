@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *handler {
+	return &handler{service: s}
+}
+
+func (h *handler) RegisterProtectedRoutes(r chi.Router) {
+	r.Route("/analytics", func(r chi.Router) {
+		r.Get("/dwell", h.GetDwellStats)
+		r.Get("/sla", h.GetSLAReport)
+	})
+}
+
+// GetDwellStats: GET /analytics/dwell?dateFrom=&dateTo=&groupBy=stage|driver|customer|week
+func (h *handler) GetDwellStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	list, err := h.service.DwellStats(r.Context(), q.Get("dateFrom"), q.Get("dateTo"), q.Get("groupBy"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidGroupBy) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+
+		log.Printf(
+			"[SERVICE]: path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed get dwell time analytics",
+		})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "OK",
+		Count:   len(list),
+		Data:    list,
+	})
+}
+
+// GetSLAReport: GET /analytics/sla, target SLA per stage + SJ yang saat ini melewatinya
+func (h *handler) GetSLAReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.SLAReport(r.Context())
+	if err != nil {
+		log.Printf(
+			"[SERVICE]: path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed get SLA report",
+		})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "OK",
+		Count:   len(report.Breaches),
+		Data:    report,
+	})
+}
@@ -0,0 +1,175 @@
+package analytics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetDwellStats(ctx context.Context, f DwellFilter, targets []SLATarget) ([]DwellStat, error)
+	GetSLABreaches(ctx context.Context, targets []SLATarget) ([]SLABreach, error)
+}
+
+type oraRepo struct {
+	db *sqlx.DB
+}
+
+func NewOraRepository(db *sqlx.DB) Repository {
+	return &oraRepo{db: db}
+}
+
+// groupColumn: ekspresi key & label per dimensi groupBy (kolom dari query dwellBase)
+type groupColumn struct {
+	Key   string
+	Label string
+}
+
+var groupColumns = map[string]groupColumn{
+	GroupByStage:    {Key: "CAST(NULL AS VARCHAR2(1))", Label: "CAST(NULL AS VARCHAR2(1))"},
+	GroupByDriver:   {Key: "TO_CHAR(NVL(DRIVERBY, 0))", Label: "NVL(DRIVERNAME, '-')"},
+	GroupByCustomer: {Key: "TO_CHAR(C_BPARTNER_ID)", Label: "CUSTOMER"},
+	GroupByWeek:     {Key: "TO_CHAR(TRUNC(CREATED, 'IW'), 'YYYY-MM-DD')", Label: `TO_CHAR(TRUNC(CREATED, 'IW'), 'IYYY-"W"IW')`},
+}
+
+// dwellBase: satu baris per event STS, DWELL = detik sampai event berikutnya SJ yang sama (NULL jika stage masih berjalan).
+// :1 dan :2 = rentang MovementDate SJ
+const dwellBase = `
+			SELECT
+				evt.EVENTTYPE AS STAGE,
+				evt.DRIVERBY,
+				drv.NAME AS DRIVERNAME,
+				mi.C_BPartner_ID,
+				cb.Value AS CUSTOMER,
+				evt.CREATED,
+				(LEAD(evt.CREATED) OVER (
+					PARTITION BY sts.M_INOUT_ID
+					ORDER BY evt.CREATED, evt.ADW_STS_EVENT_ID
+				) - evt.CREATED) * 86400 AS DWELL
+			FROM ADW_STS sts
+			JOIN ADW_STS_EVENT evt ON evt.ADW_STS_ID = sts.ADW_STS_ID
+			JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID
+			JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID
+			LEFT JOIN AD_USER drv ON evt.DRIVERBY = drv.AD_USER_ID
+			WHERE mi.MovementDate >= :1
+			  AND mi.MovementDate < :2
+			  AND mi.IsSoTrx = 'Y'
+			  AND evt.ISACTIVE = 'Y'
+			  AND mi.MOVEMENTDATE >= (
+					SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD'))
+					FROM ADW_STS_SETTING
+					WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
+				)`
+
+// GetDwellStats menghitung p50/p90/max dwell per stage (+ dimensi groupBy) dan jumlah yang melewati SLA
+func (r *oraRepo) GetDwellStats(ctx context.Context, f DwellFilter, targets []SLATarget) ([]DwellStat, error) {
+	group, ok := groupColumns[f.GroupBy]
+	if !ok {
+		return nil, ErrInvalidGroupBy
+	}
+
+	args := []interface{}{f.From, f.To}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT
+			STAGE,
+			` + group.Key + ` AS GROUPKEY,
+			` + group.Label + ` AS GROUPLABEL,
+			COUNT(*) AS CNT,
+			ROUND(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY DWELL)) AS P50SECONDS,
+			ROUND(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY DWELL)) AS P90SECONDS,
+			ROUND(MAX(DWELL)) AS MAXSECONDS,
+			MAX(SLASECONDS) AS SLASECONDS,
+			SUM(CASE WHEN DWELL > SLASECONDS THEN 1 ELSE 0 END) AS BREACHED
+		FROM (
+			SELECT d.*, sla.SLASECONDS
+			FROM (` + dwellBase + `
+			) d
+			LEFT JOIN (` + slaTable(targets, bind) + `) sla ON sla.STAGE = d.STAGE
+		)
+		WHERE DWELL IS NOT NULL
+		GROUP BY STAGE, ` + group.Key + `, ` + group.Label + `
+		ORDER BY STAGE, P90SECONDS DESC, GROUPKEY`
+
+	list := []DwellStat{}
+	if err := r.db.SelectContext(ctx, &list, query, args...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetSLABreaches mengambil SJ yang event terakhirnya (stage saat ini) sudah lebih lama dari target SLA.
+// Tidak dibatasi rentang tanggal, hanya cutoff global.
+func (r *oraRepo) GetSLABreaches(ctx context.Context, targets []SLATarget) ([]SLABreach, error) {
+	list := []SLABreach{}
+	if len(targets) == 0 {
+		return list, nil
+	}
+
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT M_INOUT_ID, DOCUMENTNO, MOVEMENTDATE, CUSTOMER, DRIVER, STAGE, SINCE, ELAPSEDSECONDS, SLASECONDS
+		FROM (
+			SELECT cur.*, sla.SLASECONDS
+			FROM (
+				SELECT
+					mi.M_InOut_ID,
+					mi.DocumentNo,
+					mi.MovementDate,
+					cb.Value AS CUSTOMER,
+					drv.NAME AS DRIVER,
+					evt.EVENTTYPE AS STAGE,
+					evt.CREATED AS SINCE,
+					ROUND((SYSDATE - evt.CREATED) * 86400) AS ELAPSEDSECONDS,
+					ROW_NUMBER() OVER (
+						PARTITION BY mi.M_InOut_ID
+						ORDER BY evt.CREATED DESC, evt.ADW_STS_EVENT_ID DESC
+					) AS RN
+				FROM ADW_STS sts
+				JOIN ADW_STS_EVENT evt ON evt.ADW_STS_ID = sts.ADW_STS_ID
+				JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID
+				JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID
+				LEFT JOIN AD_USER drv ON evt.DRIVERBY = drv.AD_USER_ID
+				WHERE mi.IsSoTrx = 'Y'
+				  AND evt.ISACTIVE = 'Y'
+				  AND mi.MOVEMENTDATE >= (
+						SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD'))
+						FROM ADW_STS_SETTING
+						WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
+					)
+			) cur
+			JOIN (` + slaTable(targets, bind) + `) sla ON sla.STAGE = cur.STAGE
+			WHERE cur.RN = 1
+		)
+		WHERE ELAPSEDSECONDS > SLASECONDS
+		ORDER BY ELAPSEDSECONDS - SLASECONDS DESC, M_INOUT_ID ASC`
+
+	if err := r.db.SelectContext(ctx, &list, query, args...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// slaTable menyusun tabel inline (STAGE, SLASECONDS) dari target SLA. Dipasang sesudah query utama
+// supaya placeholder tetap berurutan sesuai teks SQL.
+func slaTable(targets []SLATarget, bind func(interface{}) string) string {
+	if len(targets) == 0 {
+		return "SELECT CAST(NULL AS VARCHAR2(1)) AS STAGE, CAST(NULL AS NUMBER) AS SLASECONDS FROM DUAL WHERE 1 = 0"
+	}
+	rows := make([]string, 0, len(targets))
+	for _, t := range targets {
+		rows = append(rows, "SELECT "+bind(t.Stage)+" AS STAGE, "+bind(t.Seconds)+" AS SLASECONDS FROM DUAL")
+	}
+	return strings.Join(rows, " UNION ALL ")
+}
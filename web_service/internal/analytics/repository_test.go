@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSLATableBinds(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	// Seperti GetDwellStats: :1 dan :2 sudah dipakai rentang tanggal di dwellBase
+	args := []interface{}{from, to}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	targets := []SLATarget{
+		{Stage: "HO: DEL_TO_DPK", Seconds: 86400},
+		{Stage: "HO: DPK_FROM_DEL", Seconds: 3600},
+	}
+	got := slaTable(targets, bind)
+
+	want := "SELECT :3 AS STAGE, :4 AS SLASECONDS FROM DUAL UNION ALL SELECT :5 AS STAGE, :6 AS SLASECONDS FROM DUAL"
+	if got != want {
+		t.Errorf("slaTable = %q, want %q", got, want)
+	}
+	wantArgs := []interface{}{from, to, "HO: DEL_TO_DPK", int64(86400), "HO: DPK_FROM_DEL", int64(3600)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestSLATableEmpty(t *testing.T) {
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	got := slaTable(nil, bind)
+	if len(args) != 0 {
+		t.Errorf("slaTable tanpa target menambah bind %v", args)
+	}
+	if !strings.Contains(got, "WHERE 1 = 0") {
+		t.Errorf("slaTable tanpa target = %q, want tabel kosong", got)
+	}
+}
+
+// Placeholder go-ora harus berurutan sesuai kemunculan di teks SQL
+func TestDwellQueryBindOrder(t *testing.T) {
+	args := []interface{}{"from", "to"}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+	query := dwellBase + " LEFT JOIN (" + slaTable([]SLATarget{{Stage: "A", Seconds: 1}, {Stage: "B", Seconds: 2}}, bind) + ")"
+
+	matches := regexp.MustCompile(`:(\d+)`).FindAllStringSubmatch(query, -1)
+	if len(matches) != len(args) {
+		t.Fatalf("query punya %d placeholder, args %d", len(matches), len(args))
+	}
+	for i, m := range matches {
+		if m[1] != strconv.Itoa(i+1) {
+			t.Errorf("placeholder ke-%d = :%s, want :%d", i+1, m[1], i+1)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sts/web_service/internal/shared"
+	"time"
+)
+
+var ErrInvalidGroupBy = errors.New("groupBy harus stage, driver, customer atau week")
+
+type Service interface {
+	DwellStats(ctx context.Context, fromStr, toStr, groupBy string) ([]DwellStat, error)
+	SLAReport(ctx context.Context) (*SLAReport, error)
+}
+
+type service struct {
+	repo    Repository
+	targets []SLATarget
+}
+
+func NewService(r Repository, targets []SLATarget) Service {
+	return &service{repo: r, targets: targets}
+}
+
+// ParseSLATargets mengubah config SLA_TARGETS (status -> durasi Go, mis. 24h atau 90m) menjadi target per stage
+func ParseSLATargets(raw map[string]string) ([]SLATarget, error) {
+	targets := make([]SLATarget, 0, len(raw))
+	for stage, value := range raw {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("SLA_TARGETS %q: durasi %q tidak valid", stage, value)
+		}
+		targets = append(targets, SLATarget{Stage: stage, Seconds: int64(d.Seconds())})
+	}
+	slices.SortFunc(targets, func(a, b SLATarget) int {
+		return strings.Compare(a.Stage, b.Stage)
+	})
+	return targets, nil
+}
+
+func (s *service) DwellStats(ctx context.Context, fromStr, toStr, groupBy string) ([]DwellStat, error) {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
+
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	if groupBy == "" {
+		groupBy = GroupByStage
+	}
+	if _, ok := groupColumns[groupBy]; !ok {
+		return nil, ErrInvalidGroupBy
+	}
+
+	return s.repo.GetDwellStats(ctx, DwellFilter{From: dateFrom, To: dateTo, GroupBy: groupBy}, s.targets)
+}
+
+func (s *service) SLAReport(ctx context.Context) (*SLAReport, error) {
+	breaches, err := s.repo.GetSLABreaches(ctx, s.targets)
+	if err != nil {
+		return nil, err
+	}
+	return &SLAReport{Targets: s.targets, Breaches: breaches}, nil
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSLATargets(t *testing.T) {
+	got, err := ParseSLATargets(map[string]string{
+		"HO: MKT_TO_FAT":      "72h",
+		"HO: DEL_TO_DPK":      "24h",
+		"HO: DRIVER_CHECKOUT": "90m",
+		"HO: DPK_FROM_DEL":    "1h30m45s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Urut nama stage supaya bind SQL dan response stabil
+	want := []SLATarget{
+		{Stage: "HO: DEL_TO_DPK", Seconds: 86400},
+		{Stage: "HO: DPK_FROM_DEL", Seconds: 5445},
+		{Stage: "HO: DRIVER_CHECKOUT", Seconds: 5400},
+		{Stage: "HO: MKT_TO_FAT", Seconds: 259200},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSLATargets = %+v, want %+v", got, want)
+	}
+
+	if got, err := ParseSLATargets(nil); err != nil || len(got) != 0 {
+		t.Errorf("ParseSLATargets(nil) = %v, %v, want kosong", got, err)
+	}
+}
+
+func TestParseSLATargetsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"tanpa satuan", "24"},
+		{"bukan durasi", "sehari"},
+		{"kosong", ""},
+		{"nol", "0s"},
+		{"negatif", "-1h"},
+		{"satuan hari", "1d"},
+	}
+
+	for _, tt := range tests {
+		raw := map[string]string{"HO: DEL_TO_DPK": "24h", "HO: DPK_FROM_DEL": tt.value}
+		if got, err := ParseSLATargets(raw); err == nil {
+			t.Errorf("%s: ParseSLATargets(%q) = %+v, want error", tt.name, tt.value, got)
+		}
+	}
+}
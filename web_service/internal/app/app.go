@@ -1,21 +1,22 @@
 package app
 
 import (
-	// "context"
+	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"sts/web_service/internal/analytics"
 	"sts/web_service/internal/auth"
 	"sts/web_service/internal/handover"
+	"sts/web_service/internal/notification"
 	"sts/web_service/internal/shared"
 	"sts/web_service/internal/shared/config"
 	"sts/web_service/internal/shared/db"
+	"sts/web_service/internal/shared/storage"
 	"sts/web_service/internal/shipment"
 	"sts/web_service/internal/tms"
+	"sts/web_service/internal/whatsapp"
 	"time"
 
 	_ "github.com/glebarez/go-sqlite"
@@ -24,24 +25,20 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/jmoiron/sqlx"
-
-	// "github.com/mdp/qrterminal/v3"
-	"go.mau.fi/whatsmeow"
-	// "go.mau.fi/whatsmeow/store/sqlstore"
-	// waLog "go.mau.fi/whatsmeow/util/log"
 )
 
 type App struct {
-	Router   *chi.Mux
-	Config   *config.Config
-	DB       *sqlx.DB
-	Logger   *slog.Logger
-	WAClient *whatsmeow.Client
+	Router       *chi.Mux
+	Config       *config.Config
+	DB           *sqlx.DB
+	Logger       *slog.Logger
+	WhatsApp     *whatsapp.Manager
+	OutboxWorker *handover.OutboxWorker
+	NotifWorker  *notification.DeliveryWorker
 }
 
-func NewApp(cfg *config.Config, logger *slog.Logger) (*App, error) {
+func NewApp(cfg *config.Config, logger *slog.Logger) (_ *App, err error) {
 	var conn *sqlx.DB
-	var err error
 
 	conn, err = db.NewOracleDB(cfg.DBDriver, cfg.DBUrl)
 	if err != nil {
@@ -59,92 +56,115 @@ func NewApp(cfg *config.Config, logger *slog.Logger) (*App, error) {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", handover.IdempotencyHeader},
 		AllowCredentials: true,
 	}))
 
-	// // --- SETUP WHATSMEOW ---
-	// dbLogger := waLog.Stdout("Database", "DEBUG", true)
-
-	// // PERBAIKAN ERROR 1: Tambahkan context.Background() di argumen pertama
-	// dsn := "file:whatsapp_session.db?_pragma=foreign_keys(1)"
-	// container, err := sqlstore.New(context.Background(), "sqlite", dsn, dbLogger)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to create wa-sqlstore: %w", err)
-	// }
-
-	// deviceStore, err := container.GetFirstDevice(context.Background())
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to get wa-device: %w", err)
-	// }
-
-	// clientLog := waLog.Stdout("WhatsApp", "WARN", true)
-	// client := whatsmeow.NewClient(deviceStore, clientLog)
-
-	// --- LOGIKA LOGIN / CONNECT ---
-	// if client.Store.ID == nil {
-	// 	qrChan, _ := client.GetQRChannel(context.Background())
-	// 	err = client.Connect()
-	// 	if err != nil {
-	// 		return nil, err
-	// 	}
-
-	// 	go func() {
-	// 		for evt := range qrChan {
-	// 			if evt.Event == "code" {
-	// 				fmt.Println("\n--- SCAN QR INI DENGAN HP ANDA ---")
-	// 				// PERBAIKAN ERROR 2: Gunakan os.Stdout, bukan fmt.Stdout
-	// 				qrterminal.GenerateHalfBlock(evt.Code, qrterminal.L, os.Stdout)
-	// 				fmt.Println("----------------------------------\n")
-	// 			} else {
-	// 				fmt.Println("WA Login Event:", evt.Event)
-	// 			}
-	// 		}
-	// 	}()
-	// } else {
-	// 	err = client.Connect()
-	// 	if err != nil {
-	// 		return nil, err
-	// 	}
-	// }
-
-	// Testing Group = "120363407477018375@g.us"
-	// STS Group = "120363421649034694@g.us"
-	// waGroupID := "120363407477018375@g.us"
-	// notifSvc := handover.NewWANotificationService(client, waGroupID)
+	// Sesi whatsmeow; pairing / logout lewat endpoint admin /whatsapp.
+	// Baru dijalankan di Run, store-nya ditutup jika setup berikutnya gagal.
+	waManager, err := whatsapp.NewManager(context.Background(), cfg.WAEnabled, cfg.WASessionDSN, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			waManager.Shutdown()
+		}
+	}()
 
 	// REPO
 	authRepo := auth.NewOraRepository(conn)
 	shipmentRepo := shipment.NewOraRepository(conn)
 	handoverRepo := handover.NewOraRepository(conn)
 	tmsRepo := tms.NewOraRepository(conn)
+	notificationRepo := notification.NewOraRepository(conn)
+	analyticsRepo := analytics.NewOraRepository(conn)
 
 	// SERVICE & HANDLER
 	authService := auth.NewService(authRepo)
 	authHandler := auth.NewHandler(authService, tokenAuth)
 
-	shipmentService := shipment.NewService(shipmentRepo)
+	shipmentService := shipment.NewService(shipmentRepo, cfg)
 	shipmentHandler := shipment.NewHandler(shipmentService)
 
+	slaTargets, err := analytics.ParseSLATargets(cfg.SLATargets)
+	if err != nil {
+		return nil, err
+	}
+	analyticsService := analytics.NewService(analyticsRepo, slaTargets)
+	analyticsHandler := analytics.NewHandler(analyticsService)
+
 	// handoverService := handover.NewService(handoverRepo, notifSvc)
-	handoverService := handover.NewService(handoverRepo)
-	handoverHandler := handover.NewHandler(handoverService)
+	docNumbering, err := handover.NewDocNumbering(cfg.DocNoFormat, cfg.DocNoReset, cfg.DocNoPrefixes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document numbering config: %w", err)
+	}
+	if err := handover.ValidateGeofenceMode(cfg.GeofenceMode); err != nil {
+		return nil, err
+	}
+	pdfTemplate, err := handover.LoadPdfTemplate(cfg.PdfTemplatePath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init storage: %w", err)
+	}
+	channels := []notification.Channel{
+		notification.NewWhatsAppChannel(waManager),
+		notification.NewEmailChannel(notification.SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}),
+		notification.NewWebhookChannel(cfg.WebhookSecret),
+	}
+	// WA gateway hanya aktif jika WA_GATEWAY_URL diisi
+	if cfg.WAGatewayURL != "" {
+		channels = append(channels, notification.NewWAGatewayChannel(cfg.WAGatewayURL))
+	}
+	channelNames := make([]string, len(channels))
+	for i, c := range channels {
+		channelNames[i] = c.Name()
+	}
+
+	notifyRoutes, err := notification.LoadRoutes(cfg.NotifyRoutesPath, cfg.WAGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled := notification.FilterRoutes(notifyRoutes, channelNames...); len(enabled) < len(notifyRoutes) {
+		logger.Warn("notification routes to disabled channels ignored", "total", len(notifyRoutes), "enabled", len(enabled))
+		notifyRoutes = enabled
+	}
+	notifService := notification.NewService(notificationRepo, notifyRoutes, channelNames)
+	deliveryWorker := notification.NewDeliveryWorker(notificationRepo, cfg.NotifyMaxAttempts, logger, channels...)
+	handoverService := handover.NewService(handoverRepo, cfg, docNumbering, pdfTemplate, store, notifService)
+	handoverHandler := handover.NewHandler(handoverService, handoverRepo)
+	notificationHandler := notification.NewHandler(notifService, handoverService)
+	whatsappHandler := whatsapp.NewHandler(waManager)
+
+	// Worker outbox: PDF & notifikasi WA setelah commit
+	outboxWorker := handover.NewOutboxWorker(handoverRepo, handoverService, logger)
 
-	tmsService := tms.NewService(tmsRepo)
+	tmsService := tms.NewService(tmsRepo, cfg)
 	tmsHandler := tms.NewHandler(tmsService)
 
-	workDir, _ := os.Getwd()
-	// Pastikan folder ini mengarah ke root "uploads"
-	filesDir := http.Dir(filepath.Join(workDir, "uploads"))
+	// Perintah WhatsApp masuk (status SJ, driver, outstanding dpk)
+	waCommands := whatsapp.NewCommandHandler(waManager, whatsapp.NewOraRepository(conn), shipmentService, tmsService, logger)
+	waManager.AddEventHandler(waCommands.HandleEvent)
 
-	// Register route /uploads/* agar bisa diakses browser
-	setupFileServer(r, "/uploads", filesDir)
+	// Register route /uploads/* agar file di storage (local / S3) bisa diakses browser.
+	// Foto bukti (events/) hanya dengan link bertanda tangan dari API yang butuh login.
+	r.Get(storage.PublicPath+"/*", storage.Handler(store, storage.PublicPath, cfg.UploadSecret).ServeHTTP)
 
 	// Public Routes
 	r.Group(func(r chi.Router) {
 
 		authHandler.RegisterPublicRoutes(r)
 		tmsHandler.RegisterPublicRoutes(r)
+		handoverHandler.RegisterPublicRoutes(r)
 
 	})
 
@@ -158,16 +178,21 @@ func NewApp(cfg *config.Config, logger *slog.Logger) (*App, error) {
 		authHandler.RegisterProtectedRoutes(r)
 
 		shipmentHandler.RegisterProtectedRoutes(r)
+		analyticsHandler.RegisterProtectedRoutes(r)
 		handoverHandler.RegisterProtectedRoutes(r)
+		notificationHandler.RegisterProtectedRoutes(r)
+		whatsappHandler.RegisterProtectedRoutes(r)
 
 	})
 
 	return &App{
-		Router:   r,
-		Config:   cfg,
-		DB:       conn,
-		Logger:   logger,
-		// WAClient: client,
+		Router:       r,
+		Config:       cfg,
+		DB:           conn,
+		Logger:       logger,
+		OutboxWorker: outboxWorker,
+		NotifWorker:  deliveryWorker,
+		WhatsApp:     waManager,
 	}, nil
 }
 
@@ -179,15 +204,34 @@ func (a *App) Run() error {
 
 	a.listRoutes()
 
+	// Worker & sesi WA baru jalan setelah semua setup berhasil; handler perintah WA sudah terdaftar di NewApp
+	a.NotifWorker.Start()
+	a.OutboxWorker.Start()
+	a.WhatsApp.Start()
+
 	// Panggil Utils untuk menjalankan server + graceful shutdown
 	return shared.RunWithGracefulShutdown(srv, 5*time.Second, func() error {
+		// Worker dihentikan dulu karena masih butuh DB untuk menyelesaikan pesan
+		if a.OutboxWorker != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := a.OutboxWorker.Stop(ctx); err != nil {
+				a.Logger.Warn("outbox worker not stopped cleanly", "error", err)
+			}
+		}
+		if a.NotifWorker != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := a.NotifWorker.Stop(ctx); err != nil {
+				a.Logger.Warn("notification worker not stopped cleanly", "error", err)
+			}
+		}
+		if a.WhatsApp != nil {
+			a.WhatsApp.Shutdown()
+		}
 		if a.DB != nil {
 			return a.DB.Close()
 		}
-		if a.WAClient != nil {
-			a.WAClient.Disconnect()
-			fmt.Println("WhatsApp disconnected.")
-		}
 		return nil
 	})
 }
@@ -199,23 +243,3 @@ func (a *App) listRoutes() {
 		return nil
 	})
 }
-
-// Helper function untuk Static File Server
-func setupFileServer(r chi.Router, path string, root http.FileSystem) {
-	if strings.ContainsAny(path, "{}*") {
-		panic("FileServer does not permit any URL parameters.")
-	}
-
-	if path != "/" && path[len(path)-1] != '/' {
-		r.Get(path, http.RedirectHandler(path+"/", http.StatusMovedPermanently).ServeHTTP)
-		path += "/"
-	}
-	path += "*"
-
-	r.Get(path, func(w http.ResponseWriter, r *http.Request) {
-		rctx := chi.RouteContext(r.Context())
-		pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-		fs := http.StripPrefix(pathPrefix, http.FileServer(root))
-		fs.ServeHTTP(w, r)
-	})
-}
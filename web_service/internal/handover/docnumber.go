@@ -0,0 +1,118 @@
+package handover
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Periode reset nomor urut dokumen
+const (
+	ResetYearly  = "YEARLY"
+	ResetMonthly = "MONTHLY"
+	ResetNever   = "NEVER"
+)
+
+// Token format: {PREFIX} {YYYY} {YY} {MM} {SEQ} / {SEQ:n} (n = jumlah digit, diisi nol di depan)
+var docNoToken = regexp.MustCompile(`\{(PREFIX|YYYY|YY|MM|SEQ(?::(\d+))?)\}`)
+
+// DocSequencer memberi nomor urut berikutnya per prefix + periode di dalam transaksi bundle,
+// sehingga nomor ikut di-rollback jika bundle gagal dibuat.
+type DocSequencer interface {
+	NextDocSequence(ctx context.Context, tx *sqlx.Tx, prefix, period string) (int64, error)
+}
+
+// DocNumbering membentuk nomor bundle (mis. HOPT/2026/10/000123) dari konfigurasi
+type DocNumbering struct {
+	format   string
+	reset    string
+	prefixes map[string]string
+}
+
+func NewDocNumbering(format, reset string, prefixes map[string]string) (*DocNumbering, error) {
+	if !strings.Contains(format, "{SEQ") || len(docNoToken.FindAllString(format, -1)) == 0 {
+		return nil, fmt.Errorf("DOCNO_FORMAT %q harus mengandung {SEQ}", format)
+	}
+	if !strings.Contains(format, "{PREFIX}") {
+		return nil, fmt.Errorf("DOCNO_FORMAT %q harus mengandung {PREFIX}", format)
+	}
+
+	// Setelah counter reset nomor urut mulai dari 1 lagi, jadi periode harus ikut tertulis di nomor
+	// supaya nomor bundle tidak terulang
+	hasYear := strings.Contains(format, "{YYYY}") || strings.Contains(format, "{YY}")
+	switch reset {
+	case ResetYearly:
+		if !hasYear {
+			return nil, fmt.Errorf("DOCNO_FORMAT %q harus mengandung {YYYY} atau {YY} untuk DOCNO_RESET=YEARLY", format)
+		}
+	case ResetMonthly:
+		if !hasYear || !strings.Contains(format, "{MM}") {
+			return nil, fmt.Errorf("DOCNO_FORMAT %q harus mengandung {MM} dan {YYYY} / {YY} untuk DOCNO_RESET=MONTHLY", format)
+		}
+	case ResetNever:
+	default:
+		return nil, fmt.Errorf("DOCNO_RESET %q tidak dikenal (YEARLY, MONTHLY, NEVER)", reset)
+	}
+
+	for status, prefix := range prefixes {
+		if _, ok := statusRoles[status]; !ok {
+			return nil, fmt.Errorf("DOCNO_PREFIXES: status %q tidak dikenal", status)
+		}
+		if strings.ContainsAny(prefix, "{}") {
+			return nil, fmt.Errorf("DOCNO_PREFIXES: prefix %q tidak valid", prefix)
+		}
+	}
+
+	return &DocNumbering{format: format, reset: reset, prefixes: prefixes}, nil
+}
+
+// PrefixFor mengembalikan prefix bundle untuk status. false berarti status tersebut tidak membuat bundle.
+func (d *DocNumbering) PrefixFor(status string) (string, bool) {
+	prefix, ok := d.prefixes[status]
+	return prefix, ok
+}
+
+// period adalah kunci counter, nomor urut mulai dari 1 lagi setiap periode berganti
+func (d *DocNumbering) period(now time.Time) string {
+	switch d.reset {
+	case ResetMonthly:
+		return now.Format("200601")
+	case ResetYearly:
+		return now.Format("2006")
+	default:
+		return "-"
+	}
+}
+
+// Next mengambil nomor urut dari counter lalu memformatnya
+func (d *DocNumbering) Next(ctx context.Context, seq DocSequencer, tx *sqlx.Tx, prefix string, now time.Time) (string, error) {
+	n, err := seq.NextDocSequence(ctx, tx, prefix, d.period(now))
+	if err != nil {
+		return "", fmt.Errorf("gagal ambil nomor dokumen %s: %w", prefix, err)
+	}
+	return d.render(prefix, now, n), nil
+}
+
+func (d *DocNumbering) render(prefix string, now time.Time, n int64) string {
+	return docNoToken.ReplaceAllStringFunc(d.format, func(token string) string {
+		m := docNoToken.FindStringSubmatch(token)
+		switch {
+		case m[1] == "PREFIX":
+			return prefix
+		case m[1] == "YYYY":
+			return now.Format("2006")
+		case m[1] == "YY":
+			return now.Format("06")
+		case m[1] == "MM":
+			return now.Format("01")
+		default:
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, n)
+		}
+	})
+}
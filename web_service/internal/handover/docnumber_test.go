@@ -0,0 +1,154 @@
+package handover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDocNumberingRender(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		prefix string
+		n      int64
+		want   string
+	}{
+		{"{PREFIX}/{YYYY}/{MM}/{SEQ:6}", "HOPT", 123, "HOPT/2026/03/000123"},
+		{"{PREFIX}-{YY}{MM}-{SEQ:4}", "RE", 7, "RE-2603-0007"},
+		{"{PREFIX}{SEQ}", "HO", 42, "HO42"},
+		{"{PREFIX}/{SEQ:3}", "HO", 12345, "HO/12345"}, // lebih panjang dari lebar tidak dipotong
+		{"{PREFIX}/{SEQ:0}", "HO", 5, "HO/5"},
+		{"SJ {PREFIX} {YYYY}.{SEQ:2} {X}", "A", 1, "SJ A 2026.01 {X}"}, // token tidak dikenal dibiarkan
+	}
+
+	for _, tt := range tests {
+		d, err := NewDocNumbering(tt.format, ResetNever, nil)
+		if err != nil {
+			t.Fatalf("NewDocNumbering(%q): %v", tt.format, err)
+		}
+		if got := d.render(tt.prefix, now, tt.n); got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestDocNumberingPeriod(t *testing.T) {
+	tests := []struct {
+		reset string
+		now   time.Time
+		want  string
+	}{
+		{ResetYearly, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "2026"},
+		{ResetYearly, time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), "2026"},
+		{ResetMonthly, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), "202601"},
+		{ResetMonthly, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), "202602"},
+		{ResetMonthly, time.Date(2027, 12, 15, 0, 0, 0, 0, time.UTC), "202712"},
+		{ResetNever, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "-"},
+		{ResetNever, time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), "-"},
+	}
+
+	for _, tt := range tests {
+		d, err := NewDocNumbering("{PREFIX}/{YYYY}/{MM}/{SEQ}", tt.reset, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.period(tt.now); got != tt.want {
+			t.Errorf("period(%s, %s) = %q, want %q", tt.reset, tt.now.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestNewDocNumberingPeriodTokens(t *testing.T) {
+	// Format yang memuat periode reset-nya
+	tests := []struct {
+		format string
+		reset  string
+	}{
+		{"{PREFIX}/{YYYY}/{SEQ}", ResetYearly},
+		{"{PREFIX}-{YY}-{SEQ}", ResetYearly},
+		{"{PREFIX}/{YYYY}/{MM}/{SEQ}", ResetYearly},
+		{"{PREFIX}/{YYYY}/{MM}/{SEQ}", ResetMonthly},
+		{"{PREFIX}-{YY}{MM}-{SEQ:4}", ResetMonthly},
+		{"{PREFIX}/{SEQ:6}", ResetNever},
+	}
+	for _, tt := range tests {
+		if _, err := NewDocNumbering(tt.format, tt.reset, nil); err != nil {
+			t.Errorf("NewDocNumbering(%q, %s) = %v", tt.format, tt.reset, err)
+		}
+	}
+}
+
+func TestNewDocNumberingInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		reset    string
+		prefixes map[string]string
+	}{
+		{"tanpa SEQ", "{PREFIX}/{YYYY}", ResetYearly, nil},
+		{"tanpa PREFIX", "HO/{SEQ:5}", ResetYearly, nil},
+		{"reset tidak dikenal", "{PREFIX}{SEQ}", "DAILY", nil},
+		{"reset huruf kecil", "{PREFIX}{SEQ}", "yearly", nil},
+		{"status tidak dikenal", "{PREFIX}{SEQ}", ResetNever, map[string]string{"HO: LAIN": "X"}},
+		{"prefix dengan kurung kurawal", "{PREFIX}{SEQ}", ResetNever, map[string]string{StatusDelToDpk: "{SEQ}"}},
+		{"YEARLY tanpa tahun", "{PREFIX}/{SEQ:6}", ResetYearly, nil},
+		{"YEARLY hanya bulan", "{PREFIX}/{MM}/{SEQ:6}", ResetYearly, nil},
+		{"MONTHLY tanpa bulan", "{PREFIX}/{YYYY}/{SEQ}", ResetMonthly, nil},
+		{"MONTHLY tanpa tahun", "{PREFIX}/{MM}/{SEQ}", ResetMonthly, nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewDocNumbering(tt.format, tt.reset, tt.prefixes); err == nil {
+			t.Errorf("%s: NewDocNumbering = nil error", tt.name)
+		}
+	}
+}
+
+type fakeSequencer struct {
+	prefix, period string
+	next           int64
+	err            error
+}
+
+func (f *fakeSequencer) NextDocSequence(ctx context.Context, tx *sqlx.Tx, prefix, period string) (int64, error) {
+	f.prefix, f.period = prefix, period
+	return f.next, f.err
+}
+
+func TestDocNumberingNext(t *testing.T) {
+	d, err := NewDocNumbering("{PREFIX}/{YYYY}/{MM}/{SEQ:6}", ResetMonthly, map[string]string{StatusDelToDpk: "HOPT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+
+	prefix, ok := d.PrefixFor(StatusDelToDpk)
+	if !ok || prefix != "HOPT" {
+		t.Fatalf("PrefixFor = %q, %v", prefix, ok)
+	}
+	if _, ok := d.PrefixFor(StatusDpkFromDel); ok {
+		t.Error("PrefixFor status tanpa bundle = true")
+	}
+
+	seq := &fakeSequencer{next: 9}
+	got, err := d.Next(context.Background(), seq, nil, prefix, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "HOPT/2026/10/000009" {
+		t.Errorf("Next = %q", got)
+	}
+	if seq.prefix != "HOPT" || seq.period != "202610" {
+		t.Errorf("NextDocSequence dipanggil dengan %q/%q, want HOPT/202610", seq.prefix, seq.period)
+	}
+
+	seq.err = errors.New("db down")
+	if _, err := d.Next(context.Background(), seq, nil, prefix, now); !errors.Is(err, seq.err) {
+		t.Errorf("Next error = %v, want wrap %v", err, seq.err)
+	}
+}
@@ -1,6 +1,9 @@
 package handover
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 const (
 	AdClientID = 1000000
@@ -20,6 +23,7 @@ type HandoverNotifyDTO struct {
 	Time         string `db:"TIME"`
 	MovementDate string `db:"MOVEMENTDATE"`
 	TNKB         string `db:"TNKB"`
+	SppNo        string `db:"SPPNO"`
 }
 
 type ADWBundle struct {
@@ -32,6 +36,7 @@ type ADWBundle struct {
 	Description  string    `db:"DESCRIPTION"`
 	CreatedBy    int64     `db:"CREATEDBY"`
 	Attachment   string    `db:"ATTACHMENT"`
+	Signatures   BundleSignatures
 }
 
 type TrackingSJ struct {
@@ -59,8 +64,21 @@ type HandoverRequest struct {
 	Status          string  `json:"status" binding:"required"`
 	DriverBy        int64   `json:"driver_by,omitempty"`
 	CurrentCustomer int64   `json:"customer_id,omitempty"`
-	UserID          int64   `json:"user_id"` // Untuk CreatedBy
+	UserID          int64   `json:"user_id"` // Untuk CreatedBy, selalu ditimpa dengan user dari token
 	Notes           string  `json:"notes"`
+	Partial         bool    `json:"partial,omitempty"` // true: SJ yang valid tetap diproses, sisanya dilaporkan
+
+	// Tanda tangan PNG base64 (opsional), hanya untuk status penerimaan yang membuat bundle
+	GiverSignature    string `json:"giver_signature,omitempty"`
+	ReceiverSignature string `json:"receiver_signature,omitempty"`
+
+	// Foto bukti JPEG/PNG base64 (opsional), hanya untuk check-in / check-out driver
+	Photos []string `json:"photos,omitempty"`
+
+	// Posisi GPS driver, wajib untuk check-in / check-out
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Accuracy  *float64 `json:"accuracy,omitempty"` // meter
 }
 
 type NotificationDetail struct {
@@ -72,11 +90,168 @@ type NotificationDetail struct {
 }
 
 type BundleActorDTO struct {
-	PrevActorName    string `db:"PREV_ACTOR_NAME"`
-	CurrentActorName string `db:"CURRENT_ACTOR_NAME"`
-	EventType        string `db:"EVENTTYPE"`
-	HandoverTime     string `db:"HANDOVERTIME"`
-	ReceiveTime      string `db:"RECEIVETIME"`
+	PrevActorName    string `db:"PREV_ACTOR_NAME" json:"prev_actor_name"`
+	CurrentActorName string `db:"CURRENT_ACTOR_NAME" json:"current_actor_name"`
+	EventType        string `db:"EVENTTYPE" json:"event_type"`
+	HandoverTime     string `db:"HANDOVERTIME" json:"handover_time"`
+	ReceiveTime      string `db:"RECEIVETIME" json:"receive_time"`
+}
+
+const (
+	defaultBundlePageSize = 20
+	maxBundlePageSize     = 100
+)
+
+var ErrBundleNotFound = errors.New("bundle tidak ditemukan")
+
+var ErrEventNotFound = errors.New("event tidak ditemukan")
+
+// DriverVisit adalah satu kunjungan check-in / check-out: event acuan + semua SJ yang dicatat bersamaan
+type DriverVisit struct {
+	EventType  string    `db:"EVENTTYPE"`
+	DriverBy   int64     `db:"DRIVERBY"`
+	CustomerID int64     `db:"CURRENTCUSTOMER"`
+	Notes      string    `db:"NOTES"`
+	Created    time.Time `db:"CREATED"`
+	MInOutIDs  []int64   `db:"-"`
+}
+
+// Query string GET /handover/bundles (sebelum diolah service)
+type BundleQuery struct {
+	DateFrom   string
+	DateTo     string
+	BundleType string
+	ActorID    int64
+	CustomerID int64
+	Page       int
+	PageSize   int
+}
+
+// Filter ke repository
+type BundleFilter struct {
+	DateFrom   time.Time
+	DateTo     time.Time
+	BundleType string
+	ActorID    int64 // pembuat bundle atau prev/current actor di event bundle
+	CustomerID int64
+	Page       int
+	PageSize   int
+}
+
+// Header bundle untuk list & detail
+type BundleSummary struct {
+	ID            int64     `db:"ADW_STS_BUNDLE_ID" json:"id"`
+	DocumentNo    string    `db:"DOCUMENTNO" json:"document_no"`
+	BundleType    string    `db:"BUNDLE_TYPE" json:"bundle_type"`
+	MovementDate  time.Time `db:"MOVEMENTDATE" json:"movement_date"`
+	Description   string    `db:"DESCRIPTION" json:"description"`
+	CreatedBy     int64     `db:"CREATEDBY" json:"created_by"`
+	CreatedByName string    `db:"CREATEDBY_NAME" json:"created_by_name"`
+	Attachment    *string   `db:"ATTACHMENT" json:"attachment_path"`
+	AttachmentURL string    `db:"-" json:"attachment_url,omitempty"`
+	LineCount     int       `db:"LINE_COUNT" json:"line_count"`
+	Created       time.Time `db:"CREATED" json:"created"`
+}
+
+// Baris bundle beserta detail SJ-nya
+type BundleLineDTO struct {
+	Line          int       `db:"LINE" json:"line"`
+	MInOutID      int64     `db:"M_INOUT_ID" json:"m_inout_id"`
+	DocumentNo    string    `db:"DOCUMENTNO" json:"document_no"`
+	MovementDate  time.Time `db:"MOVEMENTDATE" json:"movement_date"`
+	Customer      string    `db:"CUSTOMER" json:"customer"`
+	Driver        string    `db:"DRIVER" json:"driver"`
+	TNKB          string    `db:"TNKB" json:"tnkb"`
+	SppNo         string    `db:"SPPNO" json:"spp_no"`
+	CurrentStatus string    `db:"STATUS" json:"current_status"`
+}
+
+type BundleDetail struct {
+	BundleSummary
+	Actors   *BundleActorDTO           `json:"actors"`
+	Lines    []BundleLineDTO           `json:"lines"`
+	Versions []BundleAttachmentVersion `json:"versions"`
+}
+
+// Versi PDF bundle (ADW_STS_BUNDLE_ATTACHMENT). Versi terbaru juga disalin ke ADW_STS_BUNDLE.ATTACHMENT.
+type BundleAttachmentVersion struct {
+	ID            int64     `db:"ADW_STS_BUNDLE_ATTACHMENT_ID" json:"id"`
+	Version       int       `db:"VERSION" json:"version"`
+	Attachment    string    `db:"ATTACHMENT" json:"attachment_path"`
+	URL           string    `db:"-" json:"attachment_url"`
+	Created       time.Time `db:"CREATED" json:"created"`
+	CreatedBy     int64     `db:"CREATEDBY" json:"created_by"`
+	CreatedByName string    `db:"CREATEDBY_NAME" json:"created_by_name"`
+	VerifyID      *string   `db:"VERIFYID" json:"-"`               // ID file di token QR
+	ContentHash   *string   `db:"CONTENTHASH" json:"content_hash"` // SHA-256 file PDF
+}
+
+type BundlePage struct {
+	Items    []BundleSummary `json:"items"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Total    int             `json:"total"`
+}
+
+// Hasil per SJ pada DocumentResult
+const (
+	DocumentAccepted = "ACCEPTED"
+	DocumentRejected = "REJECTED"
+
+	ReasonNotInitialized    = "NOT_INITIALIZED"
+	ReasonInvalidTransition = "INVALID_TRANSITION"
+)
+
+type DocumentResult struct {
+	MInOutID   int64  `json:"m_inout_id"`
+	Status     string `json:"status"`
+	ReasonCode string `json:"reason_code,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// Response ProcessHandover
+type HandoverResult struct {
+	BundleNo  string           `json:"bundle_no,omitempty"`
+	Accepted  int              `json:"accepted"`
+	Rejected  int              `json:"rejected"`
+	Documents []DocumentResult `json:"documents"`
+}
+
+func (r *HandoverResult) accept(mInOutID int64) {
+	r.Accepted++
+	r.Documents = append(r.Documents, DocumentResult{
+		MInOutID: mInOutID,
+		Status:   DocumentAccepted,
+	})
+}
+
+func (r *HandoverResult) reject(mInOutID int64, code, reason string) {
+	r.Rejected++
+	r.Documents = append(r.Documents, DocumentResult{
+		MInOutID:   mInOutID,
+		Status:     DocumentRejected,
+		ReasonCode: code,
+		Reason:     reason,
+	})
+}
+
+// IdempotencyRecord adalah baris ADW_STS_IDEMPOTENCY.
+// ResponseCode 0 artinya request dengan key ini masih diproses.
+type IdempotencyRecord struct {
+	Key          string    `db:"IDEMPOTENCYKEY"`
+	UserID       int64     `db:"AD_USER_ID"`
+	RequestHash  string    `db:"REQUESTHASH"`
+	ResponseCode int       `db:"RESPONSECODE"`
+	ResponseBody *string   `db:"RESPONSEBODY"`
+	Created      time.Time `db:"CREATED"`
+}
+
+// OutboxMessage adalah baris ADW_STS_OUTBOX (side effect setelah commit: PDF, notifikasi WA)
+type OutboxMessage struct {
+	ID        int64  `db:"ADW_STS_OUTBOX_ID"`
+	EventType string `db:"EVENTTYPE"`
+	Payload   string `db:"PAYLOAD"`
+	Attempts  int    `db:"ATTEMPTS"`
 }
 
 // Response ke client
@@ -0,0 +1,173 @@
+package handover
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Mode validasi geofence (GEOFENCE_MODE)
+const (
+	GeofenceModeOff    = "OFF"    // lokasi hanya dicatat
+	GeofenceModeFlag   = "FLAG"   // check-in di luar radius tetap diterima, ditandai untuk review
+	GeofenceModeReject = "REJECT" // check-in di luar radius ditolak
+)
+
+// ValidateGeofenceMode dipanggil saat startup supaya salah ketik GEOFENCE_MODE tidak diam-diam jadi FLAG
+func ValidateGeofenceMode(mode string) error {
+	switch mode {
+	case GeofenceModeOff, GeofenceModeFlag, GeofenceModeReject:
+		return nil
+	}
+	return fmt.Errorf("GEOFENCE_MODE %q tidak dikenal (OFF, FLAG, REJECT)", mode)
+}
+
+// Hasil validasi yang disimpan di ADW_STS_EVENT.GEOFENCESTATUS
+const (
+	GeofenceInside     = "INSIDE"
+	GeofenceOutside    = "OUTSIDE"
+	GeofenceNotDefined = "NO_GEOFENCE" // customer belum punya titik geofence
+	GeofenceNoGPS      = "NO_GPS"      // client tidak mengirim koordinat
+)
+
+const earthRadiusMeters = 6371000.0
+
+// Geofence per lokasi customer (ADW_STS_GEOFENCE)
+type Geofence struct {
+	LocationID int64   `db:"C_BPARTNER_LOCATION_ID"`
+	BPartnerID int64   `db:"C_BPARTNER_ID"`
+	Name       string  `db:"NAME"`
+	Latitude   float64 `db:"LATITUDE"`
+	Longitude  float64 `db:"LONGITUDE"`
+	Radius     float64 `db:"RADIUS"` // meter
+}
+
+// EventLocation adalah posisi GPS driver + hasil geofence yang ditulis ke ADW_STS_EVENT.
+// Latitude/Longitude nil jika client tidak mengirim GPS (GEOFENCESTATUS = NO_GPS).
+type EventLocation struct {
+	Latitude         *float64
+	Longitude        *float64
+	Accuracy         *float64
+	LocationID       *int64
+	GeofenceStatus   *string
+	GeofenceDistance *float64
+}
+
+// LocationError: koordinat GPS tidak dikirim / tidak valid. Handler memetakan ke HTTP 400.
+type LocationError struct {
+	Reason string `json:"reason"`
+}
+
+func (e *LocationError) Error() string {
+	return "lokasi GPS tidak valid: " + e.Reason
+}
+
+// GeofenceError: check-in di luar radius saat GEOFENCE_MODE=REJECT. Handler memetakan ke HTTP 422.
+type GeofenceError struct {
+	LocationID int64   `json:"c_bpartner_location_id"`
+	Location   string  `json:"location"`
+	Distance   float64 `json:"distance_m"`
+	Radius     float64 `json:"radius_m"`
+}
+
+func (e *GeofenceError) Error() string {
+	return fmt.Sprintf("check-in di luar area %s: jarak %.0fm, radius %.0fm", e.Location, e.Distance, e.Radius)
+}
+
+// eventLocation memvalidasi GPS dari request. Status selain check-in/check-out tidak mencatat lokasi.
+// Tanpa GPS event tetap disimpan dengan status NO_GPS; hanya check-in saat GEOFENCE_MODE=REJECT yang wajib GPS.
+func (s *service) eventLocation(ctx context.Context, req HandoverRequest) (*EventLocation, error) {
+	if req.Status != StatusDriverCheckin && req.Status != StatusDriverCheckout {
+		return nil, nil
+	}
+
+	if req.Latitude == nil || req.Longitude == nil {
+		if req.Status == StatusDriverCheckin && s.cfg.GeofenceMode == GeofenceModeReject {
+			return nil, &LocationError{Reason: "latitude dan longitude wajib untuk " + req.Status}
+		}
+		status := GeofenceNoGPS
+		return &EventLocation{GeofenceStatus: &status}, nil
+	}
+	if *req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180 {
+		return nil, &LocationError{Reason: "koordinat di luar jangkauan"}
+	}
+	if req.Accuracy != nil && *req.Accuracy < 0 {
+		return nil, &LocationError{Reason: "accuracy tidak boleh negatif"}
+	}
+
+	loc := &EventLocation{
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Accuracy:  req.Accuracy,
+	}
+
+	// Hanya check-in yang divalidasi terhadap geofence customer
+	if req.Status != StatusDriverCheckin || s.cfg.GeofenceMode == GeofenceModeOff {
+		return loc, nil
+	}
+
+	fences, err := s.repo.GetGeofencesByBPartner(ctx, req.CurrentCustomer)
+	if err != nil {
+		return nil, err
+	}
+	if len(fences) == 0 {
+		status := GeofenceNotDefined
+		loc.GeofenceStatus = &status
+		return loc, nil
+	}
+
+	// Customer bisa punya beberapa lokasi, pakai yang terdekat
+	nearest, distance := fences[0], math.Inf(1)
+	for _, f := range fences {
+		if d := haversineMeters(*loc.Latitude, *loc.Longitude, f.Latitude, f.Longitude); d < distance {
+			nearest, distance = f, d
+		}
+	}
+	distance = math.Round(distance*10) / 10
+
+	status := GeofenceInside
+	if !insideGeofence(distance, nearest.Radius, loc.Accuracy) {
+		status = GeofenceOutside
+		if s.cfg.GeofenceMode == GeofenceModeReject {
+			return nil, &GeofenceError{
+				LocationID: nearest.LocationID,
+				Location:   nearest.Name,
+				Distance:   distance,
+				Radius:     nearest.Radius,
+			}
+		}
+	}
+
+	loc.LocationID = &nearest.LocationID
+	loc.GeofenceStatus = &status
+	loc.GeofenceDistance = &distance
+	return loc, nil
+}
+
+// insideGeofence memberi toleransi sebesar akurasi GPS, maksimal sebesar radius itu sendiri
+// supaya pembacaan yang sangat tidak akurat tidak otomatis lolos.
+func insideGeofence(distance, radius float64, accuracy *float64) bool {
+	tolerance := 0.0
+	if accuracy != nil {
+		tolerance = math.Min(*accuracy, radius)
+	}
+	return distance-tolerance <= radius
+}
+
+func haversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// args mengembalikan nilai kolom lokasi event; semua NULL jika loc nil
+func (loc *EventLocation) args() []interface{} {
+	if loc == nil {
+		return []interface{}{nil, nil, nil, nil, nil, nil}
+	}
+	return []interface{}{loc.Latitude, loc.Longitude, loc.Accuracy, loc.LocationID, loc.GeofenceStatus, loc.GeofenceDistance}
+}
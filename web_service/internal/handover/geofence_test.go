@@ -0,0 +1,165 @@
+package handover
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"sts/web_service/internal/shared/config"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestHaversineMeters(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want, tol              float64
+	}{
+		{"titik sama", -6.2, 106.8, -6.2, 106.8, 0, 0.001},
+		{"1 derajat lintang", 0, 0, 1, 0, 111195, 1},
+		{"1 derajat bujur di khatulistiwa", 0, 0, 0, 1, 111195, 1},
+		{"Monas - Bundaran HI", -6.175392, 106.827153, -6.194980, 106.823010, 2226, 10},
+		{"antipoda", 0, 0, 0, 180, math.Pi * earthRadiusMeters, 1},
+	}
+	for _, tt := range tests {
+		got := haversineMeters(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+		if math.Abs(got-tt.want) > tt.tol {
+			t.Errorf("%s: haversineMeters = %.1f, want %.1f ± %.1f", tt.name, got, tt.want, tt.tol)
+		}
+		if back := haversineMeters(tt.lat2, tt.lng2, tt.lat1, tt.lng1); math.Abs(back-got) > 1e-6 {
+			t.Errorf("%s: jarak tidak simetris %.3f vs %.3f", tt.name, got, back)
+		}
+	}
+}
+
+func TestInsideGeofence(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance float64
+		radius   float64
+		accuracy *float64
+		want     bool
+	}{
+		{"di dalam", 50, 100, nil, true},
+		{"tepat di radius", 100, 100, nil, true},
+		{"di luar tanpa akurasi", 101, 100, nil, false},
+		{"di luar, tertolong akurasi", 120, 100, ptr(30.0), true},
+		{"akurasi dibatasi radius", 250, 100, ptr(500.0), false},
+		{"akurasi sebesar radius", 200, 100, ptr(500.0), true},
+	}
+	for _, tt := range tests {
+		if got := insideGeofence(tt.distance, tt.radius, tt.accuracy); got != tt.want {
+			t.Errorf("%s: insideGeofence = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type geofenceRepo struct {
+	Repository
+	fences []Geofence
+	calls  int
+}
+
+func (r *geofenceRepo) GetGeofencesByBPartner(ctx context.Context, bpartnerID int64) ([]Geofence, error) {
+	r.calls++
+	return r.fences, nil
+}
+
+func TestEventLocation(t *testing.T) {
+	// Dua lokasi customer; titik check-in ±111m di utara lokasi gudang
+	fences := []Geofence{
+		{LocationID: 1, Name: "Kantor", Latitude: -6.5, Longitude: 106.5, Radius: 100},
+		{LocationID: 2, Name: "Gudang", Latitude: -6.2, Longitude: 106.8, Radius: 100},
+	}
+	near := HandoverRequest{Status: StatusDriverCheckin, CurrentCustomer: 9, Latitude: ptr(-6.2005), Longitude: ptr(106.8)}
+	far := HandoverRequest{Status: StatusDriverCheckin, CurrentCustomer: 9, Latitude: ptr(-6.201), Longitude: ptr(106.8)}
+	noGPS := HandoverRequest{Status: StatusDriverCheckin, CurrentCustomer: 9}
+	checkoutNoGPS := HandoverRequest{Status: StatusDriverCheckout, CurrentCustomer: 9}
+
+	tests := []struct {
+		name      string
+		mode      string
+		fences    []Geofence
+		req       HandoverRequest
+		status    string // "" = tanpa GEOFENCESTATUS
+		location  int64
+		wantErr   any
+		wantNoLoc bool
+	}{
+		{"status lain tidak dicatat", GeofenceModeFlag, fences, HandoverRequest{Status: StatusDpkToDriver}, "", 0, nil, true},
+		{"OFF: hanya dicatat", GeofenceModeOff, fences, far, "", 0, nil, false},
+		{"OFF: tanpa GPS", GeofenceModeOff, fences, noGPS, GeofenceNoGPS, 0, nil, false},
+		{"FLAG: di dalam", GeofenceModeFlag, fences, near, GeofenceInside, 2, nil, false},
+		{"FLAG: di luar tetap diterima", GeofenceModeFlag, fences, far, GeofenceOutside, 2, nil, false},
+		{"FLAG: customer tanpa geofence", GeofenceModeFlag, nil, far, GeofenceNotDefined, 0, nil, false},
+		{"FLAG: tanpa GPS", GeofenceModeFlag, fences, noGPS, GeofenceNoGPS, 0, nil, false},
+		{"REJECT: di dalam", GeofenceModeReject, fences, near, GeofenceInside, 2, nil, false},
+		{"REJECT: di luar ditolak", GeofenceModeReject, fences, far, "", 0, &GeofenceError{}, false},
+		{"REJECT: check-in tanpa GPS ditolak", GeofenceModeReject, fences, noGPS, "", 0, &LocationError{}, false},
+		{"REJECT: check-out tanpa GPS diterima", GeofenceModeReject, fences, checkoutNoGPS, GeofenceNoGPS, 0, nil, false},
+		{"koordinat tidak valid", GeofenceModeFlag, fences, HandoverRequest{Status: StatusDriverCheckin, Latitude: ptr(91.0), Longitude: ptr(0.0)}, "", 0, &LocationError{}, false},
+	}
+
+	for _, tt := range tests {
+		repo := &geofenceRepo{fences: tt.fences}
+		s := &service{repo: repo, cfg: &config.Config{GeofenceMode: tt.mode}}
+		loc, err := s.eventLocation(context.Background(), tt.req)
+
+		switch want := tt.wantErr.(type) {
+		case *GeofenceError:
+			var ge *GeofenceError
+			if !errors.As(err, &ge) || ge.LocationID != 2 || ge.Radius != 100 || ge.Distance <= 100 {
+				t.Errorf("%s: error = %v, want GeofenceError lokasi 2", tt.name, err)
+			}
+			continue
+		case *LocationError:
+			var le *LocationError
+			if !errors.As(err, &le) {
+				t.Errorf("%s: error = %v, want LocationError", tt.name, err)
+			}
+			continue
+		case nil:
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+				continue
+			}
+		default:
+			t.Fatalf("wantErr %T", want)
+		}
+
+		if tt.wantNoLoc {
+			if loc != nil {
+				t.Errorf("%s: lokasi = %+v, want nil", tt.name, loc)
+			}
+			continue
+		}
+		gotStatus := ""
+		if loc.GeofenceStatus != nil {
+			gotStatus = *loc.GeofenceStatus
+		}
+		if gotStatus != tt.status {
+			t.Errorf("%s: GEOFENCESTATUS = %q, want %q", tt.name, gotStatus, tt.status)
+		}
+		if tt.location != 0 && (loc.LocationID == nil || *loc.LocationID != tt.location || loc.GeofenceDistance == nil) {
+			t.Errorf("%s: lokasi = %v, jarak %v, want lokasi %d", tt.name, loc.LocationID, loc.GeofenceDistance, tt.location)
+		}
+		if tt.mode == GeofenceModeOff && repo.calls != 0 {
+			t.Errorf("%s: geofence tetap dibaca saat OFF", tt.name)
+		}
+	}
+}
+
+func TestValidateGeofenceMode(t *testing.T) {
+	for _, mode := range []string{GeofenceModeOff, GeofenceModeFlag, GeofenceModeReject} {
+		if err := ValidateGeofenceMode(mode); err != nil {
+			t.Errorf("ValidateGeofenceMode(%q) = %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"", "flag", "WARN"} {
+		if err := ValidateGeofenceMode(mode); err == nil {
+			t.Errorf("ValidateGeofenceMode(%q) = nil, want error", mode)
+		}
+	}
+}
@@ -1,33 +1,72 @@
 package handover
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sts/web_service/internal/shared"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
+// Batas body /init & /process: foto & tanda tangan dikirim base64 (+1/3), ditambah field lain
+const maxHandoverBody = (maxPhotos*maxPhotoBytes+2*maxSignatureBytes)*4/3 + 64*1024
+
 type handler struct {
 	service Service
+	idem    IdempotencyStore
+}
+
+func NewHandler(s Service, idem IdempotencyStore) *handler {
+	return &handler{service: s, idem: idem}
 }
 
-func NewHandler(s Service) *handler {
-	return &handler{service: s}
+func (h *handler) RegisterPublicRoutes(r chi.Router) {
+	// Dibuka dari QR di PDF handover, tanpa login
+	r.Get("/verify/{token}", h.Verify)
 }
 
 func (h *handler) RegisterProtectedRoutes(r chi.Router) {
 	r.Route("/handover", func(r chi.Router) {
-		r.Post("/init", h.Init)        // Untuk scan pertama kali (Create)
-		r.Post("/process", h.Handover) // Untuk scan berikutnya (Update)
+		r.Post("/init", h.limitBody(h.idempotent(h.Init)))        // Untuk scan pertama kali (Create)
+		r.Post("/process", h.limitBody(h.idempotent(h.Handover))) // Untuk scan berikutnya (Update)
+
+		r.Get("/bundles", h.ListBundles)
+		r.Get("/bundles/{documentNo}", h.GetBundle)
+		r.Get("/bundles/{documentNo}/versions", h.GetBundleVersions)
+		r.Post("/bundles/{documentNo}/regenerate", h.RegenerateBundlePdf)
 	})
 }
 
+// limitBody menolak body yang lebih besar dari maxHandoverBody sebelum dibaca seluruhnya
+func (h *handler) limitBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxHandoverBody)
+		next(w, r)
+	}
+}
+
 func (h *handler) Init(w http.ResponseWriter, r *http.Request) {
 	var req HandoverRequest
 	if err := shared.BindAndValidate(r, &req); err != nil {
-		render.Status(r, http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, shared.ErrBodyTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		render.Status(r, status)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	actor, err := ActorFromContext(r.Context())
+	if err != nil {
+		render.Status(r, http.StatusUnauthorized)
 		render.JSON(w, r, APIResponse{
 			Success: false,
 			Message: err.Error(),
@@ -36,7 +75,10 @@ func (h *handler) Init(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Panggil service yang menangani batch
-	if err := h.service.ProcessInit(r.Context(), req); err != nil {
+	if err := h.service.ProcessInit(r.Context(), actor, req); err != nil {
+		if renderDomainError(w, r, err) {
+			return
+		}
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
@@ -55,7 +97,21 @@ func (h *handler) Init(w http.ResponseWriter, r *http.Request) {
 func (h *handler) Handover(w http.ResponseWriter, r *http.Request) {
 	var req HandoverRequest
 	if err := shared.BindAndValidate(r, &req); err != nil {
-		render.Status(r, http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, shared.ErrBodyTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		render.Status(r, status)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	actor, err := ActorFromContext(r.Context())
+	if err != nil {
+		render.Status(r, http.StatusUnauthorized)
 		render.JSON(w, r, APIResponse{
 			Success: false,
 			Message: err.Error(),
@@ -64,13 +120,26 @@ func (h *handler) Handover(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Memanggil ProcessHandover (Update)
-	if err := h.service.ProcessHandover(r.Context(), req); err != nil {
+	result, err := h.service.ProcessHandover(r.Context(), actor, req)
+	if err != nil {
 		log.Printf(
 			"[SERVICE] path=%s method=%s error=%v",
 			r.URL.Path,
 			r.Method,
 			err,
 		)
+		if errors.Is(err, ErrNoDocumentAccepted) {
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: err.Error(),
+				Data:    result,
+			})
+			return
+		}
+		if renderDomainError(w, r, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
@@ -84,5 +153,355 @@ func (h *handler) Handover(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, APIResponse{
 		Success: true,
 		Message: "Hanover Process Ok",
+		Data:    result,
+	})
+}
+
+func (h *handler) ListBundles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	query := BundleQuery{
+		DateFrom:   q.Get("dateFrom"),
+		DateTo:     q.Get("dateTo"),
+		BundleType: q.Get("bundleType"),
+	}
+
+	// Parameter angka opsional, kosong = tanpa filter
+	ints := []struct {
+		name string
+		dst  *int64
+	}{
+		{"actorId", &query.ActorID},
+		{"customerId", &query.CustomerID},
+	}
+	for _, p := range ints {
+		raw := q.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: p.name + " harus berupa angka valid",
+			})
+			return
+		}
+		*p.dst = v
+	}
+
+	for name, dst := range map[string]*int{"page": &query.Page, "pageSize": &query.PageSize} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: name + " harus berupa angka valid",
+			})
+			return
+		}
+		*dst = v
+	}
+
+	page, err := h.service.ListBundles(r.Context(), query)
+	if err != nil {
+		log.Printf(
+			"[SERVICE] path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed to get bundles",
+		})
+		return
+	}
+
+	if page.Items == nil {
+		page.Items = []BundleSummary{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "OK",
+		Data:    page,
+	})
+}
+
+func (h *handler) GetBundle(w http.ResponseWriter, r *http.Request) {
+	documentNo := bundleDocNoParam(r)
+	if documentNo == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Nomor bundle kosong",
+		})
+		return
+	}
+
+	detail, err := h.service.GetBundle(r.Context(), documentNo)
+	if errors.Is(err, ErrBundleNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf(
+			"[SERVICE] path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed to get bundle",
+		})
+		return
+	}
+
+	if detail.Lines == nil {
+		detail.Lines = []BundleLineDTO{}
+	}
+	if detail.Versions == nil {
+		detail.Versions = []BundleAttachmentVersion{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "OK",
+		Data:    detail,
 	})
 }
+
+func (h *handler) GetBundleVersions(w http.ResponseWriter, r *http.Request) {
+	documentNo := bundleDocNoParam(r)
+
+	versions, err := h.service.GetBundleVersions(r.Context(), documentNo)
+	if errors.Is(err, ErrBundleNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf(
+			"[SERVICE] path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed to get bundle versions",
+		})
+		return
+	}
+
+	if versions == nil {
+		versions = []BundleAttachmentVersion{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "OK",
+		Data:    versions,
+	})
+}
+
+func (h *handler) RegenerateBundlePdf(w http.ResponseWriter, r *http.Request) {
+	documentNo := bundleDocNoParam(r)
+
+	actor, err := ActorFromContext(r.Context())
+	if err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	version, err := h.service.RegenerateBundlePdf(r.Context(), actor, documentNo)
+	if err != nil {
+		log.Printf(
+			"[SERVICE] path=%s method=%s error=%v",
+			r.URL.Path,
+			r.Method,
+			err,
+		)
+		if errors.Is(err, ErrBundleNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		if renderDomainError(w, r, err) {
+			return
+		}
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Failed to regenerate bundle PDF",
+		})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "PDF regenerated",
+		Data:    version,
+	})
+}
+
+func (h *handler) Verify(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	clientHash := r.URL.Query().Get("sha256")
+
+	result, err := h.service.VerifyDocument(r.Context(), token, clientHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidVerifyToken):
+			render.Status(r, http.StatusBadRequest)
+		case errors.Is(err, ErrBundleNotFound), errors.Is(err, ErrVerifyFileNotFound):
+			render.Status(r, http.StatusNotFound)
+		default:
+			log.Printf(
+				"[SERVICE] path=%s method=%s error=%v",
+				r.URL.Path,
+				r.Method,
+				err,
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: "Failed to verify document",
+			})
+			return
+		}
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Dokumen terdaftar",
+		Data:    result,
+	})
+}
+
+// bundleDocNoParam membaca {documentNo}. Nomor bundle berisi "/" (mis. HOPT/2026/10/000123)
+// sehingga client wajib mengirimnya ter-encode (HOPT%2F2026%2F10%2F000123).
+func bundleDocNoParam(r *http.Request) string {
+	raw := chi.URLParam(r, "documentNo")
+	if documentNo, err := url.PathUnescape(raw); err == nil {
+		return documentNo
+	}
+	return raw
+}
+
+// renderDomainError menulis response untuk error bisnis yang sudah dikenal.
+// Return false jika error bukan error bisnis, supaya handler memakai response default-nya.
+func renderDomainError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var tErr *TransitionError
+	if errors.As(err, &tErr) {
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: tErr.Error(),
+			Data:    tErr,
+		})
+		return true
+	}
+
+	var sErr *SignatureError
+	if errors.As(err, &sErr) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: sErr.Error(),
+			Data:    sErr,
+		})
+		return true
+	}
+
+	var pErr *PhotoError
+	if errors.As(err, &pErr) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: pErr.Error(),
+			Data:    pErr,
+		})
+		return true
+	}
+
+	var lErr *LocationError
+	if errors.As(err, &lErr) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: lErr.Error(),
+			Data:    lErr,
+		})
+		return true
+	}
+
+	var gErr *GeofenceError
+	if errors.As(err, &gErr) {
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: gErr.Error(),
+			Data:    gErr,
+		})
+		return true
+	}
+
+	var fErr *ForbiddenError
+	if errors.As(err, &fErr) {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: fErr.Error(),
+			Data:    fErr,
+		})
+		return true
+	}
+
+	var dErr *DriverMismatchError
+	if errors.As(err, &dErr) {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: dErr.Error(),
+			Data:    dErr,
+		})
+		return true
+	}
+
+	return false
+}
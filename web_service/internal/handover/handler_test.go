@@ -0,0 +1,50 @@
+package handover
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// noIdempotency hanya untuk memastikan middleware idempotent aktif; body terlalu besar ditolak sebelum store dipakai
+type noIdempotency struct{ IdempotencyStore }
+
+func TestLimitBody(t *testing.T) {
+	auth := jwtauth.New("HS256", []byte("secret"), nil)
+	token, _, err := auth.Encode(map[string]interface{}{"sub": "100", "title": "DPK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Body JSON valid yang melewati batas: {"photos":["AAAA..."]}
+	big := append([]byte(`{"photos":["`), bytes.Repeat([]byte("A"), maxHandoverBody)...)
+	big = append(big, `"]}`...)
+
+	tests := []struct {
+		name string
+		idem IdempotencyStore
+		key  string
+	}{
+		{"tanpa Idempotency-Key", nil, ""},
+		{"dengan Idempotency-Key", noIdempotency{}, "abc-123"},
+	}
+
+	for _, tt := range tests {
+		h := &handler{idem: tt.idem}
+		req := httptest.NewRequest(http.MethodPost, "/handover/process", bytes.NewReader(big))
+		req = req.WithContext(jwtauth.NewContext(context.Background(), token, nil))
+		if tt.key != "" {
+			req.Header.Set(IdempotencyHeader, tt.key)
+		}
+		rec := httptest.NewRecorder()
+
+		h.limitBody(h.idempotent(h.Handover))(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: status = %d, want 413 (%s)", tt.name, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package handover
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sts/web_service/internal/shared"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	IdempotencyHeader  = "Idempotency-Key"
+	idempotencyTTLDays = 1
+	idempotencyLease   = 5 * time.Minute // reservasi tanpa response lebih lama dari ini dianggap crash
+	maxIdempotencyKey  = 128
+)
+
+var ErrIdempotencyKeyExists = errors.New("idempotency key sudah terdaftar")
+
+// IdempotencyStore menyimpan key + hash request + response agar retry dari scanner
+// bisa dijawab ulang tanpa menjalankan proses dua kali.
+type IdempotencyStore interface {
+	GetIdempotencyKey(ctx context.Context, key string, userID int64) (*IdempotencyRecord, error)
+	ReserveIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string) error
+	// ReclaimIdempotencyKey: true jika reservasi lama (RESPONSECODE = 0, lewat lease, hash sama) berhasil diambil alih
+	ReclaimIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string, lease time.Duration) (bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, userID int64, responseCode int, responseBody string) error
+	ReleaseIdempotencyKey(ctx context.Context, key string, userID int64) error
+}
+
+// responseRecorder meneruskan response ke client sambil menyimpan salinannya
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// hashRequest membuat hash dari method, path dan body JSON yang sudah dinormalisasi
+// (urutan key tidak berpengaruh).
+func hashRequest(r *http.Request, body []byte) string {
+	normalized := body
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if b, err := json.Marshal(payload); err == nil {
+			normalized = b
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotent membungkus handler POST. Tanpa header Idempotency-Key request diproses seperti biasa.
+func (h *handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" || h.idem == nil {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKey {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: "Idempotency-Key terlalu panjang",
+			})
+			return
+		}
+
+		actor, err := ActorFromContext(r.Context())
+		if err != nil {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: shared.ErrBodyTooLarge.Error(),
+			})
+			return
+		}
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: "invalid request body",
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := hashRequest(r, body)
+
+		err = h.idem.ReserveIdempotencyKey(r.Context(), key, actor.UserID, requestHash)
+		if errors.Is(err, ErrIdempotencyKeyExists) {
+			if !h.replayIdempotent(w, r, key, actor.UserID, requestHash) {
+				return
+			}
+			err = nil // reservasi lama diambil alih, request diproses ulang
+		}
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] reserve key=%s error=%v", key, err)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: "Gagal memproses Idempotency-Key",
+			})
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		// Error server tidak disimpan supaya retry berikutnya diproses ulang
+		// (transaksi sudah di-rollback oleh service).
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			if err := h.idem.ReleaseIdempotencyKey(ctx, key, actor.UserID); err != nil {
+				log.Printf("[IDEMPOTENCY] release key=%s error=%v", key, err)
+			}
+			return
+		}
+
+		// Response disimpan di luar transaksi bisnis. Jika gagal, baris tetap RESPONSECODE = 0 dan retry
+		// setelah lease memproses ulang; transisi status menolak handover yang sama dua kali.
+		if err := h.idem.CompleteIdempotencyKey(ctx, key, actor.UserID, rec.status, rec.body.String()); err != nil {
+			log.Printf("[IDEMPOTENCY] complete key=%s error=%v", key, err)
+		}
+	}
+}
+
+// replayIdempotent menjawab request yang key-nya sudah pernah dipakai. Mengembalikan true jika
+// reservasi sebelumnya lewat lease dan sudah diambil alih, sehingga request harus diproses.
+func (h *handler) replayIdempotent(w http.ResponseWriter, r *http.Request, key string, userID int64, requestHash string) bool {
+	stored, err := h.idem.GetIdempotencyKey(r.Context(), key, userID)
+	if err != nil || stored == nil {
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] get key=%s error=%v", key, err)
+		}
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Gagal memproses Idempotency-Key",
+		})
+		return false
+	}
+
+	if stored.RequestHash != requestHash {
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Idempotency-Key sudah dipakai untuk request dengan isi berbeda",
+		})
+		return false
+	}
+
+	if stored.ResponseCode == 0 {
+		// Request sebelumnya bisa saja crash setelah reservasi; setelah lease lewat retry boleh mengambil alih
+		reclaimed, err := h.idem.ReclaimIdempotencyKey(r.Context(), key, userID, requestHash, idempotencyLease)
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] reclaim key=%s error=%v", key, err)
+		}
+		if reclaimed {
+			return true
+		}
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Request dengan Idempotency-Key ini masih diproses",
+		})
+		return false
+	}
+
+	body := ""
+	if stored.ResponseBody != nil {
+		body = *stored.ResponseBody
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.ResponseCode)
+	w.Write([]byte(body))
+	return false
+}
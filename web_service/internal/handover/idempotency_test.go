@@ -0,0 +1,161 @@
+package handover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+type idemKey struct {
+	key    string
+	userID int64
+}
+
+// memIdempotency meniru ADW_STS_IDEMPOTENCY di memori; stale menandai reservasi yang sudah lewat lease
+type memIdempotency struct {
+	rows  map[idemKey]*IdempotencyRecord
+	stale map[idemKey]bool
+}
+
+func newMemIdempotency() *memIdempotency {
+	return &memIdempotency{rows: map[idemKey]*IdempotencyRecord{}, stale: map[idemKey]bool{}}
+}
+
+func (m *memIdempotency) GetIdempotencyKey(ctx context.Context, key string, userID int64) (*IdempotencyRecord, error) {
+	rec := m.rows[idemKey{key, userID}]
+	if rec == nil {
+		return nil, nil
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (m *memIdempotency) ReserveIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string) error {
+	k := idemKey{key, userID}
+	if m.rows[k] != nil {
+		return ErrIdempotencyKeyExists
+	}
+	m.rows[k] = &IdempotencyRecord{Key: key, UserID: userID, RequestHash: requestHash}
+	return nil
+}
+
+func (m *memIdempotency) ReclaimIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string, lease time.Duration) (bool, error) {
+	k := idemKey{key, userID}
+	rec := m.rows[k]
+	if rec == nil || rec.ResponseCode != 0 || rec.RequestHash != requestHash || !m.stale[k] {
+		return false, nil
+	}
+	m.stale[k] = false
+	return true, nil
+}
+
+func (m *memIdempotency) CompleteIdempotencyKey(ctx context.Context, key string, userID int64, responseCode int, responseBody string) error {
+	rec := m.rows[idemKey{key, userID}]
+	rec.ResponseCode, rec.ResponseBody = responseCode, &responseBody
+	return nil
+}
+
+func (m *memIdempotency) ReleaseIdempotencyKey(ctx context.Context, key string, userID int64) error {
+	delete(m.rows, idemKey{key, userID})
+	return nil
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	auth := jwtauth.New("HS256", []byte("secret"), nil)
+	token, _, err := auth.Encode(map[string]interface{}{"sub": "100", "title": "DPK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemIdempotency()
+	h := &handler{idem: store}
+	calls := 0
+	next := h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"success":true,"call":` + strconv.Itoa(calls) + `}`))
+	})
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/handover/process", strings.NewReader(body))
+		req = req.WithContext(jwtauth.NewContext(context.Background(), token, nil))
+		req.Header.Set(IdempotencyHeader, key)
+		rec := httptest.NewRecorder()
+		next(rec, req)
+		return rec
+	}
+
+	// Request pertama diproses dan response disimpan
+	first := do("k1", `{"status":"A","ids":[1,2]}`)
+	if first.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("request pertama = %d, calls %d", first.Code, calls)
+	}
+
+	tests := []struct {
+		name      string
+		key, body string
+		setup     func()
+		wantCode  int
+		wantCalls int
+		wantBody  string
+		replayed  bool
+	}{
+		{
+			name: "key & body sama: response tersimpan", key: "k1", body: `{"ids":[1,2],"status":"A"}`,
+			wantCode: http.StatusCreated, wantCalls: 1, wantBody: `{"success":true,"call":1}`, replayed: true,
+		},
+		{
+			name: "key sama, body berbeda", key: "k1", body: `{"status":"B","ids":[1,2]}`,
+			wantCode: http.StatusUnprocessableEntity, wantCalls: 1,
+		},
+		{
+			name: "reservasi masih berjalan", key: "k2", body: `{"status":"A"}`,
+			setup:    func() { store.ReserveIdempotencyKey(context.Background(), "k2", 100, hashFor(`{"status":"A"}`)) },
+			wantCode: http.StatusConflict, wantCalls: 1,
+		},
+		{
+			name: "reservasi lewat lease diambil alih", key: "k2", body: `{"status":"A"}`,
+			setup:    func() { store.stale[idemKey{"k2", 100}] = true },
+			wantCode: http.StatusCreated, wantCalls: 2, wantBody: `{"success":true,"call":2}`,
+		},
+		{
+			name: "setelah diambil alih response tersimpan", key: "k2", body: `{"status":"A"}`,
+			wantCode: http.StatusCreated, wantCalls: 2, wantBody: `{"success":true,"call":2}`, replayed: true,
+		},
+		{
+			name: "reservasi basi dengan body berbeda tidak diambil alih", key: "k3", body: `{"status":"B"}`,
+			setup: func() {
+				store.ReserveIdempotencyKey(context.Background(), "k3", 100, hashFor(`{"status":"A"}`))
+				store.stale[idemKey{"k3", 100}] = true
+			},
+			wantCode: http.StatusUnprocessableEntity, wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.setup != nil {
+			tt.setup()
+		}
+		rec := do(tt.key, tt.body)
+		if rec.Code != tt.wantCode || calls != tt.wantCalls {
+			t.Errorf("%s: status = %d, calls %d, want %d, calls %d (%s)", tt.name, rec.Code, calls, tt.wantCode, tt.wantCalls, rec.Body.String())
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, rec.Body.String(), tt.wantBody)
+		}
+		if got := rec.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+			t.Errorf("%s: Idempotent-Replayed = %v, want %v", tt.name, got, tt.replayed)
+		}
+	}
+}
+
+// hashFor menghitung hash request seperti middleware untuk POST /handover/process
+func hashFor(body string) string {
+	return hashRequest(httptest.NewRequest(http.MethodPost, "/handover/process", nil), []byte(body))
+}
@@ -0,0 +1,108 @@
+package handover
+
+import (
+	"context"
+	"log/slog"
+	"sts/web_service/internal/shared/poller"
+	"time"
+)
+
+// Jenis event di ADW_STS_OUTBOX
+const (
+	OutboxHandoverPdf       = "HANDOVER_PDF"
+	OutboxDriverVisitNotif  = "DRIVER_VISIT_NOTIF"
+	OutboxCheckoutNoSJNotif = "CHECKOUT_NO_SJ_NOTIF"
+)
+
+// Status pesan outbox
+const (
+	OutboxPending    = "PENDING"
+	OutboxProcessing = "PROCESSING"
+	OutboxDone       = "DONE"
+	OutboxFailed     = "FAILED"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	outboxBatchSize    = 10
+	outboxLease        = 5 * time.Minute // pesan PROCESSING yang lewat lease diambil ulang (worker crash)
+	outboxTaskTimeout  = 2 * time.Minute
+	outboxMaxAttempts  = 8
+)
+
+// outboxBackoff: 30s, 1m, 2m, 4m ... maksimal 1 jam
+var outboxBackoff = poller.Backoff{Base: 30 * time.Second, Max: time.Hour}
+
+type HandoverPdfPayload struct {
+	BundleNo    string  `json:"bundle_no"`
+	Status      string  `json:"status"`
+	MInOutIDs   []int64 `json:"m_inout_ids"`
+	RequestedBy int64   `json:"requested_by,omitempty"` // user pencatat versi PDF (0 = pembuat bundle)
+}
+
+type DriverVisitPayload struct {
+	Status     string  `json:"status"`
+	MInOutIDs  []int64 `json:"m_inout_ids"`
+	DriverBy   int64   `json:"driver_by"`
+	CustomerID int64   `json:"customer_id,omitempty"`
+	TNKBID     int64   `json:"tnkb_id,omitempty"`
+	Notes      string  `json:"notes,omitempty"`
+	Geofence   string  `json:"geofence,omitempty"` // GEOFENCESTATUS check-in
+}
+
+type CheckoutNoSJPayload struct {
+	CustomerID int64  `json:"customer_id"`
+	DriverBy   int64  `json:"driver_by"`
+	TNKBID     int64  `json:"tnkb_id,omitempty"`
+	Notes      string `json:"notes"`
+}
+
+// OutboxHandler menjalankan satu pesan outbox (diimplementasikan Service)
+type OutboxHandler interface {
+	HandleOutbox(ctx context.Context, msg OutboxMessage) error
+}
+
+// OutboxWorker mengambil pesan dari ADW_STS_OUTBOX dan menjalankannya dengan retry.
+type OutboxWorker struct {
+	*poller.Poller[OutboxMessage]
+}
+
+func NewOutboxWorker(repo Repository, handler OutboxHandler, logger *slog.Logger) *OutboxWorker {
+	return &OutboxWorker{poller.New[OutboxMessage](&outboxQueue{repo: repo, handler: handler}, poller.Options{
+		Name:         "outbox",
+		PollInterval: outboxPollInterval,
+		BatchSize:    outboxBatchSize,
+		Lease:        outboxLease,
+		TaskTimeout:  outboxTaskTimeout,
+		MaxAttempts:  outboxMaxAttempts,
+		Backoff:      outboxBackoff,
+	}, logger)}
+}
+
+// outboxQueue menghubungkan ADW_STS_OUTBOX ke poller
+type outboxQueue struct {
+	repo    Repository
+	handler OutboxHandler
+}
+
+func (q *outboxQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	return q.repo.ClaimOutbox(ctx, limit, lease)
+}
+
+func (q *outboxQueue) Process(ctx context.Context, msg OutboxMessage) error {
+	return q.handler.HandleOutbox(ctx, msg)
+}
+
+func (q *outboxQueue) Complete(ctx context.Context, msg OutboxMessage, started time.Time) error {
+	return q.repo.CompleteOutbox(ctx, msg, started)
+}
+
+func (q *outboxQueue) Fail(ctx context.Context, msg OutboxMessage, started time.Time, reason string, retryIn time.Duration, dead bool) error {
+	return q.repo.FailOutbox(ctx, msg, started, reason, retryIn, dead)
+}
+
+func (q *outboxQueue) Attempts(msg OutboxMessage) int { return msg.Attempts }
+
+func (q *outboxQueue) LogAttrs(msg OutboxMessage) []any {
+	return []any{"id", msg.ID, "event", msg.EventType}
+}
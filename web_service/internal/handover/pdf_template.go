@@ -0,0 +1,176 @@
+package handover
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Field yang bisa dipilih sebagai kolom tabel PDF handover
+const (
+	PdfFieldCustomer     = "customer"
+	PdfFieldDocumentNo   = "document_no"
+	PdfFieldMovementDate = "movement_date"
+	PdfFieldTNKB         = "tnkb"
+	PdfFieldDriver       = "driver"
+	PdfFieldSppNo        = "spp_no"
+)
+
+var pdfPaperSizes = map[string]bool{"A3": true, "A4": true, "A5": true, "LETTER": true, "LEGAL": true}
+
+var pdfFonts = map[string]bool{"Arial": true, "Helvetica": true, "Times": true, "Courier": true}
+
+type PdfColumn struct {
+	Field string  `json:"field"`
+	Label string  `json:"label"`
+	Width float64 `json:"width"` // mm, 0 = bagi rata sisa lebar halaman
+}
+
+// PdfTemplate mengatur layout PDF handover (dibaca dari file JSON PDF_TEMPLATE_PATH)
+type PdfTemplate struct {
+	Title          string      `json:"title"`
+	CompanyName    string      `json:"company_name"`
+	CompanyAddress string      `json:"company_address"`
+	LogoPath       string      `json:"logo_path"` // PNG/JPG, relatif ke working dir
+	LogoWidth      float64     `json:"logo_width"`
+	PaperSize      string      `json:"paper_size"`  // A3, A4, A5, Letter, Legal
+	Orientation    string      `json:"orientation"` // P / L
+	Font           string      `json:"font"`        // Arial, Helvetica, Times, Courier
+	Margin         float64     `json:"margin"`
+	QRSize         float64     `json:"qr_size"`
+	Columns        []PdfColumn `json:"columns"`
+	FooterText     string      `json:"footer_text"`
+}
+
+// DefaultPdfTemplate sama dengan layout lama yang di-hardcode
+func DefaultPdfTemplate() *PdfTemplate {
+	return &PdfTemplate{
+		Title:       "LIST HANDOVER",
+		PaperSize:   "A4",
+		Orientation: "P",
+		Font:        "Arial",
+		Margin:      10,
+		QRSize:      20,
+		LogoWidth:   25,
+		Columns: []PdfColumn{
+			{Field: PdfFieldCustomer, Label: "Customer", Width: 60},
+			{Field: PdfFieldDocumentNo, Label: "Shipment No", Width: 70},
+			{Field: PdfFieldMovementDate, Label: "Movement Date", Width: 50},
+		},
+	}
+}
+
+// LoadPdfTemplate membaca template dari file JSON. Path kosong atau file tidak ada = template default.
+// Field yang tidak diisi di file memakai nilai default.
+func LoadPdfTemplate(path string) (*PdfTemplate, error) {
+	tmpl := DefaultPdfTemplate()
+	if path == "" {
+		return tmpl, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tmpl, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal baca template PDF: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, tmpl); err != nil {
+		return nil, fmt.Errorf("template PDF %s tidak valid: %w", path, err)
+	}
+
+	if err := tmpl.validate(); err != nil {
+		return nil, fmt.Errorf("template PDF %s: %w", path, err)
+	}
+
+	return tmpl, nil
+}
+
+func (t *PdfTemplate) validate() error {
+	t.PaperSize = strings.ToUpper(t.PaperSize)
+	if !pdfPaperSizes[t.PaperSize] {
+		return fmt.Errorf("paper_size %q tidak didukung", t.PaperSize)
+	}
+
+	t.Orientation = strings.ToUpper(t.Orientation)
+	if t.Orientation != "P" && t.Orientation != "L" {
+		return fmt.Errorf("orientation %q harus P atau L", t.Orientation)
+	}
+
+	if !pdfFonts[t.Font] {
+		return fmt.Errorf("font %q tidak didukung", t.Font)
+	}
+
+	if len(t.Columns) == 0 {
+		return errors.New("columns tidak boleh kosong")
+	}
+	for _, c := range t.Columns {
+		if _, ok := pdfFieldValue(HandoverNotifyDTO{}, c.Field); !ok {
+			return fmt.Errorf("kolom %q tidak dikenal", c.Field)
+		}
+	}
+
+	if t.LogoPath != "" {
+		if _, err := os.Stat(t.LogoPath); err != nil {
+			return fmt.Errorf("logo %s: %w", t.LogoPath, err)
+		}
+	}
+
+	return nil
+}
+
+// paperSize mengubah nama kertas ke nama yang dikenal gofpdf
+func (t *PdfTemplate) paperSize() string {
+	switch t.PaperSize {
+	case "LETTER":
+		return "Letter"
+	case "LEGAL":
+		return "Legal"
+	default:
+		return t.PaperSize
+	}
+}
+
+// columnWidths menghitung lebar tiap kolom; kolom lebar 0 berbagi sisa lebar konten
+func (t *PdfTemplate) columnWidths(contentWidth, numberWidth float64) []float64 {
+	widths := make([]float64, len(t.Columns))
+	remaining := contentWidth - numberWidth
+	auto := 0
+	for i, c := range t.Columns {
+		widths[i] = c.Width
+		if c.Width <= 0 {
+			auto++
+		}
+		remaining -= c.Width
+	}
+	if auto > 0 && remaining > 0 {
+		for i := range widths {
+			if widths[i] <= 0 {
+				widths[i] = remaining / float64(auto)
+			}
+		}
+	}
+	return widths
+}
+
+func pdfFieldValue(item HandoverNotifyDTO, field string) (string, bool) {
+	switch field {
+	case PdfFieldCustomer:
+		return item.CustomerName, true
+	case PdfFieldDocumentNo:
+		return item.DocumentNo, true
+	case PdfFieldMovementDate:
+		return item.MovementDate, true
+	case PdfFieldTNKB:
+		return item.TNKB, true
+	case PdfFieldDriver:
+		return item.DriverName, true
+	case PdfFieldSppNo:
+		return item.SppNo, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,388 @@
+package handover
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // decoder PNG untuk image.Decode
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxPhotos          = 5
+	maxPhotoBytes      = 5 * 1024 * 1024
+	maxPhotoPixels     = 50_000_000 // cegah decompression bomb
+	photoThumbMaxSide  = 320
+	photoThumbQuality  = 80
+	exifDateTimeLayout = "2006:01:02 15:04:05"
+)
+
+// Foto bukti hanya untuk check-in / check-out driver
+var photoStatuses = map[string]bool{
+	StatusDriverCheckin:  true,
+	StatusDriverCheckout: true,
+}
+
+// PhotoError dikembalikan jika foto tidak valid. Handler memetakan ke HTTP 400.
+type PhotoError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+func (e *PhotoError) Error() string {
+	return fmt.Sprintf("foto ke-%d tidak valid: %s", e.Index+1, e.Reason)
+}
+
+// EventPhoto adalah foto yang sudah disimpan di storage, ditautkan ke ADW_STS_EVENT_PHOTO
+type EventPhoto struct {
+	PhotoKey    string
+	ThumbKey    string
+	ContentType string
+	FileSize    int
+	Width       int
+	Height      int
+	TakenAt     *time.Time // dari EXIF DateTimeOriginal, nil jika tidak ada
+}
+
+type decodedPhoto struct {
+	data        []byte
+	contentType string
+	img         image.Image
+	takenAt     *time.Time
+	orientation int // EXIF Orientation, 1 = tegak
+}
+
+// decodePhotos memvalidasi foto base64 dari request sebelum transaksi dibuka
+func decodePhotos(req HandoverRequest) ([]decodedPhoto, error) {
+	if len(req.Photos) == 0 {
+		return nil, nil
+	}
+	if !photoStatuses[req.Status] {
+		return nil, &PhotoError{Index: 0, Reason: "foto hanya untuk " + StatusDriverCheckin + " / " + StatusDriverCheckout}
+	}
+	if len(req.Photos) > maxPhotos {
+		return nil, &PhotoError{Index: maxPhotos, Reason: fmt.Sprintf("maksimal %d foto", maxPhotos)}
+	}
+
+	photos := make([]decodedPhoto, 0, len(req.Photos))
+	for i, raw := range req.Photos {
+		p, err := decodePhoto(raw)
+		if err != nil {
+			return nil, &PhotoError{Index: i, Reason: err.Error()}
+		}
+		photos = append(photos, p)
+	}
+	return photos, nil
+}
+
+func decodePhoto(raw string) (decodedPhoto, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "data:") {
+		idx := strings.Index(raw, ",")
+		if idx < 0 {
+			return decodedPhoto{}, fmt.Errorf("data URI tidak valid")
+		}
+		raw = raw[idx+1:]
+	}
+
+	if base64.StdEncoding.DecodedLen(len(raw)) > maxPhotoBytes {
+		return decodedPhoto{}, fmt.Errorf("ukuran maksimal %dMB", maxPhotoBytes/1024/1024)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return decodedPhoto{}, fmt.Errorf("base64 tidak valid")
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return decodedPhoto{}, fmt.Errorf("format harus JPEG atau PNG")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return decodedPhoto{}, fmt.Errorf("gambar tidak bisa dibaca")
+	}
+	if cfg.Width*cfg.Height > maxPhotoPixels {
+		return decodedPhoto{}, fmt.Errorf("resolusi %dx%d terlalu besar", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return decodedPhoto{}, fmt.Errorf("gambar tidak bisa dibaca")
+	}
+
+	p := decodedPhoto{data: data, contentType: contentType, img: img, orientation: 1}
+	if contentType == "image/jpeg" {
+		p.takenAt, p.orientation = exifMeta(data)
+	}
+	return p, nil
+}
+
+// size adalah lebar x tinggi sesuai orientasi tampilan
+func (p decodedPhoto) size() (int, int) {
+	b := p.img.Bounds()
+	if p.orientation >= 5 {
+		return b.Dy(), b.Dx()
+	}
+	return b.Dx(), b.Dy()
+}
+
+// storePhotos menyimpan foto asli + thumbnail ke storage.
+// cleanup menghapus file yang sudah tersimpan jika transaksi gagal.
+func (s *service) storePhotos(ctx context.Context, photos []decodedPhoto) ([]EventPhoto, func(), error) {
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			if err := s.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+				log.Printf("[SERVICE] photo cleanup key=%s error=%v", key, err)
+			}
+		}
+	}
+
+	prefix := "events/" + time.Now().Format("2006/01") + "/"
+	result := make([]EventPhoto, 0, len(photos))
+	for _, p := range photos {
+		id := uuid.New().String()
+		ext := ".jpg"
+		if p.contentType == "image/png" {
+			ext = ".png"
+		}
+
+		photoKey := prefix + id + ext
+		if err := s.store.Put(ctx, photoKey, bytes.NewReader(p.data), p.contentType); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("gagal simpan foto: %w", err)
+		}
+		stored = append(stored, photoKey)
+
+		// File asli disimpan apa adanya (EXIF tetap ada), thumbnail diputar supaya tegak
+		var thumb bytes.Buffer
+		thumbImg := orient(thumbnail(p.img, photoThumbMaxSide), p.orientation)
+		if err := jpeg.Encode(&thumb, thumbImg, &jpeg.Options{Quality: photoThumbQuality}); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("gagal membuat thumbnail: %w", err)
+		}
+		thumbKey := prefix + id + "_thumb.jpg"
+		if err := s.store.Put(ctx, thumbKey, &thumb, "image/jpeg"); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("gagal simpan thumbnail: %w", err)
+		}
+		stored = append(stored, thumbKey)
+
+		width, height := p.size()
+		result = append(result, EventPhoto{
+			PhotoKey:    photoKey,
+			ThumbKey:    thumbKey,
+			ContentType: p.contentType,
+			FileSize:    len(p.data),
+			Width:       width,
+			Height:      height,
+			TakenAt:     p.takenAt,
+		})
+	}
+
+	return result, cleanup, nil
+}
+
+// thumbnail mengecilkan gambar (box filter) sehingga sisi terpanjang = maxSide
+func thumbnail(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0 := b.Min.Y + y*h/th
+		y1 := b.Min.Y + (y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0 := b.Min.X + x*w/tw
+			x1 := b.Min.X + (x+1)*w/tw
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// orient memutar / membalik gambar sesuai tag EXIF Orientation (1-8) supaya tegak seperti di galeri HP
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 { // 5-8 menukar lebar dan tinggi
+		dw, dh = h, w
+	}
+
+	// Posisi piksel sumber untuk setiap piksel hasil (x, y)
+	source := map[int]func(x, y int) (int, int){
+		2: func(x, y int) (int, int) { return w - 1 - x, y },         // flip horizontal
+		3: func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }, // rotate 180
+		4: func(x, y int) (int, int) { return x, h - 1 - y },         // flip vertical
+		5: func(x, y int) (int, int) { return y, x },                 // transpose
+		6: func(x, y int) (int, int) { return y, h - 1 - x },         // rotate 90 CW
+		7: func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }, // transverse
+		8: func(x, y int) (int, int) { return w - 1 - y, x },         // rotate 90 CCW
+	}[orientation]
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := source(x, y)
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// exifMeta membaca DateTimeOriginal (fallback DateTime) dan Orientation dari segmen APP1 EXIF JPEG.
+// EXIF tidak menyimpan zona waktu, dianggap waktu lokal server (WIB). Orientation 1 jika tidak ada.
+func exifMeta(data []byte) (*time.Time, int) {
+	tiff := exifTIFF(data)
+	if tiff == nil || len(tiff) < 8 {
+		return nil, 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 1
+	}
+
+	ifd0 := order.Uint32(tiff[4:8])
+	var exifIFD uint32
+	var fallback string
+	orientation := 1
+
+	readIFD(tiff, order, ifd0, func(tag uint16, typ uint16, count, value uint32) {
+		switch tag {
+		case 0x8769: // ExifIFDPointer
+			exifIFD = value
+		case 0x0132: // DateTime
+			fallback = exifASCII(tiff, typ, count, value)
+		case 0x0112: // Orientation
+			if typ == 3 && count == 1 {
+				if o := int(exifShort(order, value)); o >= 1 && o <= 8 {
+					orientation = o
+				}
+			}
+		}
+	})
+
+	var original string
+	if exifIFD != 0 {
+		readIFD(tiff, order, exifIFD, func(tag uint16, typ uint16, count, value uint32) {
+			if tag == 0x9003 { // DateTimeOriginal
+				original = exifASCII(tiff, typ, count, value)
+			}
+		})
+	}
+
+	for _, v := range []string{original, fallback} {
+		if t, err := time.ParseInLocation(exifDateTimeLayout, strings.TrimSpace(v), time.Local); err == nil {
+			return &t, orientation
+		}
+	}
+	return nil, orientation
+}
+
+// exifTIFF mencari segmen APP1 "Exif\0\0" dan mengembalikan isi TIFF-nya
+func exifTIFF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if size < 2 || pos+2+size > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return segment[6:]
+		}
+		pos += 2 + size
+	}
+	return nil
+}
+
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, fn func(tag, typ uint16, count, value uint32)) {
+	if int(offset)+2 > len(tiff) {
+		return
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < n; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(tiff) {
+			return
+		}
+		fn(order.Uint16(tiff[entry:]), order.Uint16(tiff[entry+2:]),
+			order.Uint32(tiff[entry+4:]), order.Uint32(tiff[entry+8:]))
+	}
+}
+
+// exifShort mengambil nilai SHORT yang disimpan di 2 byte pertama field value IFD
+func exifShort(order binary.ByteOrder, value uint32) uint16 {
+	var b [4]byte
+	order.PutUint32(b[:], value)
+	return order.Uint16(b[:])
+}
+
+func exifASCII(tiff []byte, typ uint16, count, offset uint32) string {
+	if typ != 2 || count <= 4 || int(offset)+int(count) > len(tiff) {
+		return ""
+	}
+	return strings.TrimRight(string(tiff[offset:offset+count]), "\x00")
+}
@@ -0,0 +1,249 @@
+package handover
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exifJPEG membuat JPEG w x h dengan segmen APP1 EXIF berisi Orientation dan DateTime (jika tidak kosong)
+func exifJPEG(t *testing.T, w, h int, order binary.ByteOrder, orientation uint16, dateTime string) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		tag, typ uint16
+		count    uint32
+		value    []byte // maksimal 4 byte, atau data yang ditulis setelah IFD
+	}
+	var entries []entry
+	if orientation != 0 {
+		v := make([]byte, 4)
+		order.PutUint16(v, orientation)
+		entries = append(entries, entry{0x0112, 3, 1, v})
+	}
+	if dateTime != "" {
+		entries = append(entries, entry{0x0132, 2, uint32(len(dateTime) + 1), append([]byte(dateTime), 0)})
+	}
+
+	tiff := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	ifd := make([]byte, 2+12*len(entries)+4)
+	order.PutUint16(ifd, uint16(len(entries)))
+	dataOffset := uint32(8 + len(ifd))
+	var data []byte
+	for i, e := range entries {
+		p := ifd[2+12*i:]
+		order.PutUint16(p, e.tag)
+		order.PutUint16(p[2:], e.typ)
+		order.PutUint32(p[4:], e.count)
+		if len(e.value) <= 4 {
+			copy(p[8:], e.value)
+			continue
+		}
+		order.PutUint32(p[8:], dataOffset+uint32(len(data)))
+		data = append(data, e.value...)
+	}
+	tiff = append(append(tiff, ifd...), data...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+len(segment)))
+	app1 = append(app1, segment...)
+
+	jpg := img.Bytes()
+	out := append([]byte{}, jpg[:2]...) // SOI
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestExifMeta(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       binary.ByteOrder
+		orientation uint16
+		dateTime    string
+		wantOrient  int
+		wantTaken   string
+	}{
+		{"little endian rotate 90", binary.LittleEndian, 6, "2026:03:04 08:15:00", 6, "2026-03-04 08:15:00"},
+		{"big endian rotate 90", binary.BigEndian, 6, "2026:03:04 08:15:00", 6, "2026-03-04 08:15:00"},
+		{"big endian rotate 270", binary.BigEndian, 8, "", 8, ""},
+		{"little endian rotate 180", binary.LittleEndian, 3, "", 3, ""},
+		{"tegak", binary.LittleEndian, 1, "", 1, ""},
+		{"tanpa orientation", binary.BigEndian, 0, "2026:01:02 03:04:05", 1, "2026-01-02 03:04:05"},
+		{"orientation tidak valid", binary.LittleEndian, 9, "", 1, ""},
+		{"tanggal tidak valid", binary.LittleEndian, 6, "0000:00:00 00:00:00", 6, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takenAt, orientation := exifMeta(exifJPEG(t, 8, 8, tt.order, tt.orientation, tt.dateTime))
+			if orientation != tt.wantOrient {
+				t.Errorf("orientation = %d, want %d", orientation, tt.wantOrient)
+			}
+
+			var got string
+			if takenAt != nil {
+				got = takenAt.Format("2006-01-02 15:04:05")
+			}
+			if got != tt.wantTaken {
+				t.Errorf("takenAt = %q, want %q", got, tt.wantTaken)
+			}
+		})
+	}
+
+	// JPEG tanpa EXIF dan data bukan JPEG
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, image.NewGray(image.Rect(0, 0, 4, 4)), nil)
+	for name, data := range map[string][]byte{"tanpa EXIF": plain.Bytes(), "bukan JPEG": []byte("PNG"), "kosong": nil} {
+		if takenAt, orientation := exifMeta(data); takenAt != nil || orientation != 1 {
+			t.Errorf("%s: exifMeta = %v, %d, want nil, 1", name, takenAt, orientation)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// Sumber 3 x 2:
+	//   1 2 3
+	//   4 5 6
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	src.Pix = []uint8{1, 2, 3, 4, 5, 6}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{2, [][]uint8{{3, 2, 1}, {6, 5, 4}}},
+		{3, [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		{4, [][]uint8{{4, 5, 6}, {1, 2, 3}}},
+		{5, [][]uint8{{1, 4}, {2, 5}, {3, 6}}},
+		{6, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		{7, [][]uint8{{6, 3}, {5, 2}, {4, 1}}},
+		{8, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+		{0, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{9, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+	}
+
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+		b := dst.Bounds()
+		if b.Dx() != len(tt.want[0]) || b.Dy() != len(tt.want) {
+			t.Errorf("orient(%d) ukuran %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), len(tt.want[0]), len(tt.want))
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				got := color.GrayModel.Convert(dst.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+				if got != want {
+					t.Errorf("orient(%d) piksel (%d,%d) = %d, want %d", tt.orientation, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		wantW, wantH int
+	}{
+		{640, 480, 320, 240},
+		{480, 640, 240, 320},
+		{4000, 3000, 320, 240},
+		{3000, 4000, 240, 320},
+		{1000, 1000, 320, 320},
+		{320, 200, 320, 200}, // sudah kecil, tidak diubah
+		{100, 50, 100, 50},
+		{10000, 10, 320, 1}, // tinggi minimal 1
+		{10, 10000, 1, 320},
+	}
+
+	for _, tt := range tests {
+		got := thumbnail(image.NewGray(image.Rect(0, 0, tt.w, tt.h)), photoThumbMaxSide).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("thumbnail(%dx%d) = %dx%d, want %dx%d", tt.w, tt.h, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestDecodePhotoOrientation(t *testing.T) {
+	tests := []struct {
+		orientation  uint16
+		wantW, wantH int
+	}{
+		{1, 800, 600},
+		{3, 800, 600},
+		{6, 600, 800}, // foto portrait dari HP: piksel landscape + Orientation 6
+		{8, 600, 800},
+	}
+
+	for _, tt := range tests {
+		data := exifJPEG(t, 800, 600, binary.BigEndian, tt.orientation, "2026:03:04 08:15:00")
+		p, err := decodePhoto("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data))
+		if err != nil {
+			t.Fatalf("decodePhoto(orientation %d): %v", tt.orientation, err)
+		}
+
+		if w, h := p.size(); w != tt.wantW || h != tt.wantH {
+			t.Errorf("orientation %d: size = %dx%d, want %dx%d", tt.orientation, w, h, tt.wantW, tt.wantH)
+		}
+
+		thumb := orient(thumbnail(p.img, photoThumbMaxSide), p.orientation).Bounds()
+		wantThumbW, wantThumbH := tt.wantW*photoThumbMaxSide/800, tt.wantH*photoThumbMaxSide/800
+		if thumb.Dx() != wantThumbW || thumb.Dy() != wantThumbH {
+			t.Errorf("orientation %d: thumbnail = %dx%d, want %dx%d", tt.orientation, thumb.Dx(), thumb.Dy(), wantThumbW, wantThumbH)
+		}
+
+		if p.takenAt == nil || !p.takenAt.Equal(time.Date(2026, 3, 4, 8, 15, 0, 0, time.Local)) {
+			t.Errorf("orientation %d: takenAt = %v", tt.orientation, p.takenAt)
+		}
+	}
+}
+
+func TestDecodePhotosInvalid(t *testing.T) {
+	var small bytes.Buffer
+	jpeg.Encode(&small, image.NewGray(image.Rect(0, 0, 4, 4)), nil)
+	photo := base64.StdEncoding.EncodeToString(small.Bytes())
+
+	tests := []struct {
+		name   string
+		req    HandoverRequest
+		reason string
+	}{
+		{"status tanpa foto", HandoverRequest{Status: StatusDelToDpk, Photos: []string{photo}}, "foto hanya untuk"},
+		{"terlalu banyak", HandoverRequest{Status: StatusDriverCheckin, Photos: []string{photo, photo, photo, photo, photo, photo}}, "maksimal 5 foto"},
+		{"base64 rusak", HandoverRequest{Status: StatusDriverCheckin, Photos: []string{"%%%"}}, "base64 tidak valid"},
+		{"bukan gambar", HandoverRequest{Status: StatusDriverCheckout, Photos: []string{base64.StdEncoding.EncodeToString([]byte("halo"))}}, "format harus JPEG atau PNG"},
+	}
+
+	for _, tt := range tests {
+		_, err := decodePhotos(tt.req)
+		pe, ok := err.(*PhotoError)
+		if !ok || !strings.Contains(pe.Reason, tt.reason) {
+			t.Errorf("%s: decodePhotos = %v, want PhotoError %q", tt.name, err, tt.reason)
+		}
+	}
+
+	if photos, err := decodePhotos(HandoverRequest{Status: StatusDriverCheckin}); err != nil || photos != nil {
+		t.Errorf("tanpa foto: decodePhotos = %v, %v", photos, err)
+	}
+}
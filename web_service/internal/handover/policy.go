@@ -0,0 +1,138 @@
+package handover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sts/web_service/internal/shared"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// Role sesuai nilai AD_USER.TITLE yang masuk ke claim "title" (dibandingkan case-insensitive)
+const (
+	RoleAdmin     = shared.RoleAdmin
+	RoleDriver    = "driver"
+	RoleDPK       = "dpk"
+	RoleDelivery  = "delivery"
+	RoleMarketing = "marketing"
+	RoleFAT       = "apik staff accounting"
+)
+
+var ErrNoActor = errors.New("actor tidak ditemukan di token")
+
+// Actor adalah user yang melakukan scan, diambil dari JWT (bukan dari body request)
+type Actor struct {
+	UserID int64
+	Title  string
+}
+
+// statusRoles: status -> role yang boleh melakukannya.
+// Admin & FAT mengikuti akses menu di client (boleh semua proses dokumen).
+var statusRoles = map[string][]string{
+	StatusDelToDpk:       {RoleDelivery, RoleAdmin, RoleFAT},
+	StatusDpkFromDel:     {RoleDPK, RoleAdmin, RoleFAT},
+	StatusDpkToDriver:    {RoleDPK, RoleAdmin, RoleFAT},
+	StatusDriverCheckin:  {RoleDriver, RoleAdmin},
+	StatusDriverCheckout: {RoleDriver, RoleAdmin},
+	StatusDpkFromDriver:  {RoleDPK, RoleAdmin, RoleFAT},
+	StatusDpkToDel:       {RoleDPK, RoleAdmin, RoleFAT},
+	StatusDelFromDpk:     {RoleDelivery, RoleAdmin, RoleFAT},
+	StatusDelToMkt:       {RoleDelivery, RoleAdmin, RoleFAT},
+	StatusMktFromDel:     {RoleMarketing, RoleAdmin, RoleFAT},
+	StatusMktToFat:       {RoleMarketing, RoleAdmin, RoleFAT},
+	StatusFatFromMkt:     {RoleFAT, RoleAdmin},
+}
+
+// ForbiddenError dikembalikan jika role actor tidak boleh melakukan status tersebut.
+// Handler memetakan error ini ke HTTP 403.
+type ForbiddenError struct {
+	UserID       int64    `json:"user_id"`
+	Role         string   `json:"role"`
+	Status       string   `json:"status"`
+	AllowedRoles []string `json:"allowed_roles"`
+}
+
+func (e *ForbiddenError) Error() string {
+	role := e.Role
+	if role == "" {
+		role = "-"
+	}
+	return fmt.Sprintf("user %d dengan role %q tidak berhak melakukan %q (role yang diizinkan: %s)",
+		e.UserID, role, e.Status, strings.Join(e.AllowedRoles, ", "))
+}
+
+// DriverMismatchError dikembalikan jika driver check-in/check-out atas nama driver lain.
+// Handler memetakan error ini ke HTTP 403.
+type DriverMismatchError struct {
+	UserID   int64  `json:"user_id"`
+	DriverBy int64  `json:"driver_by"`
+	Status   string `json:"status"`
+}
+
+func (e *DriverMismatchError) Error() string {
+	return fmt.Sprintf("user %d tidak boleh melakukan %q atas nama driver %d", e.UserID, e.Status, e.DriverBy)
+}
+
+// ActorFromContext membaca claim "sub" dan "title" dari token yang sudah diverifikasi jwtauth
+func ActorFromContext(ctx context.Context) (Actor, error) {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return Actor{}, ErrNoActor
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil || userID <= 0 {
+		return Actor{}, ErrNoActor
+	}
+
+	title, _ := claims["title"].(string)
+
+	return Actor{UserID: userID, Title: title}, nil
+}
+
+// HasRole mengecek role actor secara case-insensitive
+func (a Actor) HasRole(roles ...string) bool {
+	title := strings.ToLower(strings.TrimSpace(a.Title))
+	for _, role := range roles {
+		if title == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize mengecek apakah actor boleh melakukan status tersebut
+func Authorize(actor Actor, status string) error {
+	roles := statusRoles[status]
+	if actor.HasRole(roles...) {
+		return nil
+	}
+
+	return &ForbiddenError{
+		UserID:       actor.UserID,
+		Role:         actor.Title,
+		Status:       status,
+		AllowedRoles: roles,
+	}
+}
+
+// AuthorizeDriver menentukan driver_by untuk check-in/check-out.
+// Actor ber-role driver hanya boleh atas namanya sendiri: driver_by kosong diisi dari token,
+// driver_by lain ditolak. Role lain (admin) dan status lain memakai driver_by dari request apa adanya.
+func AuthorizeDriver(actor Actor, status string, driverBy int64) (int64, error) {
+	if status != StatusDriverCheckin && status != StatusDriverCheckout {
+		return driverBy, nil
+	}
+	if !actor.HasRole(RoleDriver) {
+		return driverBy, nil
+	}
+	if driverBy != 0 && driverBy != actor.UserID {
+		return 0, &DriverMismatchError{UserID: actor.UserID, DriverBy: driverBy, Status: status}
+	}
+	return actor.UserID, nil
+}
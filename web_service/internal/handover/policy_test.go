@@ -0,0 +1,160 @@
+package handover
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+func TestAuthorizeMatrix(t *testing.T) {
+	roles := []string{RoleAdmin, RoleDriver, RoleDPK, RoleDelivery, RoleMarketing, RoleFAT}
+
+	// Matriks lengkap status x role, ditulis ulang di sini supaya perubahan statusRoles terlihat di review
+	allowed := map[string][]string{
+		StatusDelToDpk:       {RoleDelivery, RoleAdmin, RoleFAT},
+		StatusDpkFromDel:     {RoleDPK, RoleAdmin, RoleFAT},
+		StatusDpkToDriver:    {RoleDPK, RoleAdmin, RoleFAT},
+		StatusDriverCheckin:  {RoleDriver, RoleAdmin},
+		StatusDriverCheckout: {RoleDriver, RoleAdmin},
+		StatusDpkFromDriver:  {RoleDPK, RoleAdmin, RoleFAT},
+		StatusDpkToDel:       {RoleDPK, RoleAdmin, RoleFAT},
+		StatusDelFromDpk:     {RoleDelivery, RoleAdmin, RoleFAT},
+		StatusDelToMkt:       {RoleDelivery, RoleAdmin, RoleFAT},
+		StatusMktFromDel:     {RoleMarketing, RoleAdmin, RoleFAT},
+		StatusMktToFat:       {RoleMarketing, RoleAdmin, RoleFAT},
+		StatusFatFromMkt:     {RoleFAT, RoleAdmin},
+	}
+	if len(allowed) != len(statusRoles) {
+		t.Fatalf("matriks test punya %d status, statusRoles %d", len(allowed), len(statusRoles))
+	}
+
+	for status, okRoles := range allowed {
+		for _, role := range roles {
+			want := false
+			for _, r := range okRoles {
+				if r == role {
+					want = true
+				}
+			}
+
+			err := Authorize(Actor{UserID: 1, Title: role}, status)
+			if got := err == nil; got != want {
+				t.Errorf("Authorize(%q, %q) = %v, want allowed=%v", role, status, err, want)
+			}
+		}
+	}
+}
+
+func TestAuthorizeTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		title  string
+		status string
+		want   bool
+	}{
+		{"case-insensitive", "Driver", StatusDriverCheckin, true},
+		{"spasi di pinggir", "  DPK ", StatusDpkFromDel, true},
+		{"FAT dengan spasi", "APIK Staff Accounting", StatusFatFromMkt, true},
+		{"title kosong", "", StatusDelToDpk, false},
+		{"title tidak dikenal", "gudang", StatusDelToDpk, false},
+		{"status tidak dikenal", RoleAdmin, "LAIN", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(Actor{UserID: 7, Title: tt.title}, tt.status)
+			if got := err == nil; got != tt.want {
+				t.Fatalf("Authorize = %v, want allowed=%v", err, tt.want)
+			}
+			if tt.want {
+				return
+			}
+
+			var fe *ForbiddenError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Authorize = %T, want *ForbiddenError", err)
+			}
+			want := &ForbiddenError{UserID: 7, Role: tt.title, Status: tt.status, AllowedRoles: statusRoles[tt.status]}
+			if !reflect.DeepEqual(fe, want) {
+				t.Errorf("ForbiddenError = %+v, want %+v", fe, want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeDriver(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		status   string
+		driverBy int64
+		want     int64
+		wantErr  bool
+	}{
+		{"driver tanpa driver_by diisi dari token", RoleDriver, StatusDriverCheckin, 0, 7, false},
+		{"driver dengan ID sendiri", "Driver", StatusDriverCheckout, 7, 7, false},
+		{"driver atas nama driver lain", RoleDriver, StatusDriverCheckin, 8, 0, true},
+		{"checkout atas nama driver lain", RoleDriver, StatusDriverCheckout, 8, 0, true},
+		{"admin boleh atas nama driver lain", RoleAdmin, StatusDriverCheckin, 8, 8, false},
+		{"status bukan check-in/check-out", RoleDPK, StatusDpkToDriver, 8, 8, false},
+	}
+
+	for _, tt := range tests {
+		got, err := AuthorizeDriver(Actor{UserID: 7, Title: tt.title}, tt.status, tt.driverBy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: AuthorizeDriver error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			var dErr *DriverMismatchError
+			if !errors.As(err, &dErr) || dErr.DriverBy != tt.driverBy {
+				t.Errorf("%s: AuthorizeDriver error = %#v, want *DriverMismatchError", tt.name, err)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: driver_by = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		want    Actor
+		wantErr bool
+	}{
+		{"lengkap", map[string]interface{}{"sub": "42", "title": "Driver"}, Actor{UserID: 42, Title: "Driver"}, false},
+		{"tanpa title", map[string]interface{}{"sub": "42"}, Actor{UserID: 42}, false},
+		{"tanpa sub", map[string]interface{}{"title": "Driver"}, Actor{}, true},
+		{"sub bukan angka", map[string]interface{}{"sub": "abc"}, Actor{}, true},
+		{"sub nol", map[string]interface{}{"sub": "0"}, Actor{}, true},
+	}
+
+	auth := jwtauth.New("HS256", []byte("secret"), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := auth.Encode(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := jwtauth.NewContext(context.Background(), token, nil)
+
+			got, err := ActorFromContext(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ActorFromContext error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ActorFromContext = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ActorFromContext(context.Background()); !errors.Is(err, ErrNoActor) {
+		t.Errorf("ActorFromContext tanpa token = %v, want ErrNoActor", err)
+	}
+}
@@ -2,28 +2,50 @@ package handover
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sts/web_service/internal/shared/db"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	go_ora "github.com/sijms/go-ora/v2"
 )
 
 type Repository interface {
 	BeginTx(ctx context.Context) (*sqlx.Tx, error) // Tambahkan ini
 	CreateBundle(ctx context.Context, tx *sqlx.Tx, bundle ADWBundle, stsIDs []int64) error
 	CreateBatch(ctx context.Context, tx *sqlx.Tx, entities []TrackingSJ, eventType string, notes string) error
-	UpdateBatch(ctx context.Context, tx *sqlx.Tx, entities []TrackingSJ, eventType string, notes string) error
+	UpdateBatch(ctx context.Context, tx *sqlx.Tx, entities []TrackingSJ, eventType string, notes string, loc *EventLocation) ([]int64, error)
 
 	GetByMInOutIDs(ctx context.Context, tx *sqlx.Tx, ids []int64) (map[int64]TrackingSJ, error)
 	GetByCustomerIDDriverID(ctx context.Context, customerID, driverID int64) ([]TrackingSJ, error)
 	GetNotificationDetails(ctx context.Context, mInOutIDs []int64, driverID int64) ([]HandoverNotifyDTO, error)
-	LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req HandoverRequest) error
+	LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req HandoverRequest, loc *EventLocation) (int64, error)
+	InsertEventPhotos(ctx context.Context, tx *sqlx.Tx, eventIDs []int64, photos []EventPhoto, userID int64) error
 	GetNotifLogActivityOnlyDetail(ctx context.Context, customerID, driverID int64) ([]HandoverNotifyDTO, error)
+	GetGeofencesByBPartner(ctx context.Context, bpartnerID int64) ([]Geofence, error)
+	GetDriverVisit(ctx context.Context, eventID int64) (*DriverVisit, error)
 
 	GetBundleActors(ctx context.Context, bundleDocNo string) (*BundleActorDTO, error)
+	GetBundleSignatures(ctx context.Context, bundleDocNo string) (BundleSignatures, error)
+	ListBundles(ctx context.Context, f BundleFilter) ([]BundleSummary, int, error)
+	GetBundleByDocumentNo(ctx context.Context, documentNo string) (*BundleSummary, error)
+	GetBundleLines(ctx context.Context, bundleID int64) ([]BundleLineDTO, error)
 
-	UpdateBundleAttachment(ctx context.Context, documentNo, filePath string) error
+	AddBundleAttachment(ctx context.Context, documentNo string, att BundleAttachmentVersion) (*BundleAttachmentVersion, error)
+	GetBundleAttachments(ctx context.Context, bundleID int64) ([]BundleAttachmentVersion, error)
+
+	IdempotencyStore
+	DocSequencer
+
+	EnqueueOutbox(ctx context.Context, tx *sqlx.Tx, eventType string, payload interface{}) error
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	CompleteOutbox(ctx context.Context, msg OutboxMessage, started time.Time) error
+	FailOutbox(ctx context.Context, msg OutboxMessage, started time.Time, errMsg string, retryIn time.Duration, dead bool) error
 }
 
 type oraRepo struct {
@@ -58,17 +80,19 @@ func (r *oraRepo) CreateBundle(ctx context.Context, tx *sqlx.Tx, bundle ADWBundl
 		return fmt.Errorf("gagal ambil sequence bundle: %w", err)
 	}
 
-	// 2. Insert Header dengan ATTACHMENT
+	// 2. Insert Header dengan ATTACHMENT & tanda tangan (BLOB, NULL jika tidak ada)
 	queryHeader := `
         INSERT INTO plastik.ADW_STS_BUNDLE (
             ADW_STS_BUNDLE_ID, AD_CLIENT_ID, AD_ORG_ID, DOCUMENTNO, 
             BUNDLE_TYPE, MOVEMENTDATE, DESCRIPTION, ATTACHMENT,
+            GIVERSIGNATURE, RECEIVERSIGNATURE,
             CREATED, CREATEDBY, UPDATED, UPDATEDBY, ISACTIVE
-        ) VALUES (:1, :2, :3, :4, :5, SYSDATE, :6, :7, SYSDATE, :8, SYSDATE, :9, 'Y')`
+        ) VALUES (:1, :2, :3, :4, :5, SYSDATE, :6, :7, :8, :9, SYSDATE, :10, SYSDATE, :11, 'Y')`
 
 	_, err := tx.ExecContext(ctx, queryHeader,
 		nextBundleID, AdClientID, AdOrgID, bundle.DocumentNo,
 		bundle.BundleType, bundle.Description, bundle.Attachment, // ← Tambahkan attachment
+		blobArg(bundle.Signatures.Giver), blobArg(bundle.Signatures.Receiver),
 		bundle.CreatedBy, bundle.CreatedBy)
 	if err != nil {
 		return fmt.Errorf("gagal insert bundle header: %w", err)
@@ -99,18 +123,120 @@ func (r *oraRepo) CreateBundle(ctx context.Context, tx *sqlx.Tx, bundle ADWBundl
 	return nil
 }
 
-func (r *oraRepo) UpdateBundleAttachment(ctx context.Context, documentNo, filePath string) error {
-	query := `
-		UPDATE plastik.ADW_STS_BUNDLE 
-		SET ATTACHMENT = :1, UPDATED = SYSDATE 
-		WHERE DOCUMENTNO = :2`
+// AddBundleAttachment mencatat file PDF (att.Attachment) sebagai versi berikutnya dan menjadikannya ATTACHMENT aktif.
+// att.CreatedBy 0 berarti dicatat atas nama pembuat bundle.
+func (r *oraRepo) AddBundleAttachment(ctx context.Context, documentNo string, att BundleAttachmentVersion) (*BundleAttachmentVersion, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock header supaya nomor versi tidak bentrok saat regenerate bersamaan
+	var bundle struct {
+		ID         int64     `db:"ADW_STS_BUNDLE_ID"`
+		CreatedBy  int64     `db:"CREATEDBY"`
+		Created    time.Time `db:"CREATED"`
+		Attachment *string   `db:"ATTACHMENT"`
+	}
+	queryLock := `
+		SELECT ADW_STS_BUNDLE_ID, CREATEDBY, CREATED, ATTACHMENT
+		FROM plastik.ADW_STS_BUNDLE
+		WHERE DOCUMENTNO = :1
+		FOR UPDATE`
+	err = tx.GetContext(ctx, &bundle, queryLock, documentNo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBundleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal lock bundle: %w", err)
+	}
+
+	createdBy := att.CreatedBy
+	if createdBy == 0 {
+		createdBy = bundle.CreatedBy
+	}
 
-	_, err := r.db.ExecContext(ctx, query, filePath, documentNo)
+	var lastVersion int
+	queryMax := `
+		SELECT NVL(MAX(VERSION), 0)
+		FROM plastik.ADW_STS_BUNDLE_ATTACHMENT
+		WHERE ADW_STS_BUNDLE_ID = :1`
+	if err := tx.GetContext(ctx, &lastVersion, queryMax, bundle.ID); err != nil {
+		return nil, fmt.Errorf("gagal ambil versi attachment: %w", err)
+	}
+
+	queryInsert := `
+		INSERT INTO plastik.ADW_STS_BUNDLE_ATTACHMENT (
+			ADW_STS_BUNDLE_ATTACHMENT_ID, AD_CLIENT_ID, AD_ORG_ID, ADW_STS_BUNDLE_ID,
+			VERSION, ATTACHMENT, VERIFYID, CONTENTHASH, CREATED, CREATEDBY, UPDATED, UPDATEDBY, ISACTIVE
+		) VALUES (plastik.ADW_STS_BUNDLE_ATTACHMENT_SQ.NEXTVAL, :1, :2, :3, :4, :5, :6, :7, :8, :9, SYSDATE, :10, 'Y')`
+
+	// Bundle lama (sebelum ada versioning) yang sudah punya PDF dicatat dulu sebagai versi 1
+	if lastVersion == 0 && bundle.Attachment != nil && *bundle.Attachment != "" {
+		lastVersion = 1
+		_, err := tx.ExecContext(ctx, queryInsert,
+			AdClientID, AdOrgID, bundle.ID, lastVersion, *bundle.Attachment, nil, nil,
+			bundle.Created, bundle.CreatedBy, bundle.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("gagal simpan attachment lama: %w", err)
+		}
+	}
+
+	version := &BundleAttachmentVersion{
+		Version:     lastVersion + 1,
+		Attachment:  att.Attachment,
+		Created:     time.Now(),
+		CreatedBy:   createdBy,
+		VerifyID:    att.VerifyID,
+		ContentHash: att.ContentHash,
+	}
+
+	_, err = tx.ExecContext(ctx, queryInsert,
+		AdClientID, AdOrgID, bundle.ID, version.Version, version.Attachment,
+		version.VerifyID, version.ContentHash,
+		version.Created, createdBy, createdBy)
 	if err != nil {
-		return fmt.Errorf("gagal update attachment: %w", err)
+		return nil, fmt.Errorf("gagal insert versi attachment: %w", err)
 	}
 
-	return nil
+	queryUpdate := `
+		UPDATE plastik.ADW_STS_BUNDLE 
+		SET ATTACHMENT = :1, UPDATED = SYSDATE, UPDATEDBY = :2
+		WHERE ADW_STS_BUNDLE_ID = :3`
+	if _, err := tx.ExecContext(ctx, queryUpdate, version.Attachment, createdBy, bundle.ID); err != nil {
+		return nil, fmt.Errorf("gagal update attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func (r *oraRepo) GetBundleAttachments(ctx context.Context, bundleID int64) ([]BundleAttachmentVersion, error) {
+	query := `
+		SELECT
+			att.ADW_STS_BUNDLE_ATTACHMENT_ID,
+			att.VERSION,
+			att.ATTACHMENT,
+			att.CREATED,
+			att.CREATEDBY,
+			NVL(au.NAME, 'System') AS CREATEDBY_NAME,
+			att.VERIFYID,
+			att.CONTENTHASH
+		FROM plastik.ADW_STS_BUNDLE_ATTACHMENT att
+		LEFT JOIN AD_USER au ON att.CREATEDBY = au.AD_USER_ID
+		WHERE att.ADW_STS_BUNDLE_ID = :1
+		ORDER BY att.VERSION DESC`
+
+	var list []BundleAttachmentVersion
+	if err := r.db.SelectContext(ctx, &list, query, bundleID); err != nil {
+		return nil, fmt.Errorf("gagal ambil versi attachment: %w", err)
+	}
+
+	return list, nil
 }
 
 func (r *oraRepo) GetByMInOutIDs(ctx context.Context, tx *sqlx.Tx, ids []int64) (map[int64]TrackingSJ, error) {
@@ -132,7 +258,7 @@ func (r *oraRepo) GetByMInOutIDs(ctx context.Context, tx *sqlx.Tx, ids []int64)
 	}
 
 	// 2. Gabungkan ke dalam Query (Pastikan tidak ada backtick atau newline)
-	queryStr := "SELECT ADW_STS_ID, M_INOUT_ID, TNKB_ID, DRIVERBY, UPDATEDBY, CURRENTCUSTOMER, CREATED " +
+	queryStr := "SELECT ADW_STS_ID, M_INOUT_ID, STATUS, TNKB_ID, DRIVERBY, UPDATEDBY, CURRENTCUSTOMER, CREATED " +
 		"FROM ADW_STS " +
 		"WHERE M_INOUT_ID IN (" + strings.Join(placeholders, ",") + ") " +
 		"AND ISACTIVE = 'Y'"
@@ -157,7 +283,7 @@ func (r *oraRepo) GetByCustomerIDDriverID(ctx context.Context, customerID, drive
 
 	// Mencari data yang aktif berdasarkan DRIVERBY
 	queryStr := `SELECT 
-				sts.ADW_STS_ID, sts.M_INOUT_ID, sts.TNKB_ID, sts.DRIVERBY, sts.UPDATEDBY
+				sts.ADW_STS_ID, sts.M_INOUT_ID, sts.STATUS, sts.TNKB_ID, sts.DRIVERBY, sts.UPDATEDBY
 				FROM ADW_STS sts
 				JOIN M_INOUT mi ON sts.M_INOUT_ID  = mi.M_INOUT_ID 
 				LEFT JOIN ADW_TMS tms ON mi.ADW_TMS_ID  = tms.ADW_TMS_ID  
@@ -192,7 +318,8 @@ func (r *oraRepo) GetNotificationDetails(ctx context.Context, ids []int64, drive
 			NVL(au.NAME, 'N/A') AS DRIVER_NAME,
 			TO_CHAR(SYSDATE, 'DD-MM-YYYY HH24:MI') AS TIME,
 			TO_CHAR(mi.MovementDate, 'DD-MM-YYYY') AS MOVEMENTDATE,
-			NVL(att.NAME, 'N/A') AS TNKB
+			NVL(att.NAME, 'N/A') AS TNKB,
+			NVL(mi.SPPNO, '-') AS SPPNO
 		FROM M_INOUT mi
 		LEFT JOIN C_BPARTNER bp ON mi.C_BPARTNER_ID = bp.C_BPARTNER_ID
 		LEFT JOIN ADW_TMS t ON mi.ADW_TMS_ID = t.ADW_TMS_ID 
@@ -307,13 +434,13 @@ func (r *oraRepo) CreateBatch(ctx context.Context, tx *sqlx.Tx, entities []Track
 	return nil
 }
 
-func (r *oraRepo) UpdateBatch(ctx context.Context, tx *sqlx.Tx, entities []TrackingSJ, eventType string, notes string) error {
+func (r *oraRepo) UpdateBatch(ctx context.Context, tx *sqlx.Tx, entities []TrackingSJ, eventType string, notes string, loc *EventLocation) ([]int64, error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
 		var err error
 		tx, err = r.db.BeginTxx(ctx, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer tx.Rollback()
 	}
@@ -327,66 +454,75 @@ func (r *oraRepo) UpdateBatch(ctx context.Context, tx *sqlx.Tx, entities []Track
 		INSERT INTO ADW_STS_EVENT 
 			(ADW_STS_EVENT_ID, ADW_STS_ID, AD_CLIENT_ID, AD_ORG_ID, 
 			EVENTTYPE, PREVACTOR, ISACTIVE, CURRENTACTOR, 
-			NOTES, CREATED, CREATEDBY, UPDATED, UPDATEDBY, DRIVERBY, TNKB_ID, CURRENTCUSTOMER, PREVCREATED) 
+			NOTES, CREATED, CREATEDBY, UPDATED, UPDATEDBY, DRIVERBY, TNKB_ID, CURRENTCUSTOMER, PREVCREATED,
+			LATITUDE, LONGITUDE, GPSACCURACY, C_BPARTNER_LOCATION_ID, GEOFENCESTATUS, GEOFENCEDISTANCE) 
 			VALUES 
-			(:1, :2, :3, :4, :5, :6, 'Y', :7, :8, SYSDATE, :9, SYSDATE, :10, :11, :12, :13, :14)`
+			(:1, :2, :3, :4, :5, :6, 'Y', :7, :8, SYSDATE, :9, SYSDATE, :10, :11, :12, :13, :14,
+			:15, :16, :17, :18, :19, :20)`
 
+	eventIDs := make([]int64, 0, len(entities))
 	for _, e := range entities {
 		// 1. Update Tabel Utama
 		if _, err := tx.ExecContext(ctx, queryUpdate, e.Status, e.TNKBID, e.DriverBy, e.UpdatedBy, e.CurrentCustomer, e.ID); err != nil {
 			tx.Rollback()
-			return err
+			return nil, err
 		}
 
 		// 2. Ambil Sequence Event secara manual (Cara yang Anda suka/berhasil)
 		var nextEventID int64
 		if err := tx.GetContext(ctx, &nextEventID, "SELECT ADW_STS_EVENT_SQ.NEXTVAL FROM DUAL"); err != nil {
 			tx.Rollback()
-			return err
+			return nil, err
 		}
 
 		// 3. Insert Log (Logic PrevActorID sudah dihitung Service)
-		if _, err := tx.ExecContext(ctx, queryEvent, nextEventID, e.ID, e.ClientID, e.OrgID, eventType, e.PrevActorID, e.UpdatedBy, notes, e.UpdatedBy, e.UpdatedBy, e.DriverBy, e.TNKBID, e.CurrentCustomer, e.CreatedAt); err != nil {
+		args := []interface{}{nextEventID, e.ID, e.ClientID, e.OrgID, eventType, e.PrevActorID, e.UpdatedBy, notes, e.UpdatedBy, e.UpdatedBy, e.DriverBy, e.TNKBID, e.CurrentCustomer, e.CreatedAt}
+		if _, err := tx.ExecContext(ctx, queryEvent, append(args, loc.args()...)...); err != nil {
 			tx.Rollback()
-			return err
+			return nil, err
 		}
+		eventIDs = append(eventIDs, nextEventID)
 	}
 
 	if !useExternalTx {
-		return tx.Commit()
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return eventIDs, nil
 }
 
 // Tambahkan di Interface
 // LogActivityOnly(ctx context.Context, req HandoverRequest) error
 
-func (r *oraRepo) LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req HandoverRequest) error {
+func (r *oraRepo) LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req HandoverRequest, loc *EventLocation) (int64, error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
 		var err error
 		tx, err = r.db.BeginTxx(ctx, nil)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		defer tx.Rollback()
 	}
 
 	var nextEventID int64
 	if err := tx.GetContext(ctx, &nextEventID, "SELECT ADW_STS_EVENT_SQ.NEXTVAL FROM DUAL"); err != nil {
-		return fmt.Errorf("gagal ambil sequence: %w", err)
+		return 0, fmt.Errorf("gagal ambil sequence: %w", err)
 	}
 
 	queryEvent := `
         INSERT INTO ADW_STS_EVENT 
             (ADW_STS_EVENT_ID, ADW_STS_ID, AD_CLIENT_ID, AD_ORG_ID, 
             EVENTTYPE, ISACTIVE, CURRENTACTOR, 
-            NOTES, CREATED, CREATEDBY, UPDATED, UPDATEDBY, DRIVERBY, TNKB_ID, CURRENTCUSTOMER) 
+            NOTES, CREATED, CREATEDBY, UPDATED, UPDATEDBY, DRIVERBY, TNKB_ID, CURRENTCUSTOMER,
+            LATITUDE, LONGITUDE, GPSACCURACY, C_BPARTNER_LOCATION_ID, GEOFENCESTATUS, GEOFENCEDISTANCE) 
         VALUES 
-            (:1, NULL, :2, :3, :4, 'Y', :5, :6, SYSDATE, :7, SYSDATE, :8, :9, :10, :11)`
+            (:1, NULL, :2, :3, :4, 'Y', :5, :6, SYSDATE, :7, SYSDATE, :8, :9, :10, :11,
+            :12, :13, :14, :15, :16, :17)`
 
-	_, err := tx.ExecContext(ctx, queryEvent,
+	args := []interface{}{
 		nextEventID,
 		AdClientID, // Gunakan konstanta yang sama
 		AdOrgID,    // Gunakan konstanta yang sama
@@ -398,10 +534,54 @@ func (r *oraRepo) LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req Handover
 		req.DriverBy,
 		req.TNKBID,
 		req.CurrentCustomer,
-	)
+	}
 
+	_, err := tx.ExecContext(ctx, queryEvent, append(args, loc.args()...)...)
 	if err != nil {
-		return fmt.Errorf("gagal insert activity log: %w", err)
+		return 0, fmt.Errorf("gagal insert activity log: %w", err)
+	}
+
+	if !useExternalTx {
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+
+	return nextEventID, nil
+}
+
+// InsertEventPhotos menautkan foto bukti ke setiap event (satu check-in bisa mencakup beberapa SJ)
+func (r *oraRepo) InsertEventPhotos(ctx context.Context, tx *sqlx.Tx, eventIDs []int64, photos []EventPhoto, userID int64) error {
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		var err error
+		tx, err = r.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	query := `
+		INSERT INTO ADW_STS_EVENT_PHOTO
+			(ADW_STS_EVENT_PHOTO_ID, AD_CLIENT_ID, AD_ORG_ID, ADW_STS_EVENT_ID, LINE,
+			PHOTOKEY, THUMBKEY, CONTENTTYPE, FILESIZE, WIDTH, HEIGHT, TAKENAT,
+			ISACTIVE, CREATED, CREATEDBY, UPDATED, UPDATEDBY)
+		VALUES
+			(ADW_STS_EVENT_PHOTO_SQ.NEXTVAL, :1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11,
+			'Y', SYSDATE, :12, SYSDATE, :13)`
+
+	for _, eventID := range eventIDs {
+		for i, p := range photos {
+			_, err := tx.ExecContext(ctx, query,
+				AdClientID, AdOrgID, eventID, (i+1)*10,
+				p.PhotoKey, p.ThumbKey, p.ContentType, p.FileSize, p.Width, p.Height, p.TakenAt,
+				userID, userID,
+			)
+			if err != nil {
+				return fmt.Errorf("gagal simpan foto event %d: %w", eventID, err)
+			}
+		}
 	}
 
 	if !useExternalTx {
@@ -411,6 +591,59 @@ func (r *oraRepo) LogActivityOnly(ctx context.Context, tx *sqlx.Tx, req Handover
 	return nil
 }
 
+// GetDriverVisit mengambil event acuan beserta SJ lain dari check-in / check-out yang sama.
+// Satu request check-in membuat satu event per SJ dengan driver, customer & waktu yang (hampir) sama.
+func (r *oraRepo) GetDriverVisit(ctx context.Context, eventID int64) (*DriverVisit, error) {
+	var visit DriverVisit
+	query := `
+		SELECT EVENTTYPE, NVL(DRIVERBY, 0) AS DRIVERBY, NVL(CURRENTCUSTOMER, 0) AS CURRENTCUSTOMER,
+			NVL(NOTES, ' ') AS NOTES, CREATED
+		FROM ADW_STS_EVENT
+		WHERE ADW_STS_EVENT_ID = :1`
+	err := r.db.GetContext(ctx, &visit, query, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil event %d: %w", eventID, err)
+	}
+	visit.Notes = strings.TrimSpace(visit.Notes)
+
+	queryDocs := `
+		SELECT DISTINCT s.M_INOUT_ID
+		FROM ADW_STS_EVENT e
+		JOIN ADW_STS s ON s.ADW_STS_ID = e.ADW_STS_ID
+		WHERE e.EVENTTYPE = :1
+			AND NVL(e.DRIVERBY, 0) = :2
+			AND NVL(e.CURRENTCUSTOMER, 0) = :3
+			AND e.CREATED BETWEEN :4 - 1/1440 AND :5 + 1/1440`
+	err = r.db.SelectContext(ctx, &visit.MInOutIDs, queryDocs,
+		visit.EventType, visit.DriverBy, visit.CustomerID, visit.Created, visit.Created)
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil SJ event %d: %w", eventID, err)
+	}
+
+	return &visit, nil
+}
+
+// GetGeofencesByBPartner mengambil titik geofence aktif semua lokasi customer
+func (r *oraRepo) GetGeofencesByBPartner(ctx context.Context, bpartnerID int64) ([]Geofence, error) {
+	fences := []Geofence{}
+	query := `
+		SELECT g.C_BPARTNER_LOCATION_ID, bl.C_BPARTNER_ID, NVL(bl.NAME, '-') AS NAME,
+			g.LATITUDE, g.LONGITUDE, g.RADIUS
+		FROM ADW_STS_GEOFENCE g
+		JOIN C_BPARTNER_LOCATION bl ON bl.C_BPARTNER_LOCATION_ID = g.C_BPARTNER_LOCATION_ID
+		WHERE bl.C_BPARTNER_ID = :1
+			AND g.ISACTIVE = 'Y'
+			AND bl.ISACTIVE = 'Y'`
+
+	if err := r.db.SelectContext(ctx, &fences, query, bpartnerID); err != nil {
+		return nil, fmt.Errorf("gagal ambil geofence customer %d: %w", bpartnerID, err)
+	}
+	return fences, nil
+}
+
 func (r *oraRepo) GetBundleActors(ctx context.Context, bundleDocNo string) (*BundleActorDTO, error) {
 	query := `
         SELECT 
@@ -438,3 +671,474 @@ func (r *oraRepo) GetBundleActors(ctx context.Context, bundleDocNo string) (*Bun
 
 	return &actor, nil
 }
+
+const bundleSummaryColumns = `
+            bnd.ADW_STS_BUNDLE_ID,
+            bnd.DOCUMENTNO,
+            bnd.BUNDLE_TYPE,
+            bnd.MOVEMENTDATE,
+            NVL(bnd.DESCRIPTION, '-') AS DESCRIPTION,
+            bnd.CREATEDBY,
+            NVL(au.NAME, 'System') AS CREATEDBY_NAME,
+            bnd.ATTACHMENT,
+            (SELECT COUNT(*) FROM plastik.ADW_STS_BUNDLE_LINE bln
+              WHERE bln.ADW_STS_BUNDLE_ID = bnd.ADW_STS_BUNDLE_ID) AS LINE_COUNT,
+            bnd.CREATED`
+
+func (r *oraRepo) ListBundles(ctx context.Context, f BundleFilter) ([]BundleSummary, int, error) {
+	conditions := []string{"bnd.CREATED >= :1", "bnd.CREATED < :2"}
+	args := []interface{}{f.DateFrom, f.DateTo}
+
+	next := func() string {
+		return ":" + strconv.Itoa(len(args)+1)
+	}
+
+	if f.BundleType != "" {
+		conditions = append(conditions, "bnd.BUNDLE_TYPE = "+next())
+		args = append(args, f.BundleType)
+	}
+
+	if f.ActorID > 0 {
+		// Go-ora tidak mendukung bind name yang sama dipakai berulang, jadi nilainya dikirim 3x
+		pCreated := next()
+		args = append(args, f.ActorID)
+		pPrev := next()
+		args = append(args, f.ActorID)
+		pCurr := next()
+		args = append(args, f.ActorID)
+
+		conditions = append(conditions, `(bnd.CREATEDBY = `+pCreated+` OR EXISTS (
+                SELECT 1
+                FROM plastik.ADW_STS_BUNDLE_LINE bln
+                JOIN ADW_STS_EVENT evt ON bln.ADW_STS_ID = evt.ADW_STS_ID AND evt.EVENTTYPE = bnd.BUNDLE_TYPE
+                WHERE bln.ADW_STS_BUNDLE_ID = bnd.ADW_STS_BUNDLE_ID
+                  AND (evt.PREVACTOR = `+pPrev+` OR evt.CURRENTACTOR = `+pCurr+`)
+            ))`)
+	}
+
+	if f.CustomerID > 0 {
+		conditions = append(conditions, `EXISTS (
+                SELECT 1
+                FROM plastik.ADW_STS_BUNDLE_LINE bln
+                JOIN ADW_STS sts ON bln.ADW_STS_ID = sts.ADW_STS_ID
+                JOIN M_INOUT mi ON sts.M_INOUT_ID = mi.M_INOUT_ID
+                WHERE bln.ADW_STS_BUNDLE_ID = bnd.ADW_STS_BUNDLE_ID
+                  AND mi.C_BPARTNER_ID = `+next()+`
+            )`)
+		args = append(args, f.CustomerID)
+	}
+
+	offset := (f.Page - 1) * f.PageSize
+	pStart := next()
+	args = append(args, offset)
+	pEnd := next()
+	args = append(args, offset+f.PageSize)
+
+	query := `
+        SELECT * FROM (
+            SELECT ` + bundleSummaryColumns + `,
+                COUNT(*) OVER () AS TOTAL_COUNT,
+                ROW_NUMBER() OVER (ORDER BY bnd.CREATED DESC, bnd.ADW_STS_BUNDLE_ID DESC) AS RN
+            FROM plastik.ADW_STS_BUNDLE bnd
+            LEFT JOIN AD_USER au ON bnd.CREATEDBY = au.AD_USER_ID
+            WHERE ` + strings.Join(conditions, "\n              AND ") + `
+        )
+        WHERE RN > ` + pStart + ` AND RN <= ` + pEnd + `
+        ORDER BY RN`
+
+	var rows []struct {
+		BundleSummary
+		TotalCount int `db:"TOTAL_COUNT"`
+		RN         int `db:"RN"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("gagal ambil daftar bundle: %w", err)
+	}
+
+	list := make([]BundleSummary, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		list = append(list, row.BundleSummary)
+		total = row.TotalCount
+	}
+
+	// Halaman di luar jangkauan tidak membawa COUNT(*), hitung terpisah
+	if len(rows) == 0 && f.Page > 1 {
+		countQuery := `
+        SELECT COUNT(*)
+        FROM plastik.ADW_STS_BUNDLE bnd
+        WHERE ` + strings.Join(conditions, "\n          AND ")
+		if err := r.db.GetContext(ctx, &total, countQuery, args[:len(args)-2]...); err != nil {
+			return nil, 0, fmt.Errorf("gagal hitung bundle: %w", err)
+		}
+	}
+
+	return list, total, nil
+}
+
+func (r *oraRepo) GetBundleByDocumentNo(ctx context.Context, documentNo string) (*BundleSummary, error) {
+	query := `
+        SELECT ` + bundleSummaryColumns + `
+        FROM plastik.ADW_STS_BUNDLE bnd
+        LEFT JOIN AD_USER au ON bnd.CREATEDBY = au.AD_USER_ID
+        WHERE bnd.DOCUMENTNO = :1`
+
+	var bundle BundleSummary
+	err := r.db.GetContext(ctx, &bundle, query, documentNo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBundleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil bundle %s: %w", documentNo, err)
+	}
+
+	return &bundle, nil
+}
+
+func (r *oraRepo) GetBundleLines(ctx context.Context, bundleID int64) ([]BundleLineDTO, error) {
+	query := `
+        SELECT
+            bln.LINE,
+            mi.M_INOUT_ID,
+            mi.DOCUMENTNO,
+            mi.MOVEMENTDATE,
+            cb.VALUE AS CUSTOMER,
+            COALESCE(TO_CHAR(au.NAME), TO_CHAR(t.DRIVER_NAME), '-') AS DRIVER,
+            NVL(NVL(att.NAME, t.TNKB), '-') AS TNKB,
+            NVL(mi.SPPNO, '-') AS SPPNO,
+            sts.STATUS
+        FROM plastik.ADW_STS_BUNDLE_LINE bln
+        JOIN ADW_STS sts ON bln.ADW_STS_ID = sts.ADW_STS_ID
+        JOIN M_INOUT mi ON sts.M_INOUT_ID = mi.M_INOUT_ID
+        JOIN C_BPARTNER cb ON mi.C_BPARTNER_ID = cb.C_BPARTNER_ID
+        LEFT JOIN ADW_TMS t ON mi.ADW_TMS_ID = t.ADW_TMS_ID
+        LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID
+        LEFT JOIN ADW_TMS_TNKB att ON sts.TNKB_ID = att.ADW_TMS_TNKB_ID
+        WHERE bln.ADW_STS_BUNDLE_ID = :1
+        ORDER BY bln.LINE`
+
+	var lines []BundleLineDTO
+	if err := r.db.SelectContext(ctx, &lines, query, bundleID); err != nil {
+		return nil, fmt.Errorf("gagal ambil line bundle: %w", err)
+	}
+
+	return lines, nil
+}
+
+// NextDocSequence mengunci baris counter ADW_STS_DOCSEQ (prefix + periode) di dalam tx yang sama
+// dengan pembuatan bundle. Baris baru dibuat saat periode pertama kali dipakai.
+func (r *oraRepo) NextDocSequence(ctx context.Context, tx *sqlx.Tx, prefix, period string) (int64, error) {
+	querySelect := `
+		SELECT CURRENTNEXT
+		FROM ADW_STS_DOCSEQ
+		WHERE PREFIX = :1 AND PERIOD = :2
+		FOR UPDATE`
+
+	queryInsert := `
+		INSERT INTO ADW_STS_DOCSEQ (
+			AD_CLIENT_ID, PREFIX, PERIOD, CURRENTNEXT, CREATED, UPDATED
+		) VALUES (:1, :2, :3, 2, SYSDATE, SYSDATE)`
+
+	queryUpdate := `
+		UPDATE ADW_STS_DOCSEQ
+		SET CURRENTNEXT = CURRENTNEXT + 1, UPDATED = SYSDATE
+		WHERE PREFIX = :1 AND PERIOD = :2`
+
+	// Maksimal 2x: jika insert kalah cepat dengan request lain, ulangi SELECT FOR UPDATE
+	for attempt := 0; attempt < 2; attempt++ {
+		var current int64
+		err := tx.GetContext(ctx, &current, querySelect, prefix, period)
+		if err == nil {
+			if _, err := tx.ExecContext(ctx, queryUpdate, prefix, period); err != nil {
+				return 0, fmt.Errorf("gagal update counter dokumen: %w", err)
+			}
+			return current, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("gagal lock counter dokumen: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, queryInsert, AdClientID, prefix, period)
+		if err == nil {
+			return 1, nil
+		}
+		if !db.IsUniqueViolation(err) {
+			return 0, fmt.Errorf("gagal buat counter dokumen: %w", err)
+		}
+	}
+
+	return 0, fmt.Errorf("counter dokumen %s/%s sedang dipakai, coba lagi", prefix, period)
+}
+
+func (r *oraRepo) GetBundleSignatures(ctx context.Context, bundleDocNo string) (BundleSignatures, error) {
+	query := `
+        SELECT GIVERSIGNATURE, RECEIVERSIGNATURE
+        FROM plastik.ADW_STS_BUNDLE
+        WHERE DOCUMENTNO = :1`
+
+	var row struct {
+		Giver    go_ora.Blob `db:"GIVERSIGNATURE"`
+		Receiver go_ora.Blob `db:"RECEIVERSIGNATURE"`
+	}
+	err := r.db.GetContext(ctx, &row, query, bundleDocNo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return BundleSignatures{}, ErrBundleNotFound
+	}
+	if err != nil {
+		return BundleSignatures{}, fmt.Errorf("gagal ambil tanda tangan bundle: %w", err)
+	}
+
+	return BundleSignatures{Giver: row.Giver.Data, Receiver: row.Receiver.Data}, nil
+}
+
+// blobArg mengirim NULL untuk data kosong, selain itu sebagai BLOB
+func blobArg(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return go_ora.Blob{Data: data}
+}
+
+func (r *oraRepo) GetIdempotencyKey(ctx context.Context, key string, userID int64) (*IdempotencyRecord, error) {
+	query := `
+		SELECT IDEMPOTENCYKEY, AD_USER_ID, REQUESTHASH, RESPONSECODE, RESPONSEBODY, CREATED
+		FROM ADW_STS_IDEMPOTENCY
+		WHERE IDEMPOTENCYKEY = :1 AND AD_USER_ID = :2
+		AND CREATED >= SYSDATE - :3`
+
+	var rec IdempotencyRecord
+	err := r.db.GetContext(ctx, &rec, query, key, userID, idempotencyTTLDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil idempotency key: %w", err)
+	}
+
+	return &rec, nil
+}
+
+func (r *oraRepo) ReserveIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string) error {
+	// Key yang sudah kadaluarsa boleh dipakai ulang
+	queryExpired := `
+		DELETE FROM ADW_STS_IDEMPOTENCY
+		WHERE IDEMPOTENCYKEY = :1 AND AD_USER_ID = :2
+		AND CREATED < SYSDATE - :3`
+
+	if _, err := r.db.ExecContext(ctx, queryExpired, key, userID, idempotencyTTLDays); err != nil {
+		return fmt.Errorf("gagal hapus idempotency key lama: %w", err)
+	}
+
+	queryInsert := `
+		INSERT INTO ADW_STS_IDEMPOTENCY (
+			IDEMPOTENCYKEY, AD_USER_ID, REQUESTHASH, RESPONSECODE, RESERVED, CREATED, UPDATED
+		) VALUES (:1, :2, :3, 0, SYSDATE, SYSDATE, SYSDATE)`
+
+	_, err := r.db.ExecContext(ctx, queryInsert, key, userID, requestHash)
+	if db.IsUniqueViolation(err) {
+		return ErrIdempotencyKeyExists
+	}
+	if err != nil {
+		return fmt.Errorf("gagal simpan idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// ReclaimIdempotencyKey mengambil alih reservasi yang lewat lease. UPDATE bersyarat sekaligus menjadi
+// kunci: dari beberapa retry yang bersamaan hanya satu yang mendapat baris.
+func (r *oraRepo) ReclaimIdempotencyKey(ctx context.Context, key string, userID int64, requestHash string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE ADW_STS_IDEMPOTENCY
+		SET RESERVED = SYSDATE, UPDATED = SYSDATE
+		WHERE IDEMPOTENCYKEY = :1 AND AD_USER_ID = :2 AND REQUESTHASH = :3
+		AND RESPONSECODE = 0
+		AND RESERVED < SYSDATE - :4 / 86400`
+
+	res, err := r.db.ExecContext(ctx, query, key, userID, requestHash, int64(lease.Seconds()))
+	if err != nil {
+		return false, fmt.Errorf("gagal ambil alih idempotency key: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *oraRepo) CompleteIdempotencyKey(ctx context.Context, key string, userID int64, responseCode int, responseBody string) error {
+	query := `
+		UPDATE ADW_STS_IDEMPOTENCY
+		SET RESPONSECODE = :1, RESPONSEBODY = :2, UPDATED = SYSDATE
+		WHERE IDEMPOTENCYKEY = :3 AND AD_USER_ID = :4`
+
+	_, err := r.db.ExecContext(ctx, query, responseCode, responseBody, key, userID)
+	if err != nil {
+		return fmt.Errorf("gagal update idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oraRepo) ReleaseIdempotencyKey(ctx context.Context, key string, userID int64) error {
+	query := `DELETE FROM ADW_STS_IDEMPOTENCY WHERE IDEMPOTENCYKEY = :1 AND AD_USER_ID = :2`
+
+	_, err := r.db.ExecContext(ctx, query, key, userID)
+	if err != nil {
+		return fmt.Errorf("gagal hapus idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueueOutbox wajib dipanggil di dalam transaksi yang sama dengan perubahan datanya
+func (r *oraRepo) EnqueueOutbox(ctx context.Context, tx *sqlx.Tx, eventType string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("gagal encode payload outbox: %w", err)
+	}
+
+	query := `
+		INSERT INTO ADW_STS_OUTBOX (
+			ADW_STS_OUTBOX_ID, AD_CLIENT_ID, AD_ORG_ID, EVENTTYPE, PAYLOAD,
+			STATUS, ATTEMPTS, NEXTATTEMPT, CREATED, UPDATED
+		) VALUES (ADW_STS_OUTBOX_SQ.NEXTVAL, :1, :2, :3, :4, :5, 0, SYSDATE, SYSDATE, SYSDATE)`
+
+	_, err = tx.ExecContext(ctx, query, AdClientID, AdOrgID, eventType, string(body), OutboxPending)
+	if err != nil {
+		return fmt.Errorf("gagal insert outbox %s: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// ClaimOutbox mengunci pesan yang siap diproses (SKIP LOCKED agar aman untuk beberapa replika)
+// lalu menandainya PROCESSING sampai lease habis.
+func (r *oraRepo) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ADW_STS_OUTBOX_ID, EVENTTYPE, PAYLOAD, ATTEMPTS
+		FROM ADW_STS_OUTBOX
+		WHERE (STATUS = :1 AND NEXTATTEMPT <= SYSDATE)
+		   OR (STATUS = :2 AND LOCKEDUNTIL < SYSDATE)
+		ORDER BY ADW_STS_OUTBOX_ID
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryxContext(ctx, query, OutboxPending, OutboxProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil outbox: %w", err)
+	}
+
+	// Dengan SKIP LOCKED baris baru dikunci saat di-fetch, jadi cukup fetch sebanyak limit
+	var msgs []OutboxMessage
+	for len(msgs) < limit && rows.Next() {
+		var m OutboxMessage
+		if err := rows.StructScan(&m); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("gagal scan outbox: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	rows.Close()
+
+	queryClaim := `
+		UPDATE ADW_STS_OUTBOX
+		SET STATUS = :1, ATTEMPTS = ATTEMPTS + 1,
+			LOCKEDUNTIL = SYSDATE + :2 / 86400, UPDATED = SYSDATE
+		WHERE ADW_STS_OUTBOX_ID = :3`
+
+	for i := range msgs {
+		if _, err := tx.ExecContext(ctx, queryClaim, OutboxProcessing, int64(lease.Seconds()), msgs[i].ID); err != nil {
+			return nil, fmt.Errorf("gagal claim outbox %d: %w", msgs[i].ID, err)
+		}
+		msgs[i].Attempts++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+func (r *oraRepo) CompleteOutbox(ctx context.Context, msg OutboxMessage, started time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE ADW_STS_OUTBOX
+		SET STATUS = :1, LASTERROR = NULL, LOCKEDUNTIL = NULL,
+			PROCESSED = SYSDATE, UPDATED = SYSDATE
+		WHERE ADW_STS_OUTBOX_ID = :2`
+
+	if _, err := tx.ExecContext(ctx, query, OutboxDone, msg.ID); err != nil {
+		return fmt.Errorf("gagal update outbox: %w", err)
+	}
+
+	if err := insertOutboxAttempt(ctx, tx, msg, started, "Y", ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *oraRepo) FailOutbox(ctx context.Context, msg OutboxMessage, started time.Time, errMsg string, retryIn time.Duration, dead bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status := OutboxPending
+	if dead {
+		status = OutboxFailed
+	}
+
+	query := `
+		UPDATE ADW_STS_OUTBOX
+		SET STATUS = :1, LASTERROR = :2, NEXTATTEMPT = SYSDATE + :3 / 86400,
+			LOCKEDUNTIL = NULL, UPDATED = SYSDATE
+		WHERE ADW_STS_OUTBOX_ID = :4`
+
+	if _, err := tx.ExecContext(ctx, query, status, truncate(errMsg, 2000), int64(retryIn.Seconds()), msg.ID); err != nil {
+		return fmt.Errorf("gagal update outbox: %w", err)
+	}
+
+	if err := insertOutboxAttempt(ctx, tx, msg, started, "N", errMsg); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertOutboxAttempt mencatat riwayat setiap percobaan di ADW_STS_OUTBOX_ATTEMPT
+func insertOutboxAttempt(ctx context.Context, tx *sqlx.Tx, msg OutboxMessage, started time.Time, success, errMsg string) error {
+	query := `
+		INSERT INTO ADW_STS_OUTBOX_ATTEMPT (
+			ADW_STS_OUTBOX_ATTEMPT_ID, ADW_STS_OUTBOX_ID, ATTEMPTNO,
+			STARTED, FINISHED, ISSUCCESS, ERRORMSG
+		) VALUES (ADW_STS_OUTBOX_ATTEMPT_SQ.NEXTVAL, :1, :2, :3, SYSDATE, :4, :5)`
+
+	_, err := tx.ExecContext(ctx, query, msg.ID, msg.Attempts, started, success, truncate(errMsg, 2000))
+	if err != nil {
+		return fmt.Errorf("gagal insert riwayat outbox: %w", err)
+	}
+
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"os"
-	"path/filepath"
+	"strings"
+	"sts/web_service/internal/notification"
+	"sts/web_service/internal/shared"
+	"sts/web_service/internal/shared/config"
+	"sts/web_service/internal/shared/storage"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,142 +19,194 @@ import (
 )
 
 type Service interface {
-	ProcessInit(ctx context.Context, req HandoverRequest) error
-	ProcessHandover(ctx context.Context, req HandoverRequest) error
+	ProcessInit(ctx context.Context, actor Actor, req HandoverRequest) error
+	ProcessHandover(ctx context.Context, actor Actor, req HandoverRequest) (*HandoverResult, error)
+	HandleOutbox(ctx context.Context, msg OutboxMessage) error
+
+	ListBundles(ctx context.Context, q BundleQuery) (*BundlePage, error)
+	GetBundle(ctx context.Context, documentNo string) (*BundleDetail, error)
+	GetBundleVersions(ctx context.Context, documentNo string) ([]BundleAttachmentVersion, error)
+	RegenerateBundlePdf(ctx context.Context, actor Actor, documentNo string) (*BundleAttachmentVersion, error)
+
+	VerifyDocument(ctx context.Context, token, clientHash string) (*VerifyResult, error)
+
+	// Sumber data preview template notifikasi
+	PreviewEvent(ctx context.Context, ref notification.PreviewRef) (*notification.Event, error)
 }
 
 type service struct {
-	repo Repository
-	// notifService NotificationService
+	repo        Repository
+	cfg         *config.Config
+	docNo       *DocNumbering
+	pdfTemplate *PdfTemplate
+	store       storage.Storage
+	events      notification.Publisher // notifikasi hanya lewat domain event
 }
 
-// func NewService(r Repository, n NotificationService) Service {
-// 	return &service{repo: r, notifService: n}
-// }
-
-func NewService(r Repository) Service {
-	return &service{repo: r}
+func NewService(r Repository, cfg *config.Config, docNo *DocNumbering, pdfTemplate *PdfTemplate, store storage.Storage, events notification.Publisher) Service {
+	return &service{repo: r, cfg: cfg, docNo: docNo, pdfTemplate: pdfTemplate, store: store, events: events}
 }
 
-func (s *service) generateHandoverPdf(bundleNo string, req HandoverRequest, details []HandoverNotifyDTO, actors *BundleActorDTO) (string, error) {
-	// 1. Path Setup
-	uploadDir := "uploads/handover"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create dir: %v", err)
+// generateHandoverPdf membuat isi PDF di memori; penyimpanan ke storage dilakukan pemanggil.
+// qrContent adalah URL verifikasi.
+func (s *service) generateHandoverPdf(bundleNo string, status string, details []HandoverNotifyDTO, actors *BundleActorDTO, signatures BundleSignatures, qrContent string) ([]byte, error) {
+	tmpl := s.pdfTemplate
+
+	// 1. Init PDF sesuai template (Unit: mm)
+	pdf := gofpdf.New(tmpl.Orientation, "mm", tmpl.paperSize(), "")
+	margin := tmpl.Margin
+	pdf.SetMargins(margin, margin, margin)
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - (2 * margin)
+
+	if tmpl.FooterText != "" {
+		pdf.SetFooterFunc(func() {
+			pdf.SetY(-margin - 5)
+			pdf.SetFont(tmpl.Font, "I", 7)
+			pdf.CellFormat(0, 5, tmpl.FooterText, "", 0, "C", false, 0, "")
+		})
 	}
 
-	uniqueId := uuid.New().String()
-	fileName := fmt.Sprintf("handover_%s.pdf", uniqueId)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// 2. Init PDF (A4, Unit: mm)
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetMargins(10, 10, 10)
 	pdf.AddPage()
 
+	// Header - Logo & identitas perusahaan
+	headerY := pdf.GetY()
+	if tmpl.LogoPath != "" {
+		pdf.ImageOptions(tmpl.LogoPath, margin, headerY, tmpl.LogoWidth, 0, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+	}
+	if tmpl.CompanyName != "" {
+		pdf.SetFont(tmpl.Font, "B", 11)
+		pdf.CellFormat(0, 6, tmpl.CompanyName, "", 1, "C", false, 0, "")
+		if tmpl.CompanyAddress != "" {
+			pdf.SetFont(tmpl.Font, "", 8)
+			pdf.CellFormat(0, 4, tmpl.CompanyAddress, "", 1, "C", false, 0, "")
+		}
+		pdf.Ln(2)
+	}
+
 	// Header - Title
-	pdf.SetFont("Arial", "B", 14)
-	pdf.CellFormat(0, 10, "LIST HANDOVER", "", 1, "C", false, 0, "")
+	pdf.SetFont(tmpl.Font, "B", 14)
+	pdf.CellFormat(0, 10, tmpl.Title, "", 1, "C", false, 0, "")
 
 	// Sub-Header
-	pdf.SetFont("Arial", "", 10)
-	pdf.CellFormat(0, 5, fmt.Sprintf("Status: %s", req.Status), "", 1, "C", false, 0, "")
+	pdf.SetFont(tmpl.Font, "", 10)
+	pdf.CellFormat(0, 5, fmt.Sprintf("Status: %s", status), "", 1, "C", false, 0, "")
 	pdf.CellFormat(0, 5, fmt.Sprintf("Bundle No: %s", bundleNo), "", 1, "C", false, 0, "")
 	pdf.Ln(10)
 
 	// 3. Draw Table Header
+	numberWidth := 10.0
+	widths := tmpl.columnWidths(contentWidth, numberWidth)
+	drawTableHeader := func() {
+		pdf.SetFont(tmpl.Font, "B", 9)
+		pdf.CellFormat(numberWidth, 8, "No", "1", 0, "C", true, 0, "")
+		for i, col := range tmpl.Columns {
+			pdf.CellFormat(widths[i], 8, col.Label, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(tmpl.Font, "", 8)
+	}
+
 	pdf.SetFillColor(230, 230, 230)
-	pdf.SetFont("Arial", "B", 9)
-	pdf.CellFormat(10, 8, "No", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(60, 8, "Customer", "1", 0, "L", true, 0, "")
-	pdf.CellFormat(70, 8, "Shipment No", "1", 0, "L", true, 0, "")
-	pdf.CellFormat(50, 8, "Movement Date", "1", 1, "L", true, 0, "")
+	drawTableHeader()
 
 	// 4. Draw Table Body
-	pdf.SetFont("Arial", "", 8)
+	pageBreakY := pageHeight - margin - 17
 	for i, item := range details {
-		// Auto add page if content exceeds
-		if pdf.GetY() > 270 {
+		// Auto add page if content exceeds, redraw header on new page
+		if pdf.GetY() > pageBreakY {
 			pdf.AddPage()
-			// Redraw header on new page
-			pdf.SetFont("Arial", "B", 9)
-			pdf.CellFormat(10, 8, "No", "1", 0, "C", true, 0, "")
-			pdf.CellFormat(60, 8, "Customer", "1", 0, "L", true, 0, "")
-			pdf.CellFormat(70, 8, "Shipment No", "1", 0, "L", true, 0, "")
-			pdf.CellFormat(50, 8, "Movement Date", "1", 1, "L", true, 0, "")
-			pdf.SetFont("Arial", "", 8)
+			drawTableHeader()
 		}
 
-		pdf.CellFormat(10, 7, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
-		pdf.CellFormat(60, 7, item.CustomerName, "1", 0, "L", false, 0, "")
-		pdf.CellFormat(70, 7, item.DocumentNo, "1", 0, "L", false, 0, "")
-		pdf.CellFormat(50, 7, item.MovementDate, "1", 1, "L", false, 0, "")
+		pdf.CellFormat(numberWidth, 7, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		for j, col := range tmpl.Columns {
+			value, _ := pdfFieldValue(item, col.Field)
+			pdf.CellFormat(widths[j], 7, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
 	}
 
 	pdf.Ln(8)
 
+	// Blok tanda tangan tidak boleh terpotong ke halaman berikutnya
+	if pdf.GetY() > pageHeight-margin-25-signatureHeight {
+		pdf.AddPage()
+	}
+
 	// 5. Layout: [Penyerah] [QR Code] [Penerima] dalam satu baris
 	currentY := pdf.GetY()
-	pageWidth := 210.0 // A4 width in mm
-	margin := 10.0
-	contentWidth := pageWidth - (2 * margin) // 190mm
 
-	// QR Code Generation (ukuran lebih kecil)
-	qrFileName := fmt.Sprintf("temp_qr_%s.png", uniqueId)
-	qrPath := filepath.Join(uploadDir, qrFileName)
-	qrContent := fmt.Sprintf("https://api-sts.adyawinsa.com/api/uploads/handover/%s", fileName)
-	qrSize := 20.0 // Ukuran QR code 20mm (lebih kecil dari 30mm sebelumnya)
+	// QR Code berisi URL verifikasi bertanda tangan (BaseURL dari config)
+	qrSize := tmpl.QRSize
 
-	if errQr := qrcode.WriteFile(qrContent, qrcode.Medium, 256, qrPath); errQr == nil {
+	if qrPng, errQr := qrcode.Encode(qrContent, qrcode.Medium, 256); errQr == nil {
 		// QR code di tengah
 		qrX := margin + (contentWidth-qrSize)/2
-		pdf.ImageOptions(qrPath, qrX, currentY, qrSize, 0, false, gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}, 0, "")
-		defer os.Remove(qrPath)
+		qrOpts := gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}
+		pdf.RegisterImageOptionsReader("qr", qrOpts, bytes.NewReader(qrPng))
+		pdf.ImageOptions("qr", qrX, currentY, qrSize, 0, false, qrOpts, 0, "")
 	}
 
 	// Kolom Penyerah (Kiri)
 	colWidth := (contentWidth - qrSize) / 2 // Lebar kolom kiri/kanan
 
 	pdf.SetXY(margin, currentY)
-	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFont(tmpl.Font, "B", 9)
 	pdf.CellFormat(colWidth, 5, "Diserahkan Oleh:", "", 1, "C", false, 0, "")
 
+	drawSignature(pdf, "sig_giver", signatures.Giver, margin, currentY+5, colWidth)
+
 	pdf.SetX(margin)
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "U", 9)
+	pdf.Ln(signatureHeight)
+	pdf.SetFont(tmpl.Font, "U", 9)
 	pdf.SetX(margin)
 	pdf.CellFormat(colWidth, 5, actors.PrevActorName, "", 1, "C", false, 0, "")
 
 	pdf.SetX(margin)
-	pdf.SetFont("Arial", "", 8)
+	pdf.SetFont(tmpl.Font, "", 8)
 	pdf.CellFormat(colWidth, 5, actors.HandoverTime+" WIB", "", 0, "C", false, 0, "")
 
 	// Kolom Penerima (Kanan)
 	rightColX := margin + colWidth + qrSize
 
 	pdf.SetXY(rightColX, currentY)
-	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFont(tmpl.Font, "B", 9)
 	pdf.CellFormat(colWidth, 5, "Diterima Oleh:", "", 1, "C", false, 0, "")
 
+	drawSignature(pdf, "sig_receiver", signatures.Receiver, rightColX, currentY+5, colWidth)
+
 	pdf.SetX(rightColX)
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "U", 9)
+	pdf.Ln(signatureHeight)
+	pdf.SetFont(tmpl.Font, "U", 9)
 	pdf.SetX(rightColX)
 	pdf.CellFormat(colWidth, 5, actors.CurrentActorName, "", 1, "C", false, 0, "")
 
 	pdf.SetX(rightColX)
-	pdf.SetFont("Arial", "", 8)
+	pdf.SetFont(tmpl.Font, "", 8)
 	pdf.CellFormat(colWidth, 5, actors.ReceiveTime+" WIB", "", 0, "C", false, 0, "")
 
-	// 6. Save File
-	err := pdf.OutputFileAndClose(filePath)
-	return filePath, err
+	// 6. Output
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-func (s *service) ProcessInit(ctx context.Context, req HandoverRequest) error {
+func (s *service) ProcessInit(ctx context.Context, actor Actor, req HandoverRequest) error {
+	if err := Authorize(actor, req.Status); err != nil {
+		return err
+	}
+	// Pelaku selalu dari token, user_id dari client diabaikan
+	req.UserID = actor.UserID
+
 	if len(req.MInOutIDs) == 0 {
 		return errors.New("minimal satu Surat Jalan harus dipilih")
 	}
+	if err := validateInit(req.MInOutIDs, req.Status); err != nil {
+		return err
+	}
 	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
 		return err
@@ -191,27 +245,90 @@ func (s *service) ProcessInit(ctx context.Context, req HandoverRequest) error {
 	return tx.Commit()
 }
 
-func (s *service) ProcessHandover(ctx context.Context, req HandoverRequest) error {
+func (s *service) ProcessHandover(ctx context.Context, actor Actor, req HandoverRequest) (*HandoverResult, error) {
 	var oldDataList []TrackingSJ
 	var err error
 
+	if err := Authorize(actor, req.Status); err != nil {
+		return nil, err
+	}
+	// Pelaku selalu dari token, user_id dari client diabaikan
+	req.UserID = actor.UserID
+	if req.DriverBy, err = AuthorizeDriver(actor, req.Status, req.DriverBy); err != nil {
+		return nil, err
+	}
+
+	signatures, err := decodeSignatures(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.docNo.PrefixFor(req.Status); !ok && !signatures.empty() {
+		return nil, &SignatureError{Party: "giver/receiver", Reason: "status " + req.Status + " tidak membuat bundle"}
+	}
+
+	// Lokasi GPS & geofence dicek sebelum foto diunggah
+	loc, err := s.eventLocation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Foto divalidasi & diunggah sebelum transaksi; dihapus lagi jika proses gagal
+	decoded, err := decodePhotos(req)
+	if err != nil {
+		return nil, err
+	}
+	var photos []EventPhoto
+	committed := false
+	if len(decoded) > 0 {
+		var cleanup func()
+		photos, cleanup, err = s.storePhotos(ctx, decoded)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !committed {
+				cleanup()
+			}
+		}()
+	}
+
 	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
+	result := &HandoverResult{Documents: []DocumentResult{}}
+
 	if req.Status == "HO: DRIVER_CHECKOUT" && len(req.MInOutIDs) == 0 {
 		// 1. Catat log aktivitas ke event (tanpa update table ADW_STS)
-		err = s.repo.LogActivityOnly(ctx, tx, req)
+		eventID, err := s.repo.LogActivityOnly(ctx, tx, req, loc)
 		if err != nil {
-			return fmt.Errorf("gagal mencatat aktivitas checkout: %w", err)
+			return nil, fmt.Errorf("gagal mencatat aktivitas checkout: %w", err)
+		}
+		if len(photos) > 0 {
+			if err := s.repo.InsertEventPhotos(ctx, tx, []int64{eventID}, photos, req.UserID); err != nil {
+				return nil, err
+			}
+		}
+
+		// 2. Notifikasi sederhana dikirim worker outbox setelah commit
+		errO := s.repo.EnqueueOutbox(ctx, tx, OutboxCheckoutNoSJNotif, CheckoutNoSJPayload{
+			CustomerID: req.CurrentCustomer,
+			DriverBy:   req.DriverBy,
+			TNKBID:     req.TNKBID,
+			Notes:      req.Notes,
+		})
+		if errO != nil {
+			return nil, errO
 		}
 
-		// 2. Kirim Notifikasi Sederhana secara Async
-		go s.sendSimpleCheckoutNotification(req)
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		committed = true
 
-		return nil // Berhenti di sini karena tidak ada SJ yang diproses
+		return result, nil // Berhenti di sini karena tidak ada SJ yang diproses
 	}
 
 	// 1. Tentukan sumber data
@@ -219,32 +336,52 @@ func (s *service) ProcessHandover(ctx context.Context, req HandoverRequest) erro
 		// Tarik dari DB berdasarkan Driver ID
 		oldDataList, err = s.repo.GetByCustomerIDDriverID(ctx, req.CurrentCustomer, req.DriverBy)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(oldDataList) == 0 {
-			return fmt.Errorf("tidak ada Surat Jalan aktif untuk Driver ID %d", req.DriverBy)
+			return nil, fmt.Errorf("tidak ada Surat Jalan aktif untuk Driver ID %d", req.DriverBy)
 		}
 	} else {
 		// Skenario normal: Tarik berdasarkan m_inout_ids dari request
 		if len(req.MInOutIDs) == 0 {
-			return errors.New("minimal satu Surat Jalan harus dipilih")
+			return nil, errors.New("minimal satu Surat Jalan harus dipilih")
 		}
 
 		oldDataMap, err := s.repo.GetByMInOutIDs(ctx, tx, req.MInOutIDs)
 		if err != nil {
 			fmt.Printf("[GetByMInOutIDs]: error: %v\n", err)
-			return err
+			return nil, err
 		}
 
 		for _, id := range req.MInOutIDs {
 			data, exists := oldDataMap[id]
 			if !exists {
-				return fmt.Errorf("SJ ID %d belum di-proses INIT", id)
+				msg := fmt.Sprintf("SJ ID %d belum di-proses INIT", id)
+				if !req.Partial {
+					return nil, errors.New(msg)
+				}
+				// Mode partial: catat sebagai ditolak dan lanjut ke SJ berikutnya
+				result.reject(id, ReasonNotInitialized, msg)
+				continue
 			}
 			oldDataList = append(oldDataList, data)
 		}
 	}
 
+	// Tolak perpindahan status yang tidak ada di tabel transisi
+	if req.Partial {
+		var rejected []*TransitionError
+		oldDataList, rejected = splitByTransition(oldDataList, req.Status)
+		for _, tErr := range rejected {
+			result.reject(tErr.MInOutID, ReasonInvalidTransition, tErr.Error())
+		}
+		if len(oldDataList) == 0 {
+			return result, ErrNoDocumentAccepted
+		}
+	} else if err := validateTransitions(oldDataList, req.Status); err != nil {
+		return nil, err
+	}
+
 	// 2. Proses data yang sudah terkumpul (baik dari ID maupun dari Driver)
 	var entities []TrackingSJ
 	var stsIDs []int64
@@ -333,249 +470,546 @@ func (s *service) ProcessHandover(ctx context.Context, req HandoverRequest) erro
 		})
 		stsIDs = append(stsIDs, oldData.ID)
 		mInOutIDs = append(mInOutIDs, oldData.MInOutID)
+		result.accept(oldData.MInOutID)
 	}
 
-	err = s.repo.UpdateBatch(ctx, tx, entities, req.Status, req.Notes)
+	eventIDs, err := s.repo.UpdateBatch(ctx, tx, entities, req.Status, req.Notes, loc)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(photos) > 0 {
+		if err := s.repo.InsertEventPhotos(ctx, tx, eventIDs, photos, req.UserID); err != nil {
+			return nil, err
+		}
 	}
 
-	// 3. Logika Pembuatan Bundle & Persiapan PDF
-	var bundleDocNo string // ← Simpan DocumentNo di sini
-	var shouldGeneratePDF bool
-
-	if req.Status == "RE: DPK_FROM_DEL" ||
-		req.Status == "RE: DEL_FROM_DPK" ||
-		req.Status == "RE: MKT_FROM_DEL" ||
-		req.Status == "RE: FAT_FROM_MKT" {
-
-		shouldGeneratePDF = true
-
-		prefix := getPrefixForStatus(req.Status)
-		bundleDocNo = fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano()/1e6)
+	// 3. Logika Pembuatan Bundle & Persiapan PDF (status penerimaan yang punya prefix di DOCNO_PREFIXES)
+	if prefix, ok := s.docNo.PrefixFor(req.Status); ok {
+		bundleDocNo, errN := s.docNo.Next(ctx, s.repo, tx, prefix, time.Now())
+		if errN != nil {
+			return nil, errN
+		}
 
 		bundleHeader := ADWBundle{
-			DocumentNo:  bundleDocNo, // ← Gunakan variable yang sama
+			DocumentNo:  bundleDocNo,
 			BundleType:  req.Status,
 			Description: req.Notes,
 			CreatedBy:   req.UserID,
 			Attachment:  "",
+			Signatures:  signatures,
 		}
 
 		if errB := s.repo.CreateBundle(ctx, tx, bundleHeader, stsIDs); errB != nil {
-			return fmt.Errorf("gagal membuat bundle penerimaan: %w", errB)
+			return nil, fmt.Errorf("gagal membuat bundle penerimaan: %w", errB)
+		}
+		result.BundleNo = bundleDocNo
+
+		// PDF dibuat worker outbox setelah commit
+		errO := s.repo.EnqueueOutbox(ctx, tx, OutboxHandoverPdf, HandoverPdfPayload{
+			BundleNo:    bundleDocNo,
+			Status:      req.Status,
+			MInOutIDs:   mInOutIDs,
+			RequestedBy: req.UserID,
+		})
+		if errO != nil {
+			return nil, errO
+		}
+	}
+
+	// 4. Notifikasi WA check-in / check-out, dikirim worker outbox setelah commit
+	if req.Status == "HO: DRIVER_CHECKIN" || req.Status == "HO: DRIVER_CHECKOUT" {
+		customerID := req.CurrentCustomer
+		if customerID == 0 && len(entities) > 0 && entities[0].CurrentCustomer != nil {
+			customerID = *entities[0].CurrentCustomer
+		}
+		payload := DriverVisitPayload{
+			Status:     req.Status,
+			MInOutIDs:  mInOutIDs,
+			DriverBy:   req.DriverBy,
+			CustomerID: customerID,
+			TNKBID:     req.TNKBID,
+			Notes:      req.Notes,
+		}
+		if loc != nil && loc.GeofenceStatus != nil {
+			payload.Geofence = *loc.GeofenceStatus
+		}
+		errO := s.repo.EnqueueOutbox(ctx, tx, OutboxDriverVisitNotif, payload)
+		if errO != nil {
+			return nil, errO
 		}
 	}
 
-	// 4. Commit Transaksi
+	// 5. Commit Transaksi (update STS, foto, bundle & outbox sekaligus)
 	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return result, nil
+}
+
+// HandleOutbox menjalankan side effect dari satu pesan outbox
+func (s *service) HandleOutbox(ctx context.Context, msg OutboxMessage) error {
+	switch msg.EventType {
+	case OutboxHandoverPdf:
+		var p HandoverPdfPayload
+		if err := json.Unmarshal([]byte(msg.Payload), &p); err != nil {
+			return fmt.Errorf("payload tidak valid: %w", err)
+		}
+		_, err := s.buildHandoverPdf(ctx, p)
 		return err
+
+	case OutboxDriverVisitNotif:
+		var p DriverVisitPayload
+		if err := json.Unmarshal([]byte(msg.Payload), &p); err != nil {
+			return fmt.Errorf("payload tidak valid: %w", err)
+		}
+		return s.emitDriverVisit(ctx, p, outboxSource(msg))
+
+	case OutboxCheckoutNoSJNotif:
+		var p CheckoutNoSJPayload
+		if err := json.Unmarshal([]byte(msg.Payload), &p); err != nil {
+			return fmt.Errorf("payload tidak valid: %w", err)
+		}
+		return s.emitCheckoutNoSJ(ctx, p, outboxSource(msg))
+
+	default:
+		return fmt.Errorf("event outbox tidak dikenal: %s", msg.EventType)
 	}
+}
 
-	// 5. G5. Generate PDF & Update Attachment
-	if shouldGeneratePDF {
-		capturedIDs := make([]int64, len(mInOutIDs))
-		copy(capturedIDs, mInOutIDs)
-		capturedReq := req
-		capturedDocNo := bundleDocNo
+// buildHandoverPdf membuat PDF bundle dari data DB saat ini dan menyimpannya sebagai versi baru
+func (s *service) buildHandoverPdf(ctx context.Context, p HandoverPdfPayload) (*BundleAttachmentVersion, error) {
+	// A. Ambil detail SJ
+	details, err := s.repo.GetNotificationDetails(ctx, p.MInOutIDs, 0)
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil detail: %w", err)
+	}
 
-		go func() {
-			fmt.Printf("[PDF-DEBUG]: Querying IDs=%v\n", capturedIDs)
+	if len(details) == 0 {
+		return nil, fmt.Errorf("tidak ada detail untuk IDs: %v", p.MInOutIDs)
+	}
 
-			// A. Ambil detail SJ
-			details, errD := s.repo.GetNotificationDetails(context.Background(), capturedIDs, 0)
-			if errD != nil {
-				fmt.Printf("[PDF-ERR]: Gagal ambil detail: %v\n", errD)
-				return
-			}
+	// B. Ambil info Penyerah & Penerima
+	actors, errActor := s.repo.GetBundleActors(ctx, p.BundleNo)
+	if errActor != nil {
+		fmt.Printf("[PDF-WARN]: Gagal ambil actor info: %v\n", errActor)
+		// Set default jika gagal
+		actors = &BundleActorDTO{
+			PrevActorName:    "N/A",
+			CurrentActorName: "N/A",
+			EventType:        p.Status,
+			HandoverTime:     "N/A",
+			ReceiveTime:      time.Now().Format("02-01-2006 15:04"),
+		}
+	}
 
-			if len(details) == 0 {
-				fmt.Printf("[PDF-WARN]: Tidak ada detail untuk IDs: %v\n", capturedIDs)
-				return
-			}
+	// C. Tanda tangan (opsional), PDF tetap dibuat tanpa gambar jika gagal diambil
+	signatures, errSig := s.repo.GetBundleSignatures(ctx, p.BundleNo)
+	if errSig != nil {
+		fmt.Printf("[PDF-WARN]: Gagal ambil tanda tangan: %v\n", errSig)
+	}
 
-			// B. Ambil info Penyerah & Penerima
-			actors, errActor := s.repo.GetBundleActors(context.Background(), capturedDocNo)
-			if errActor != nil {
-				fmt.Printf("[PDF-WARN]: Gagal ambil actor info: %v\n", errActor)
-				// Set default jika gagal
-				actors = &BundleActorDTO{
-					PrevActorName:    "N/A",
-					CurrentActorName: "N/A",
-					EventType:        capturedReq.Status,
-					HandoverTime:     "N/A",
-					ReceiveTime:      time.Now().Format("02-01-2006 15:04"),
-				}
-			}
+	// D. Token verifikasi QR: HMAC atas nomor bundle, hash isi SJ dan waktu
+	bundle, err := s.repo.GetBundleByDocumentNo(ctx, p.BundleNo)
+	if err != nil {
+		return nil, err
+	}
 
-			// C. Generate PDF dengan info actors
-			filePath, errPdf := s.generateHandoverPdf(capturedDocNo, capturedReq, details, actors)
-			if errPdf != nil {
-				fmt.Printf("[PDF-ERR]: Gagal generate file: %v\n", errPdf)
-				return
-			}
+	lines := make([]VerifyLine, 0, len(details))
+	for _, d := range details {
+		lines = append(lines, VerifyLine{DocumentNo: d.DocumentNo, MovementDate: d.MovementDate, Customer: d.CustomerName})
+	}
 
-			fmt.Printf("[PDF-OK]: File tersimpan di %s\n", filePath)
+	fileID := uuid.New().String()
+	token, err := signVerifyToken(s.cfg.QRSecret, VerifyClaims{
+		BundleNo:   p.BundleNo,
+		FileID:     fileID,
+		LinesHash:  linesHash(lines),
+		BundleTime: bundle.Created.Unix(),
+		IssuedAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat token verifikasi: %w", err)
+	}
+	qrContent := strings.TrimRight(s.cfg.BaseURL, "/") + "/verify/" + token
 
-			// D. Update attachment di database
-			errUpdate := s.repo.UpdateBundleAttachment(context.Background(), capturedDocNo, filePath)
-			if errUpdate != nil {
-				fmt.Printf("[PDF-ERR]: Gagal update attachment: %v\n", errUpdate)
-			} else {
-				fmt.Printf("[PDF-OK]: Attachment updated untuk bundle %s\n", capturedDocNo)
-			}
-		}()
+	// E. Generate PDF dengan info actors
+	content, err := s.generateHandoverPdf(p.BundleNo, p.Status, details, actors, signatures, qrContent)
+	if err != nil {
+		return nil, fmt.Errorf("gagal generate file: %w", err)
 	}
 
-	// 6. Kirim Notifikasi WA (Logic tetap sama)
-	if req.Status == "HO: DRIVER_CHECKIN" || req.Status == "HO: DRIVER_CHECKOUT" {
-		// Ambil semua M_INOUT_ID dari entities untuk di-query detailnya
-		var ids []int64
-		for _, e := range entities {
-			ids = append(ids, e.MInOutID)
-		}
-
-		go func() {
-			// A. Ambil Data Detail (Join) dari Database
-			details, err := s.repo.GetNotificationDetails(context.Background(), ids, req.DriverBy)
-			if err != nil {
-				fmt.Printf("[WA-ERROR]: Gagal ambil detail: %v\n", err)
-				return
-			}
+	storageKey := fmt.Sprintf("handover/handover_%s.pdf", fileID)
+	if err := s.store.Put(ctx, storageKey, bytes.NewReader(content), "application/pdf"); err != nil {
+		return nil, fmt.Errorf("gagal simpan PDF: %w", err)
+	}
+	contentHash := contentSHA256(content)
+
+	// F. Simpan sebagai versi baru (file versi lama tetap ada & bisa diunduh)
+	version, err := s.repo.AddBundleAttachment(ctx, p.BundleNo, BundleAttachmentVersion{
+		Attachment:  storageKey,
+		CreatedBy:   p.RequestedBy,
+		VerifyID:    &fileID,
+		ContentHash: &contentHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	version.URL = s.attachmentURL(&version.Attachment)
 
-			if len(details) == 0 {
-				return
-			}
+	fmt.Printf("[PDF-OK]: Attachment v%d updated untuk bundle %s (%s)\n", version.Version, p.BundleNo, storageKey)
+	return version, nil
+}
 
-			var titleDisplay string
-			if req.Status == "HO: DRIVER_CHECKIN" {
-				titleDisplay = "Driver Check-In Customer"
-			} else {
-				titleDisplay = "Driver Check-Out Customer"
-			}
+// outboxSource: event dari pesan outbox yang sama tidak dikirim dua kali saat pesan diproses ulang
+func outboxSource(msg OutboxMessage) string {
+	return fmt.Sprintf("outbox:%d", msg.ID)
+}
 
-			// B. Susun Pesan WA yang Cantik
-			driverName := details[0].DriverName // Ambil dari baris pertama
-			checkinTime := details[0].Time
-			tnkbName := details[0].TNKB
-			customerName := details[0].CustomerName
-
-			msg := fmt.Sprintf("*%s*\n\n", titleDisplay)
-			msg += fmt.Sprintf("Driver: *%s*\n", driverName)
-			msg += fmt.Sprintf("TNKB: *%s*\n", tnkbName)
-			msg += fmt.Sprintf("Customer: *%s*\n", customerName)
-			msg += fmt.Sprintf("Waktu   : *%s*\n", checkinTime) // Menggunakan field Time
-			// msg += fmt.Sprintf("Catatan: %s\n\n", req.Notes)
-			msg += fmt.Sprintf("Catatan: %s\n\n", "-")
-			msg += "*Daftar Surat Jalan:*\n"
-
-			for i, d := range details {
-				msg += fmt.Sprintf("%d. *%s* - %s\n", i+1, d.DocumentNo, d.CustomerName)
-			}
-			msg += fmt.Sprintf("\n_Total: %d Surat Jalan_", len(details))
-
-			// Core WA Server
-			// errNotif := s.notifService.SendDriverCheckinNotification(context.Background(), msg)
-			// if errNotif != nil {
-			// 	fmt.Printf("[WA-ERROR]: %v\n", errNotif)
-			// }
-
-			// Wa Server External
-			go func(message string) {
-				url := "http://192.168.1.113:3002/send_group_message"
-
-				// Buat payload map
-				payload := map[string]string{
-					"groupId": "120363421649034694@g.us",
-					"message": message,
-				}
+// emitDriverVisit melengkapi data check-in / check-out lalu menerbitkannya sebagai domain event
+func (s *service) emitDriverVisit(ctx context.Context, p DriverVisitPayload, source string) error {
+	details, err := s.repo.GetNotificationDetails(ctx, p.MInOutIDs, p.DriverBy)
+	if err != nil {
+		return fmt.Errorf("gagal ambil detail: %w", err)
+	}
 
-				jsonPayload, _ := json.Marshal(payload)
+	if len(details) == 0 {
+		return nil
+	}
 
-				resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
-				if err != nil {
-					fmt.Printf("[WA-ERROR-GROUP]: Gagal kirim HTTP Post: %v\n", err)
-					return
-				}
-				defer resp.Body.Close()
+	eventType := notification.EventDriverCheckin
+	if p.Status == StatusDriverCheckout {
+		eventType = notification.EventDriverCheckout
+	}
 
-				if resp.StatusCode != http.StatusOK {
-					fmt.Printf("[WA-ERROR-GROUP]: Status code tidak 200: %d\n", resp.StatusCode)
-				}
-			}(msg)
-		}()
+	event := notifyEvent(eventType, p.CustomerID, p.DriverBy, p.Notes, details)
+	event.TNKBID = p.TNKBID
+	event.SourceKey = source
+	if p.Geofence == GeofenceOutside {
+		event.Exception = true
+		event.ExceptionReason = "Check-in di luar area geofence customer"
 	}
+	return s.events.Publish(ctx, event)
+}
 
-	return nil
+// emitCheckoutNoSJ menerbitkan event check-out tanpa SJ
+func (s *service) emitCheckoutNoSJ(ctx context.Context, p CheckoutNoSJPayload, source string) error {
+	event := s.checkoutNoSJEvent(ctx, p)
+	event.SourceKey = source
+	return s.events.Publish(ctx, event)
 }
 
-func (s *service) sendSimpleCheckoutNotification(req HandoverRequest) {
-	// 1. Ambil detail nama driver & customer dari DB
-	details, err := s.repo.GetNotifLogActivityOnlyDetail(context.Background(), req.CurrentCustomer, req.DriverBy)
+func (s *service) checkoutNoSJEvent(ctx context.Context, p CheckoutNoSJPayload) notification.Event {
+	var event notification.Event
 
-	var driverName, customerName, currentTime string
+	details, err := s.repo.GetNotifLogActivityOnlyDetail(ctx, p.CustomerID, p.DriverBy)
 	if err != nil || len(details) == 0 {
 		// Fallback jika query gagal
-		driverName = fmt.Sprintf("ID: %d", req.DriverBy)
-		customerName = fmt.Sprintf("ID: %d", req.CurrentCustomer)
-		currentTime = "Baru saja"
+		event = notification.Event{
+			Type:       notification.EventDriverCheckoutNoSJ,
+			CustomerID: p.CustomerID,
+			Customer:   fmt.Sprintf("ID: %d", p.CustomerID),
+			DriverID:   p.DriverBy,
+			Driver:     fmt.Sprintf("ID: %d", p.DriverBy),
+			Time:       "Baru saja",
+			Notes:      p.Notes,
+		}
 	} else {
-		driverName = details[0].DriverName
-		customerName = details[0].CustomerName
-		currentTime = details[0].Time
+		// Check-out tanpa SJ tidak punya daftar dokumen
+		event = notifyEvent(notification.EventDriverCheckoutNoSJ, p.CustomerID, p.DriverBy, p.Notes, details)
+		event.Details = nil
 	}
 
-	// 2. Susun pesan WA yang lebih informatif
-	msg := "*Driver Check-Out (Tanpa SJ)*\n\n"
-	msg += fmt.Sprintf("Driver: *%s*\n", driverName)
-	msg += fmt.Sprintf("Lokasi: *%s*\n", customerName)
-	msg += fmt.Sprintf("Waktu : *%s*\n", currentTime)
-	msg += fmt.Sprintf("Catatan: %s\n\n", req.Notes)
-	msg += "_Keterangan: Driver telah meninggalkan lokasi customer tanpa membawa kembali dokumen Surat Jalan._"
-
-	// 3. Kirim Core WA Server
-	// errNotif := s.notifService.SendDriverCheckinNotification(context.Background(), msg)
-	// if errNotif != nil {
-	// 	fmt.Printf("[WA-ERROR]: %v\n", errNotif)
-	// }
+	event.TNKBID = p.TNKBID
+	event.Exception = true
+	event.ExceptionReason = "Driver check-out tanpa membawa Surat Jalan"
+	return event
+}
 
-	// WA Server
-	go func(message string) {
-		url := "http://192.168.1.113:3002/send_group_message"
+// notifyEvent menyusun domain event dari baris HandoverNotifyDTO (header diambil dari baris pertama)
+func notifyEvent(eventType string, customerID, driverID int64, notes string, details []HandoverNotifyDTO) notification.Event {
+	event := notification.Event{
+		Type:       eventType,
+		CustomerID: customerID,
+		DriverID:   driverID,
+		Notes:      notes,
+	}
+	if len(details) > 0 {
+		event.Customer = details[0].CustomerName
+		event.Driver = details[0].DriverName
+		event.TNKB = details[0].TNKB
+		event.Time = details[0].Time
+	}
+	for _, d := range details {
+		event.Details = append(event.Details, notification.Detail{
+			DocumentNo:   d.DocumentNo,
+			CustomerName: d.CustomerName,
+			DriverName:   d.DriverName,
+			Time:         d.Time,
+			MovementDate: d.MovementDate,
+			TNKB:         d.TNKB,
+			SppNo:        d.SppNo,
+		})
+	}
+	return event
+}
 
-		// Buat payload map
-		payload := map[string]string{
-			"groupId": "120363421649034694@g.us",
-			"message": message,
+// PreviewEvent menyusun event dari bundle atau kunjungan driver yang sudah ada, untuk preview template
+func (s *service) PreviewEvent(ctx context.Context, ref notification.PreviewRef) (*notification.Event, error) {
+	if ref.BundleNo != "" {
+		bundle, err := s.repo.GetBundleByDocumentNo(ctx, ref.BundleNo)
+		if errors.Is(err, ErrBundleNotFound) {
+			return nil, fmt.Errorf("bundle %s: %w", ref.BundleNo, notification.ErrPreviewNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines, err := s.repo.GetBundleLines(ctx, bundle.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, 0, len(lines))
+		for _, l := range lines {
+			ids = append(ids, l.MInOutID)
 		}
+		details, err := s.repo.GetNotificationDetails(ctx, ids, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		event := notifyEvent(ref.EventType, 0, 0, bundle.Description, details)
+		event.OccurredAt = bundle.Created
+		return &event, nil
+	}
 
-		jsonPayload, _ := json.Marshal(payload)
+	visit, err := s.repo.GetDriverVisit(ctx, ref.EventID)
+	if errors.Is(err, ErrEventNotFound) {
+		return nil, fmt.Errorf("event %d: %w", ref.EventID, notification.ErrPreviewNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
+	var event notification.Event
+	if len(visit.MInOutIDs) == 0 {
+		event = s.checkoutNoSJEvent(ctx, CheckoutNoSJPayload{
+			CustomerID: visit.CustomerID,
+			DriverBy:   visit.DriverBy,
+			Notes:      visit.Notes,
+		})
+	} else {
+		details, err := s.repo.GetNotificationDetails(ctx, visit.MInOutIDs, 0)
 		if err != nil {
-			fmt.Printf("[WA-ERROR-GROUP]: Gagal kirim HTTP Post: %v\n", err)
-			return
+			return nil, err
 		}
-		defer resp.Body.Close()
+		event = notifyEvent(ref.EventType, visit.CustomerID, visit.DriverBy, visit.Notes, details)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			fmt.Printf("[WA-ERROR-GROUP]: Status code tidak 200: %d\n", resp.StatusCode)
-		}
-	}(msg)
+	event.Type = ref.EventType
+	event.OccurredAt = visit.Created
+	event.Time = visit.Created.Format("02-01-2006 15:04")
+	return &event, nil
 }
 
-// Helper function
-func getPrefixForStatus(status string) string {
-	switch status {
-	case "RE: DPK_FROM_DEL":
-		return "HOPT"
-	case "RE: DEL_FROM_DPK":
-		return "HITP"
-	case "RE: MKT_FROM_DEL":
-		return "HIPM"
-	case "RE: FAT_FROM_MKT":
-		return "HIMF"
-	default:
-		return "RECV"
+func (s *service) ListBundles(ctx context.Context, q BundleQuery) (*BundlePage, error) {
+	dateFrom, dateTo := shared.ParseDateRange(q.DateFrom, q.DateTo)
+
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := q.PageSize
+	if pageSize < 1 {
+		pageSize = defaultBundlePageSize
+	}
+	if pageSize > maxBundlePageSize {
+		pageSize = maxBundlePageSize
+	}
+
+	list, total, err := s.repo.ListBundles(ctx, BundleFilter{
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+		BundleType: q.BundleType,
+		ActorID:    q.ActorID,
+		CustomerID: q.CustomerID,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		list[i].AttachmentURL = s.attachmentURL(list[i].Attachment)
+	}
+
+	return &BundlePage{
+		Items:    list,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) GetBundle(ctx context.Context, documentNo string) (*BundleDetail, error) {
+	bundle, err := s.repo.GetBundleByDocumentNo(ctx, documentNo)
+	if err != nil {
+		return nil, err
+	}
+	bundle.AttachmentURL = s.attachmentURL(bundle.Attachment)
+
+	lines, err := s.repo.GetBundleLines(ctx, bundle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.bundleVersions(ctx, bundle.ID)
+	if err != nil {
+		return nil, err
 	}
+
+	// Actor bisa kosong untuk bundle lama yang event-nya sudah tidak ada
+	actors, err := s.repo.GetBundleActors(ctx, documentNo)
+	if err != nil {
+		fmt.Printf("[BUNDLE-WARN]: Actor bundle %s tidak ditemukan: %v\n", documentNo, err)
+		actors = nil
+	}
+
+	return &BundleDetail{
+		BundleSummary: *bundle,
+		Actors:        actors,
+		Lines:         lines,
+		Versions:      versions,
+	}, nil
+}
+
+func (s *service) GetBundleVersions(ctx context.Context, documentNo string) ([]BundleAttachmentVersion, error) {
+	bundle, err := s.repo.GetBundleByDocumentNo(ctx, documentNo)
+	if err != nil {
+		return nil, err
+	}
+	return s.bundleVersions(ctx, bundle.ID)
+}
+
+func (s *service) bundleVersions(ctx context.Context, bundleID int64) ([]BundleAttachmentVersion, error) {
+	versions, err := s.repo.GetBundleAttachments(ctx, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		versions[i].URL = s.attachmentURL(&versions[i].Attachment)
+	}
+	return versions, nil
+}
+
+// RegenerateBundlePdf membuat ulang PDF bundle dari kondisi DB saat ini (mis. setelah PDF async gagal
+// atau nama actor masih "N/A"). Hanya role yang boleh melakukan status bundle tersebut yang bisa regenerate.
+func (s *service) RegenerateBundlePdf(ctx context.Context, actor Actor, documentNo string) (*BundleAttachmentVersion, error) {
+	bundle, err := s.repo.GetBundleByDocumentNo(ctx, documentNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Authorize(actor, bundle.BundleType); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.repo.GetBundleLines(ctx, bundle.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("bundle %s tidak memiliki line", documentNo)
+	}
+
+	mInOutIDs := make([]int64, 0, len(lines))
+	for _, l := range lines {
+		mInOutIDs = append(mInOutIDs, l.MInOutID)
+	}
+
+	return s.buildHandoverPdf(ctx, HandoverPdfPayload{
+		BundleNo:    bundle.DocumentNo,
+		Status:      bundle.BundleType,
+		MInOutIDs:   mInOutIDs,
+		RequestedBy: actor.UserID,
+	})
+}
+
+// attachmentURL mengubah ATTACHMENT menjadi URL unduhan. Nilainya storage key (handover/x.pdf);
+// data lama masih berupa path lokal (uploads/handover/x.pdf) dan tetap dikenali.
+func (s *service) attachmentURL(key *string) string {
+	if key == nil || *key == "" {
+		return ""
+	}
+	return storage.URL(s.cfg.BaseURL, *key)
+}
+
+// VerifyDocument memeriksa token dari QR PDF: tanda tangan HMAC, keberadaan bundle & file,
+// kecocokan isi SJ saat ini, dan (opsional) hash PDF yang dimiliki client.
+func (s *service) VerifyDocument(ctx context.Context, token, clientHash string) (*VerifyResult, error) {
+	claims, err := parseVerifyToken(s.cfg.QRSecret, token)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.repo.GetBundleByDocumentNo(ctx, claims.BundleNo)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Created.Unix() != claims.BundleTime {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	versions, err := s.repo.GetBundleAttachments(ctx, bundle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *BundleAttachmentVersion
+	for i := range versions {
+		if versions[i].VerifyID != nil && *versions[i].VerifyID == claims.FileID {
+			file = &versions[i]
+			break
+		}
+	}
+	if file == nil {
+		return nil, ErrVerifyFileNotFound
+	}
+
+	bundleLines, err := s.repo.GetBundleLines(ctx, bundle.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]VerifyLine, 0, len(bundleLines))
+	for _, l := range bundleLines {
+		lines = append(lines, VerifyLine{
+			DocumentNo:    l.DocumentNo,
+			MovementDate:  l.MovementDate.Format("02-01-2006"),
+			Customer:      l.Customer,
+			CurrentStatus: l.CurrentStatus,
+		})
+	}
+
+	result := &VerifyResult{
+		BundleNo:      bundle.DocumentNo,
+		BundleType:    bundle.BundleType,
+		BundleCreated: bundle.Created,
+		IssuedAt:      time.Unix(claims.IssuedAt, 0),
+		Version:       file.Version,
+		LatestVersion: file.Version == versions[0].Version, // versions urut terbaru dulu
+		Lines:         lines,
+		LinesMatch:    linesHash(lines) == claims.LinesHash,
+	}
+	if file.ContentHash != nil {
+		result.ContentHash = *file.ContentHash
+	}
+
+	if clientHash != "" {
+		match := strings.EqualFold(strings.TrimSpace(clientHash), result.ContentHash)
+		result.ContentHashMatch = &match
+	}
+
+	return result, nil
 }
@@ -0,0 +1,115 @@
+package handover
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"log"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	maxSignatureBytes = 512 * 1024
+	maxSignatureSide  = 2000 // px
+	signatureHeight   = 15.0 // mm, tinggi area tanda tangan di PDF
+)
+
+// SignatureError dikembalikan jika gambar tanda tangan tidak valid. Handler memetakan ke HTTP 400.
+type SignatureError struct {
+	Party  string `json:"party"` // giver / receiver
+	Reason string `json:"reason"`
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("tanda tangan %s tidak valid: %s", e.Party, e.Reason)
+}
+
+// BundleSignatures adalah tanda tangan penyerah & penerima (PNG) yang disimpan di ADW_STS_BUNDLE
+type BundleSignatures struct {
+	Giver    []byte
+	Receiver []byte
+}
+
+func (s BundleSignatures) empty() bool {
+	return len(s.Giver) == 0 && len(s.Receiver) == 0
+}
+
+// decodeSignatures membaca tanda tangan base64 dari request (boleh berbentuk data URI "data:image/png;base64,...")
+func decodeSignatures(req HandoverRequest) (BundleSignatures, error) {
+	var sig BundleSignatures
+	var err error
+
+	if sig.Giver, err = decodeSignature("giver", req.GiverSignature); err != nil {
+		return sig, err
+	}
+	if sig.Receiver, err = decodeSignature("receiver", req.ReceiverSignature); err != nil {
+		return sig, err
+	}
+	return sig, nil
+}
+
+func decodeSignature(party, raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(raw, "data:") {
+		idx := strings.Index(raw, ",")
+		if idx < 0 || !strings.HasPrefix(raw, "data:image/png;base64") {
+			return nil, &SignatureError{Party: party, Reason: "data URI harus image/png;base64"}
+		}
+		raw = raw[idx+1:]
+	}
+
+	if base64.StdEncoding.DecodedLen(len(raw)) > maxSignatureBytes {
+		return nil, &SignatureError{Party: party, Reason: "ukuran maksimal 512KB"}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, &SignatureError{Party: party, Reason: "base64 tidak valid"}
+	}
+
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, &SignatureError{Party: party, Reason: "bukan file PNG"}
+	}
+	if cfg.Width == 0 || cfg.Height == 0 || cfg.Width > maxSignatureSide || cfg.Height > maxSignatureSide {
+		return nil, &SignatureError{Party: party, Reason: fmt.Sprintf("dimensi %dx%d tidak didukung", cfg.Width, cfg.Height)}
+	}
+
+	return data, nil
+}
+
+// drawSignature menggambar PNG tanda tangan di tengah kolom (x, lebar colWidth) di bawah label.
+// Tanpa gambar area tetap kosong seperti sebelumnya.
+func drawSignature(pdf *gofpdf.Fpdf, name string, data []byte, x, y, colWidth float64) {
+	if len(data) == 0 {
+		return
+	}
+
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	info := pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(data))
+	if pdf.Err() {
+		// Gambar rusak jangan menggagalkan seluruh PDF
+		log.Printf("[SERVICE] signature image=%s error=%v", name, pdf.Error())
+		pdf.ClearError()
+		return
+	}
+	if info == nil || info.Height() == 0 {
+		return
+	}
+
+	h := signatureHeight - 1
+	w := h * info.Width() / info.Height()
+	if w > colWidth {
+		w = colWidth
+		h = w * info.Height() / info.Width()
+	}
+
+	pdf.ImageOptions(name, x+(colWidth-w)/2, y, w, h, false, opts, 0, "")
+}
@@ -0,0 +1,127 @@
+package handover
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status yang dipakai di ADW_STS.STATUS dan ADW_STS_EVENT.EVENTTYPE
+const (
+	StatusDelToDpk       = "HO: DEL_TO_DPK"
+	StatusDpkFromDel     = "RE: DPK_FROM_DEL"
+	StatusDpkToDriver    = "HO: DPK_TO_DRIVER"
+	StatusDriverCheckin  = "HO: DRIVER_CHECKIN"
+	StatusDriverCheckout = "HO: DRIVER_CHECKOUT"
+	StatusDpkFromDriver  = "RE: DPK_FROM_DRIVER"
+	StatusDpkToDel       = "HO: DPK_TO_DEL"
+	StatusDelFromDpk     = "RE: DEL_FROM_DPK"
+	StatusDelToMkt       = "HO: DEL_TO_MKT"
+	StatusMktFromDel     = "RE: MKT_FROM_DEL"
+	StatusMktToFat       = "HO: MKT_TO_FAT"
+	StatusFatFromMkt     = "RE: FAT_FROM_MKT"
+)
+
+var ErrNoDocumentAccepted = errors.New("tidak ada Surat Jalan yang valid untuk diproses")
+
+// initialStatuses adalah status yang boleh dipakai saat INIT (belum ada ADW_STS)
+var initialStatuses = []string{StatusDelToDpk}
+
+// transitions: status sekarang -> status berikutnya yang diizinkan
+var transitions = map[string][]string{
+	StatusDelToDpk:       {StatusDpkFromDel},
+	StatusDpkFromDel:     {StatusDpkToDriver},
+	StatusDpkToDriver:    {StatusDriverCheckin, StatusDpkFromDriver},
+	StatusDriverCheckin:  {StatusDriverCheckout, StatusDpkFromDriver},
+	StatusDriverCheckout: {StatusDpkFromDriver},
+	StatusDpkFromDriver:  {StatusDpkToDel},
+	StatusDpkToDel:       {StatusDelFromDpk},
+	StatusDelFromDpk:     {StatusDelToMkt},
+	StatusDelToMkt:       {StatusMktFromDel},
+	StatusMktFromDel:     {StatusMktToFat},
+	StatusMktToFat:       {StatusFatFromMkt},
+	StatusFatFromMkt:     {},
+}
+
+// TransitionError dikembalikan jika perpindahan status SJ tidak sesuai tabel transisi.
+// Handler memetakan error ini ke HTTP 409.
+type TransitionError struct {
+	MInOutID      int64    `json:"m_inout_id"`
+	CurrentStatus string   `json:"current_status"`
+	Requested     string   `json:"requested_status"`
+	Allowed       []string `json:"allowed_statuses"`
+}
+
+func (e *TransitionError) Error() string {
+	allowed := "-"
+	if len(e.Allowed) > 0 {
+		allowed = strings.Join(e.Allowed, ", ")
+	}
+	if e.CurrentStatus == "" {
+		return fmt.Sprintf("SJ ID %d: status %q tidak bisa dipakai untuk INIT (diizinkan: %s)",
+			e.MInOutID, e.Requested, allowed)
+	}
+	return fmt.Sprintf("SJ ID %d berstatus %q, tidak bisa pindah ke %q (diizinkan: %s)",
+		e.MInOutID, e.CurrentStatus, e.Requested, allowed)
+}
+
+// AllowedNext mengembalikan daftar status berikutnya yang sah dari status sekarang
+func AllowedNext(current string) []string {
+	return transitions[current]
+}
+
+// CanTransition mengecek apakah perpindahan from -> to terdaftar di tabel transisi
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInit memastikan status INIT adalah status awal
+func validateInit(mInOutIDs []int64, status string) error {
+	for _, s := range initialStatuses {
+		if s == status {
+			return nil
+		}
+	}
+
+	var id int64
+	if len(mInOutIDs) > 0 {
+		id = mInOutIDs[0]
+	}
+	return &TransitionError{
+		MInOutID:  id,
+		Requested: status,
+		Allowed:   initialStatuses,
+	}
+}
+
+// validateTransitions mengecek setiap SJ terhadap tabel transisi dan berhenti di pelanggaran pertama
+func validateTransitions(list []TrackingSJ, next string) error {
+	if _, rejected := splitByTransition(list, next); len(rejected) > 0 {
+		return rejected[0]
+	}
+	return nil
+}
+
+// splitByTransition memisahkan SJ yang boleh pindah status dari yang ditolak (dipakai mode partial)
+func splitByTransition(list []TrackingSJ, next string) ([]TrackingSJ, []*TransitionError) {
+	var valid []TrackingSJ
+	var rejected []*TransitionError
+	for _, sj := range list {
+		if CanTransition(sj.Status, next) {
+			valid = append(valid, sj)
+			continue
+		}
+		rejected = append(rejected, &TransitionError{
+			MInOutID:      sj.MInOutID,
+			CurrentStatus: sj.Status,
+			Requested:     next,
+			Allowed:       AllowedNext(sj.Status),
+		})
+	}
+	return valid, rejected
+}
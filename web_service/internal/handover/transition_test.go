@@ -0,0 +1,196 @@
+package handover
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransitionsTable(t *testing.T) {
+	// Setiap status tujuan juga harus terdaftar sebagai status asal, supaya SJ tidak buntu
+	for from, nexts := range transitions {
+		for _, next := range nexts {
+			if _, ok := transitions[next]; !ok {
+				t.Errorf("%q -> %q: status tujuan tidak ada di tabel transisi", from, next)
+			}
+		}
+	}
+
+	// Setiap status harus punya aturan role
+	for status := range transitions {
+		if _, ok := statusRoles[status]; !ok {
+			t.Errorf("status %q tidak punya role di statusRoles", status)
+		}
+	}
+
+	// Semua status bisa dicapai dari status awal
+	reached := map[string]bool{}
+	queue := append([]string(nil), initialStatuses...)
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if reached[s] {
+			continue
+		}
+		reached[s] = true
+		queue = append(queue, transitions[s]...)
+	}
+	for status := range transitions {
+		if !reached[status] {
+			t.Errorf("status %q tidak bisa dicapai dari status awal", status)
+		}
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusDelToDpk, StatusDpkFromDel, true},
+		{StatusDpkFromDel, StatusDpkToDriver, true},
+		{StatusDpkToDriver, StatusDriverCheckin, true},
+		{StatusDpkToDriver, StatusDpkFromDriver, true}, // driver tidak check-in, SJ kembali
+		{StatusDriverCheckin, StatusDriverCheckout, true},
+		{StatusDriverCheckin, StatusDpkFromDriver, true},
+		{StatusDriverCheckout, StatusDpkFromDriver, true},
+		{StatusMktToFat, StatusFatFromMkt, true},
+
+		{StatusDelToDpk, StatusDpkToDriver, false}, // lompat stage
+		{StatusDpkFromDel, StatusDelToDpk, false},  // mundur
+		{StatusDriverCheckout, StatusDriverCheckin, false},
+		{StatusDriverCheckin, StatusDriverCheckin, false},
+		{StatusFatFromMkt, StatusDelToDpk, false}, // status akhir
+		{"", StatusDelToDpk, false},
+		{"TIDAK ADA", StatusDpkFromDel, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestValidateInit(t *testing.T) {
+	if err := validateInit([]int64{10}, StatusDelToDpk); err != nil {
+		t.Errorf("validateInit(%q) = %v, want nil", StatusDelToDpk, err)
+	}
+
+	err := validateInit([]int64{10, 11}, StatusDpkFromDel)
+	var te *TransitionError
+	if !errors.As(err, &te) {
+		t.Fatalf("validateInit = %v, want *TransitionError", err)
+	}
+	want := &TransitionError{MInOutID: 10, Requested: StatusDpkFromDel, Allowed: initialStatuses}
+	if !reflect.DeepEqual(te, want) {
+		t.Errorf("validateInit = %+v, want %+v", te, want)
+	}
+
+	if err := validateInit(nil, StatusDpkFromDel); err == nil {
+		t.Error("validateInit tanpa SJ = nil, want error")
+	}
+}
+
+func TestSplitByTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     []TrackingSJ
+		next     string
+		valid    []int64
+		rejected []*TransitionError
+	}{
+		{
+			name: "semua valid",
+			list: []TrackingSJ{
+				{MInOutID: 1, Status: StatusDpkToDriver},
+				{MInOutID: 2, Status: StatusDriverCheckin},
+			},
+			next:  StatusDpkFromDriver,
+			valid: []int64{1, 2},
+		},
+		{
+			name: "campuran, urutan dipertahankan",
+			list: []TrackingSJ{
+				{MInOutID: 1, Status: StatusDelToDpk},
+				{MInOutID: 2, Status: StatusDpkFromDel},
+				{MInOutID: 3, Status: StatusDelToDpk},
+				{MInOutID: 4, Status: StatusFatFromMkt},
+			},
+			next:  StatusDpkFromDel,
+			valid: []int64{1, 3},
+			rejected: []*TransitionError{
+				{MInOutID: 2, CurrentStatus: StatusDpkFromDel, Requested: StatusDpkFromDel, Allowed: []string{StatusDpkToDriver}},
+				{MInOutID: 4, CurrentStatus: StatusFatFromMkt, Requested: StatusDpkFromDel, Allowed: []string{}},
+			},
+		},
+		{
+			name: "status tidak dikenal",
+			list: []TrackingSJ{{MInOutID: 9, Status: "LAIN"}},
+			next: StatusDpkFromDel,
+			rejected: []*TransitionError{
+				{MInOutID: 9, CurrentStatus: "LAIN", Requested: StatusDpkFromDel},
+			},
+		},
+		{
+			name: "kosong",
+			next: StatusDpkFromDel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, rejected := splitByTransition(tt.list, tt.next)
+
+			var ids []int64
+			for _, sj := range valid {
+				ids = append(ids, sj.MInOutID)
+			}
+			if !reflect.DeepEqual(ids, tt.valid) {
+				t.Errorf("valid = %v, want %v", ids, tt.valid)
+			}
+			if !reflect.DeepEqual(rejected, tt.rejected) {
+				t.Errorf("rejected = %+v, want %+v", rejected, tt.rejected)
+			}
+
+			// validateTransitions mengembalikan penolakan pertama
+			err := validateTransitions(tt.list, tt.next)
+			if len(tt.rejected) == 0 {
+				if err != nil {
+					t.Errorf("validateTransitions = %v, want nil", err)
+				}
+				return
+			}
+			var te *TransitionError
+			if !errors.As(err, &te) || !reflect.DeepEqual(te, tt.rejected[0]) {
+				t.Errorf("validateTransitions = %v, want %+v", err, tt.rejected[0])
+			}
+		})
+	}
+}
+
+func TestTransitionErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *TransitionError
+		want string
+	}{
+		{
+			err:  &TransitionError{MInOutID: 5, CurrentStatus: StatusDelToDpk, Requested: StatusDelToMkt, Allowed: []string{StatusDpkFromDel}},
+			want: `SJ ID 5 berstatus "HO: DEL_TO_DPK", tidak bisa pindah ke "HO: DEL_TO_MKT" (diizinkan: RE: DPK_FROM_DEL)`,
+		},
+		{
+			err:  &TransitionError{MInOutID: 5, CurrentStatus: StatusFatFromMkt, Requested: StatusDelToDpk},
+			want: `SJ ID 5 berstatus "RE: FAT_FROM_MKT", tidak bisa pindah ke "HO: DEL_TO_DPK" (diizinkan: -)`,
+		},
+		{
+			err:  &TransitionError{MInOutID: 7, Requested: StatusDpkFromDel, Allowed: []string{StatusDelToDpk}},
+			want: `SJ ID 7: status "RE: DPK_FROM_DEL" tidak bisa dipakai untuk INIT (diizinkan: HO: DEL_TO_DPK)`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %s, want %s", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package handover
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidVerifyToken = errors.New("token verifikasi tidak valid")
+	ErrVerifyFileNotFound = errors.New("dokumen untuk token ini tidak ditemukan")
+)
+
+// VerifyClaims adalah isi token QR. Token = base64url(claims) + "." + base64url(HMAC-SHA256(claims)).
+type VerifyClaims struct {
+	BundleNo   string `json:"b"`
+	FileID     string `json:"f"` // ID file PDF (ADW_STS_BUNDLE_ATTACHMENT.VERIFYID)
+	LinesHash  string `json:"h"` // hash isi SJ saat PDF dibuat
+	BundleTime int64  `json:"c"` // ADW_STS_BUNDLE.CREATED (unix)
+	IssuedAt   int64  `json:"t"`
+}
+
+// VerifyLine adalah SJ pada response verifikasi
+type VerifyLine struct {
+	DocumentNo    string `json:"document_no"`
+	MovementDate  string `json:"movement_date"`
+	Customer      string `json:"customer"`
+	CurrentStatus string `json:"current_status"`
+}
+
+// VerifyResult adalah response GET /verify/{token}
+type VerifyResult struct {
+	BundleNo      string       `json:"bundle_no"`
+	BundleType    string       `json:"bundle_type"`
+	BundleCreated time.Time    `json:"bundle_created"`
+	IssuedAt      time.Time    `json:"issued_at"`
+	Version       int          `json:"version"`
+	LatestVersion bool         `json:"latest_version"`
+	Lines         []VerifyLine `json:"lines"`
+	LinesMatch    bool         `json:"lines_match"` // isi SJ di DB masih sama dengan saat PDF dibuat
+	ContentHash   string       `json:"content_hash"`
+	// nil jika client tidak mengirim ?sha256=, selain itu hasil perbandingan hash PDF milik client
+	ContentHashMatch *bool `json:"content_hash_match,omitempty"`
+}
+
+func signVerifyToken(secret string, claims VerifyClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(verifyMAC(secret, payload)), nil
+}
+
+func parseVerifyToken(secret, token string) (*VerifyClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidVerifyToken
+	}
+	mac, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	if !hmac.Equal(mac, verifyMAC(secret, payload)) {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	var claims VerifyClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.BundleNo == "" || claims.FileID == "" {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	return &claims, nil
+}
+
+func verifyMAC(secret string, payload []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// linesHash membuat hash dari isi SJ (urutan tidak berpengaruh).
+// Setiap line: nomor SJ | tanggal (DD-MM-YYYY) | customer.
+func linesHash(lines []VerifyLine) string {
+	items := make([]string, 0, len(lines))
+	for _, l := range lines {
+		items = append(items, l.DocumentNo+"|"+l.MovementDate+"|"+l.Customer)
+	}
+	sort.Strings(items)
+
+	h := sha256.New()
+	for _, item := range items {
+		h.Write([]byte(item + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// contentSHA256 menghitung hash isi file PDF yang dihasilkan server
+func contentSHA256(content []byte) string {
+	h := sha256.Sum256(content)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,117 @@
+package handover
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyTokenRoundTrip(t *testing.T) {
+	claims := VerifyClaims{
+		BundleNo:   "HOPT/2026/03/000123",
+		FileID:     "0c6f4f0e-8c3b-4a57-9d1e-2f0a7d9b6c11",
+		LinesHash:  "abc",
+		BundleTime: 1772582400,
+		IssuedAt:   1772586000,
+	}
+
+	token, err := signVerifyToken("rahasia", claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(token, "+/=") {
+		t.Errorf("token %q bukan base64url tanpa padding", token)
+	}
+
+	got, err := parseVerifyToken("rahasia", token)
+	if err != nil {
+		t.Fatalf("parseVerifyToken: %v", err)
+	}
+	if !reflect.DeepEqual(*got, claims) {
+		t.Errorf("claims = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestVerifyTokenTampered(t *testing.T) {
+	enc := base64.RawURLEncoding
+	token, err := signVerifyToken("rahasia", VerifyClaims{BundleNo: "HO/1", FileID: "f1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, mac, _ := strings.Cut(token, ".")
+
+	// Payload diganti, MAC lama dipakai ulang
+	forged := enc.EncodeToString([]byte(`{"b":"HO/2","f":"f1"}`)) + "." + mac
+
+	// Payload tanpa nomor bundle / file ID tapi ditandatangani dengan benar
+	noBundle, _ := signVerifyToken("rahasia", VerifyClaims{FileID: "f1"})
+	noFile, _ := signVerifyToken("rahasia", VerifyClaims{BundleNo: "HO/1"})
+
+	// Satu karakter MAC diubah
+	flipped := []byte(mac)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+	}{
+		{"secret lain", "bukan-rahasia", token},
+		{"payload diganti", "rahasia", forged},
+		{"MAC diubah", "rahasia", payload + "." + string(flipped)},
+		{"tanpa MAC", "rahasia", payload},
+		{"MAC kosong", "rahasia", payload + "."},
+		{"bagian lebih", "rahasia", token + ".x"},
+		{"payload bukan base64", "rahasia", "!!!." + mac},
+		{"MAC bukan base64", "rahasia", payload + ".!!!"},
+		{"tanpa nomor bundle", "rahasia", noBundle},
+		{"tanpa file ID", "rahasia", noFile},
+		{"kosong", "rahasia", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseVerifyToken(tt.secret, tt.token); !errors.Is(err, ErrInvalidVerifyToken) {
+				t.Errorf("parseVerifyToken = %v, want ErrInvalidVerifyToken", err)
+			}
+		})
+	}
+}
+
+func TestLinesHash(t *testing.T) {
+	a := VerifyLine{DocumentNo: "SJ-001", MovementDate: "04-03-2026", Customer: "CUST A", CurrentStatus: StatusDelToDpk}
+	b := VerifyLine{DocumentNo: "SJ-002", MovementDate: "04-03-2026", Customer: "CUST B", CurrentStatus: StatusDpkFromDel}
+	c := VerifyLine{DocumentNo: "SJ-003", MovementDate: "05-03-2026", Customer: "CUST A"}
+
+	base := linesHash([]VerifyLine{a, b, c})
+
+	tests := []struct {
+		name  string
+		lines []VerifyLine
+		same  bool
+	}{
+		{"urutan lain", []VerifyLine{c, a, b}, true},
+		{"urutan terbalik", []VerifyLine{c, b, a}, true},
+		{"status berubah tidak berpengaruh", []VerifyLine{a, b, {DocumentNo: "SJ-003", MovementDate: "05-03-2026", Customer: "CUST A", CurrentStatus: StatusFatFromMkt}}, true},
+		{"SJ hilang", []VerifyLine{a, b}, false},
+		{"SJ tambahan", []VerifyLine{a, b, c, c}, false},
+		{"tanggal berubah", []VerifyLine{a, b, {DocumentNo: "SJ-003", MovementDate: "06-03-2026", Customer: "CUST A"}}, false},
+		{"customer berubah", []VerifyLine{a, b, {DocumentNo: "SJ-003", MovementDate: "05-03-2026", Customer: "CUST C"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := linesHash(tt.lines) == base; got != tt.same {
+			t.Errorf("%s: hash sama = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+
+	if linesHash(nil) != linesHash([]VerifyLine{}) {
+		t.Error("linesHash(nil) berbeda dengan slice kosong")
+	}
+}
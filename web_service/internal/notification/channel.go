@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"context"
+	"errors"
+)
+
+// Nama channel yang bisa dipakai di file rute
+const (
+	ChannelWAGateway = "wa_gateway" // HTTP WA gateway eksternal
+	ChannelWhatsApp  = "whatsapp"   // klien whatsmeow native
+	ChannelEmail     = "email"      // SMTP
+	ChannelWebhook   = "webhook"    // HTTP POST JSON
+)
+
+var channelNames = []string{ChannelWAGateway, ChannelWhatsApp, ChannelEmail, ChannelWebhook}
+
+// ErrChannelDisabled dikembalikan channel yang belum dikonfigurasi
+var ErrChannelDisabled = errors.New("channel notifikasi belum dikonfigurasi")
+
+// Channel mengirim satu pesan ke satu penerima (JID group/nomor WA, alamat email, URL webhook)
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to string, msg Message) error
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPOptions konfigurasi channel email
+type SMTPOptions struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type emailChannel struct {
+	opts SMTPOptions
+}
+
+// NewEmailChannel mengirim notifikasi sebagai email teks biasa lewat SMTP (STARTTLS jika server mendukung)
+func NewEmailChannel(opts SMTPOptions) Channel {
+	return &emailChannel{opts: opts}
+}
+
+func (c *emailChannel) Name() string { return ChannelEmail }
+
+func (c *emailChannel) Send(ctx context.Context, to string, msg Message) error {
+	if c.opts.Host == "" || c.opts.From == "" {
+		return ErrChannelDisabled
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("alamat email %q tidak valid", to)
+	}
+
+	var auth smtp.Auth
+	if c.opts.Username != "" {
+		auth = smtp.PlainAuth("", c.opts.Username, c.opts.Password, c.opts.Host)
+	}
+
+	// smtp.SendMail tidak menerima context, jadi dijalankan di goroutine
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(c.opts.Host, c.opts.Port), auth, c.opts.From, []string{to}, c.build(to, msg))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *emailChannel) build(to string, msg Message) []byte {
+	subject := msg.Subject
+	if subject == "" {
+		subject = "Notifikasi STS"
+	}
+
+	// Format WA (*tebal*, _miring_) dibiarkan apa adanya di email teks
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.opts.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,132 @@
+package notification
+
+import "time"
+
+// Jenis domain event yang bisa dirutekan ke channel notifikasi
+const (
+	EventDriverCheckin      = "DRIVER_CHECKIN"
+	EventDriverCheckout     = "DRIVER_CHECKOUT"
+	EventDriverCheckoutNoSJ = "DRIVER_CHECKOUT_NO_SJ"
+)
+
+// EventTypes dipakai untuk validasi rute
+var EventTypes = []string{EventDriverCheckin, EventDriverCheckout, EventDriverCheckoutNoSJ}
+
+// Event adalah domain event yang dikirim modul lain (mis. handover).
+// Modul pengirim tidak tahu channel mana yang dipakai.
+type Event struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	CustomerID int64     `json:"customer_id,omitempty"`
+	Customer   string    `json:"customer,omitempty"`
+	DriverID   int64     `json:"driver_id,omitempty"`
+	Driver     string    `json:"driver,omitempty"`
+	TNKBID     int64     `json:"tnkb_id,omitempty"`
+	TNKB       string    `json:"tnkb,omitempty"`
+	Time       string    `json:"time,omitempty"` // waktu kejadian untuk tampilan (format dari DB)
+	Notes      string    `json:"notes,omitempty"`
+	Details    []Detail  `json:"details,omitempty"`
+
+	// Event pengecualian (check-out tanpa SJ, check-in di luar geofence) untuk langganan exceptions_only
+	Exception       bool   `json:"exception"`
+	ExceptionReason string `json:"exception_reason,omitempty"`
+
+	// SourceKey menandai asal event (mis. "outbox:123"). Event dengan SourceKey yang sama hanya
+	// membuat satu notifikasi per channel + penerima walaupun Publish dipanggil ulang.
+	SourceKey string `json:"-"`
+}
+
+// Detail adalah satu Surat Jalan pada event, field sama dengan handover.HandoverNotifyDTO
+// supaya template bisa memakai nama yang sama ({{.DocumentNo}}, {{.CustomerName}}, ...).
+type Detail struct {
+	DocumentNo   string `json:"document_no"`
+	CustomerName string `json:"customer_name"`
+	DriverName   string `json:"driver_name"`
+	Time         string `json:"time"`
+	MovementDate string `json:"movement_date"`
+	TNKB         string `json:"tnkb"`
+	SppNo        string `json:"spp_no"`
+}
+
+// Bahasa template; DefaultLang dipakai jika rute tidak menyebut bahasa atau template bahasa lain belum ada
+const (
+	LangID      = "id"
+	LangEN      = "en"
+	DefaultLang = LangID
+)
+
+// Asal template pada MessageTemplate.Source
+const (
+	TemplateSourceDB      = "DB"
+	TemplateSourceDefault = "DEFAULT"
+)
+
+// MessageTemplate adalah template text/template per event & bahasa (ADW_STS_NOTIF_TEMPLATE)
+type MessageTemplate struct {
+	ID        int64      `db:"ADW_STS_NOTIF_TEMPLATE_ID" json:"id,omitempty"`
+	EventType string     `db:"EVENTTYPE" json:"event_type"`
+	Lang      string     `db:"LANG" json:"lang"`
+	Subject   string     `db:"SUBJECT" json:"subject"`
+	Body      string     `db:"BODY" json:"body"`
+	Updated   *time.Time `db:"UPDATED" json:"updated,omitempty"`
+	UpdatedBy *int64     `db:"UPDATEDBY" json:"updated_by,omitempty"`
+	Source    string     `db:"-" json:"source"`
+}
+
+// Message adalah isi notifikasi yang sudah dirender untuk satu event
+type Message struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Event   Event  `json:"event"`
+}
+
+// Status baris ADW_STS_NOTIF_DELIVERY
+const (
+	DeliveryPending   = "PENDING"   // menunggu dikirim / dicoba ulang
+	DeliverySending   = "SENDING"   // sedang diproses worker (dengan lease)
+	DeliveryDelivered = "DELIVERED" // terkirim
+	DeliveryDead      = "DEAD"      // gagal setelah batas percobaan (dead-letter)
+
+	// DeliveryFailed hanya filter list: PENDING yang pernah gagal + DEAD
+	DeliveryFailed = "FAILED"
+)
+
+// Delivery adalah satu notifikasi keluar untuk satu channel & penerima
+type Delivery struct {
+	ID          int64      `db:"ADW_STS_NOTIF_DELIVERY_ID" json:"id"`
+	EventType   string     `db:"EVENTTYPE" json:"event_type"`
+	Channel     string     `db:"CHANNEL" json:"channel"`
+	Recipient   string     `db:"RECIPIENT" json:"recipient"`
+	Lang        string     `db:"LANG" json:"lang"`
+	Payload     string     `db:"PAYLOAD" json:"-"` // Message dalam JSON
+	SubID       *int64     `db:"ADW_STS_NOTIF_SUBSCRIPTION_ID" json:"subscription_id"`
+	Status      string     `db:"STATUS" json:"status"`
+	Attempts    int        `db:"ATTEMPTS" json:"attempts"`
+	LastError   *string    `db:"LASTERROR" json:"last_error"`
+	NextAttempt *time.Time `db:"NEXTATTEMPT" json:"next_attempt"`
+	DeliveredAt *time.Time `db:"DELIVERED" json:"delivered_at"`
+	Created     time.Time  `db:"CREATED" json:"created"`
+	Message     *Message   `db:"-" json:"message,omitempty"`
+	DedupeKey   *string    `db:"-" json:"-"`
+}
+
+const (
+	defaultDeliveryPageSize = 20
+	maxDeliveryPageSize     = 100
+)
+
+// DeliveryFilter untuk GET /notifications/deliveries
+type DeliveryFilter struct {
+	Status    string
+	Channel   string
+	EventType string
+	Page      int
+	PageSize  int
+}
+
+type DeliveryPage struct {
+	Items    []Delivery `json:"items"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+	Total    int        `json:"total"`
+}
@@ -0,0 +1,389 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sts/web_service/internal/shared"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type APIResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// PreviewRef menunjuk data nyata untuk preview: bundle (DocumentNo) atau kunjungan driver (ADW_STS_EVENT_ID)
+type PreviewRef struct {
+	EventType string
+	BundleNo  string
+	EventID   int64
+}
+
+// PreviewSource menyusun Event dari data DB (diimplementasikan handover.Service)
+type PreviewSource interface {
+	PreviewEvent(ctx context.Context, ref PreviewRef) (*Event, error)
+}
+
+// ErrPreviewNotFound dikembalikan PreviewSource jika bundle / event tidak ada
+var ErrPreviewNotFound = errors.New("data untuk preview tidak ditemukan")
+
+type handler struct {
+	service Service
+	source  PreviewSource
+}
+
+func NewHandler(s Service, source PreviewSource) *handler {
+	return &handler{service: s, source: source}
+}
+
+func (h *handler) RegisterProtectedRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Use(shared.AdminOnly)
+		r.Get("/templates", h.ListTemplates)
+		r.Put("/templates/{eventType}/{lang}", h.SaveTemplate)
+		r.Post("/templates/preview", h.PreviewTemplate)
+
+		r.Get("/deliveries", h.ListDeliveries)
+		r.Post("/deliveries/{id}/resend", h.ResendDelivery)
+
+		r.Get("/subscriptions", h.ListSubscriptions)
+		r.Post("/subscriptions", h.CreateSubscription)
+		r.Put("/subscriptions/{id}", h.UpdateSubscription)
+		r.Delete("/subscriptions/{id}", h.DeleteSubscription)
+	})
+}
+
+func (h *handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListTemplates(r.Context())
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+type saveTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (h *handler) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	var req saveTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Payload tidak valid",
+		})
+		return
+	}
+
+	userID, _ := shared.AdminID(r)
+	saved, err := h.service.SaveTemplate(r.Context(), MessageTemplate{
+		EventType: chi.URLParam(r, "eventType"),
+		Lang:      strings.ToLower(chi.URLParam(r, "lang")),
+		Subject:   req.Subject,
+		Body:      req.Body,
+	}, userID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Template tersimpan",
+		Data:    saved,
+	})
+}
+
+type previewRequest struct {
+	EventType string `json:"event_type"`
+	Lang      string `json:"lang"`
+	BundleNo  string `json:"bundle_no,omitempty"` // preview terhadap SJ di bundle
+	EventID   int64  `json:"event_id,omitempty"`  // atau terhadap kunjungan driver (ADW_STS_EVENT_ID)
+
+	// Draft opsional; kosong = pakai template yang sedang aktif
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+func (h *handler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	var req previewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Payload tidak valid",
+		})
+		return
+	}
+
+	if !slices.Contains(EventTypes, req.EventType) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "event_type tidak dikenal",
+		})
+		return
+	}
+	if (req.BundleNo == "") == (req.EventID == 0) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "isi salah satu: bundle_no atau event_id",
+		})
+		return
+	}
+
+	event, err := h.source.PreviewEvent(r.Context(), PreviewRef{
+		EventType: req.EventType,
+		BundleNo:  req.BundleNo,
+		EventID:   req.EventID,
+	})
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	var draft *MessageTemplate
+	if req.Body != "" {
+		draft = &MessageTemplate{Subject: req.Subject, Body: req.Body}
+	}
+
+	msg, err := h.service.Preview(r.Context(), *event, strings.ToLower(req.Lang), draft)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Data:    msg,
+	})
+}
+
+// ListDeliveries: status = PENDING, SENDING, DELIVERED, DEAD atau FAILED (default FAILED)
+func (h *handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := strings.ToUpper(q.Get("status"))
+	if status == "" {
+		status = DeliveryFailed
+	}
+	if status != "ALL" && !slices.Contains([]string{DeliveryPending, DeliverySending, DeliveryDelivered, DeliveryDead, DeliveryFailed}, status) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "status tidak dikenal",
+		})
+		return
+	}
+	if status == "ALL" {
+		status = ""
+	}
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+	result, err := h.service.ListDeliveries(r.Context(), DeliveryFilter{
+		Status:    status,
+		Channel:   q.Get("channel"),
+		EventType: q.Get("eventType"),
+		Page:      page,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func (h *handler) ResendDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "id tidak valid",
+		})
+		return
+	}
+
+	userID, _ := shared.AdminID(r)
+	if err := h.service.ResendDelivery(r.Context(), id, userID); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Notifikasi dijadwalkan ulang",
+	})
+}
+
+func (h *handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+func (h *handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var sub Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Payload tidak valid",
+		})
+		return
+	}
+
+	userID, _ := shared.AdminID(r)
+	created, err := h.service.CreateSubscription(r.Context(), sub, userID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Langganan dibuat",
+		Data:    created,
+	})
+}
+
+func (h *handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "id tidak valid",
+		})
+		return
+	}
+
+	var sub Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "Payload tidak valid",
+		})
+		return
+	}
+	sub.ID = id
+
+	userID, _ := shared.AdminID(r)
+	updated, err := h.service.UpdateSubscription(r.Context(), sub, userID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Langganan diperbarui",
+		Data:    updated,
+	})
+}
+
+func (h *handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "id tidak valid",
+		})
+		return
+	}
+
+	userID, _ := shared.AdminID(r)
+	if err := h.service.DeleteSubscription(r.Context(), id, userID); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Langganan dihapus",
+	})
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, err error) {
+	var tErr *TemplateError
+	var sErr *SubscriptionError
+	switch {
+	case errors.As(err, &sErr):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: sErr.Error(),
+			Data:    sErr,
+		})
+	case errors.As(err, &tErr):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: tErr.Error(),
+			Data:    tErr,
+		})
+	case errors.Is(err, ErrDeliveryNotResendable):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, ErrPreviewNotFound), errors.Is(err, ErrTemplateNotFound), errors.Is(err, ErrDeliveryNotFound),
+		errors.Is(err, ErrSubscriptionNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	default:
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+}
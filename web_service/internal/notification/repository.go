@@ -0,0 +1,419 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sts/web_service/internal/shared/db"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTemplateNotFound      = errors.New("template tidak ditemukan")
+	ErrDeliveryNotFound      = errors.New("notifikasi tidak ditemukan")
+	ErrDeliveryNotResendable = errors.New("notifikasi sudah terkirim atau sedang diproses")
+	ErrSubscriptionNotFound  = errors.New("langganan tidak ditemukan")
+)
+
+type Repository interface {
+	GetTemplate(ctx context.Context, eventType, lang string) (*MessageTemplate, error)
+	ListTemplates(ctx context.Context) ([]MessageTemplate, error)
+	SaveTemplate(ctx context.Context, t MessageTemplate, userID int64) error
+
+	InsertDeliveries(ctx context.Context, deliveries []Delivery) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, errMsg string, retryIn time.Duration, dead bool) error
+	ListDeliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, int, error)
+	ResendDelivery(ctx context.Context, id int64, userID int64) error
+
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	GetSubscription(ctx context.Context, id int64) (*Subscription, error)
+	CreateSubscription(ctx context.Context, sub Subscription, userID int64) (int64, error)
+	UpdateSubscription(ctx context.Context, sub Subscription, userID int64) error
+	DeactivateSubscription(ctx context.Context, id int64, userID int64) error
+}
+
+type oraRepo struct {
+	db *sqlx.DB
+}
+
+func NewOraRepository(db *sqlx.DB) Repository {
+	return &oraRepo{db: db}
+}
+
+const templateColumns = `ADW_STS_NOTIF_TEMPLATE_ID, EVENTTYPE, LANG, NVL(SUBJECT, ' ') AS SUBJECT, BODY, UPDATED, UPDATEDBY`
+
+func (r *oraRepo) GetTemplate(ctx context.Context, eventType, lang string) (*MessageTemplate, error) {
+	var t MessageTemplate
+	query := `SELECT ` + templateColumns + `
+		FROM ADW_STS_NOTIF_TEMPLATE
+		WHERE EVENTTYPE = :1 AND LANG = :2 AND ISACTIVE = 'Y'`
+
+	err := r.db.GetContext(ctx, &t, query, eventType, lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil template %s/%s: %w", eventType, lang, err)
+	}
+	t.Source = TemplateSourceDB
+	return &t, nil
+}
+
+func (r *oraRepo) ListTemplates(ctx context.Context) ([]MessageTemplate, error) {
+	list := []MessageTemplate{}
+	query := `SELECT ` + templateColumns + `
+		FROM ADW_STS_NOTIF_TEMPLATE
+		WHERE ISACTIVE = 'Y'
+		ORDER BY EVENTTYPE, LANG`
+
+	if err := r.db.SelectContext(ctx, &list, query); err != nil {
+		return nil, fmt.Errorf("gagal ambil daftar template: %w", err)
+	}
+	for i := range list {
+		list[i].Source = TemplateSourceDB
+	}
+	return list, nil
+}
+
+// SaveTemplate insert atau update template untuk pasangan event & bahasa
+func (r *oraRepo) SaveTemplate(ctx context.Context, t MessageTemplate, userID int64) error {
+	query := `
+		MERGE INTO ADW_STS_NOTIF_TEMPLATE t
+		USING (SELECT :1 AS EVENTTYPE, :2 AS LANG FROM DUAL) s
+			ON (t.EVENTTYPE = s.EVENTTYPE AND t.LANG = s.LANG)
+		WHEN MATCHED THEN UPDATE SET
+			t.SUBJECT = :3, t.BODY = :4, t.ISACTIVE = 'Y', t.UPDATED = SYSDATE, t.UPDATEDBY = :5
+		WHEN NOT MATCHED THEN INSERT
+			(ADW_STS_NOTIF_TEMPLATE_ID, EVENTTYPE, LANG, SUBJECT, BODY, ISACTIVE, CREATED, CREATEDBY, UPDATED, UPDATEDBY)
+			VALUES (ADW_STS_NOTIF_TEMPLATE_SQ.NEXTVAL, s.EVENTTYPE, s.LANG, :6, :7, 'Y', SYSDATE, :8, SYSDATE, :9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		t.EventType, t.Lang,
+		t.Subject, t.Body, userID,
+		t.Subject, t.Body, userID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("gagal simpan template %s/%s: %w", t.EventType, t.Lang, err)
+	}
+	return nil
+}
+
+// InsertDeliveries menyimpan semua notifikasi satu event dalam satu transaksi
+func (r *oraRepo) InsertDeliveries(ctx context.Context, deliveries []Delivery) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO ADW_STS_NOTIF_DELIVERY (
+			ADW_STS_NOTIF_DELIVERY_ID, EVENTTYPE, CHANNEL, RECIPIENT, LANG, PAYLOAD,
+			ADW_STS_NOTIF_SUBSCRIPTION_ID, STATUS, ATTEMPTS, NEXTATTEMPT, DEDUPEKEY, CREATED, UPDATED
+		) VALUES (ADW_STS_NOTIF_DELIVERY_SQ.NEXTVAL, :1, :2, :3, :4, :5, :6, :7, 0, NVL(:8, SYSDATE), :9, SYSDATE, SYSDATE)`
+
+	for _, d := range deliveries {
+		// NextAttempt diisi jika penerima sedang jam tenang
+		var next interface{}
+		if d.NextAttempt != nil {
+			next = *d.NextAttempt
+		}
+		_, err := tx.ExecContext(ctx, query, d.EventType, d.Channel, d.Recipient, d.Lang, d.Payload, d.SubID, DeliveryPending, next, d.DedupeKey)
+		if db.IsUniqueViolation(err) {
+			// Event yang sama sudah pernah diantrikan ke penerima ini (outbox diproses ulang)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("gagal simpan notifikasi %s -> %s: %w", d.Channel, d.Recipient, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const deliveryColumns = `ADW_STS_NOTIF_DELIVERY_ID, EVENTTYPE, CHANNEL, RECIPIENT, LANG, PAYLOAD,
+	ADW_STS_NOTIF_SUBSCRIPTION_ID, STATUS, ATTEMPTS, LASTERROR, NEXTATTEMPT, DELIVERED, CREATED`
+
+// ClaimDeliveries mengunci notifikasi yang siap dikirim (SKIP LOCKED) dan menandainya SENDING sampai lease habis
+func (r *oraRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM ADW_STS_NOTIF_DELIVERY
+		WHERE (STATUS = :1 AND NEXTATTEMPT <= SYSDATE)
+		   OR (STATUS = :2 AND LOCKEDUNTIL < SYSDATE)
+		ORDER BY ADW_STS_NOTIF_DELIVERY_ID
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryxContext(ctx, query, DeliveryPending, DeliverySending)
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil notifikasi: %w", err)
+	}
+
+	var list []Delivery
+	for len(list) < limit && rows.Next() {
+		var d Delivery
+		if err := rows.StructScan(&d); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("gagal scan notifikasi: %w", err)
+		}
+		list = append(list, d)
+	}
+	rows.Close()
+
+	queryClaim := `
+		UPDATE ADW_STS_NOTIF_DELIVERY
+		SET STATUS = :1, ATTEMPTS = ATTEMPTS + 1,
+			LOCKEDUNTIL = SYSDATE + :2 / 86400, UPDATED = SYSDATE
+		WHERE ADW_STS_NOTIF_DELIVERY_ID = :3`
+
+	for i := range list {
+		if _, err := tx.ExecContext(ctx, queryClaim, DeliverySending, int64(lease.Seconds()), list[i].ID); err != nil {
+			return nil, fmt.Errorf("gagal claim notifikasi %d: %w", list[i].ID, err)
+		}
+		list[i].Attempts++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *oraRepo) MarkDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE ADW_STS_NOTIF_DELIVERY
+		SET STATUS = :1, LASTERROR = NULL, LOCKEDUNTIL = NULL,
+			DELIVERED = SYSDATE, UPDATED = SYSDATE
+		WHERE ADW_STS_NOTIF_DELIVERY_ID = :2`
+
+	if _, err := r.db.ExecContext(ctx, query, DeliveryDelivered, id); err != nil {
+		return fmt.Errorf("gagal update notifikasi %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *oraRepo) MarkFailed(ctx context.Context, id int64, errMsg string, retryIn time.Duration, dead bool) error {
+	status := DeliveryPending
+	if dead {
+		status = DeliveryDead
+	}
+
+	query := `
+		UPDATE ADW_STS_NOTIF_DELIVERY
+		SET STATUS = :1, LASTERROR = :2, NEXTATTEMPT = SYSDATE + :3 / 86400,
+			LOCKEDUNTIL = NULL, UPDATED = SYSDATE
+		WHERE ADW_STS_NOTIF_DELIVERY_ID = :4`
+
+	if len(errMsg) > 2000 {
+		errMsg = errMsg[:2000]
+	}
+	if _, err := r.db.ExecContext(ctx, query, status, errMsg, int64(retryIn.Seconds()), id); err != nil {
+		return fmt.Errorf("gagal update notifikasi %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *oraRepo) ListDeliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, int, error) {
+	var where []string
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	switch f.Status {
+	case "":
+	case DeliveryFailed:
+		where = append(where, "(STATUS = "+bind(DeliveryDead)+" OR (STATUS = "+bind(DeliveryPending)+" AND LASTERROR IS NOT NULL))")
+	default:
+		where = append(where, "STATUS = "+bind(f.Status))
+	}
+	if f.Channel != "" {
+		where = append(where, "CHANNEL = "+bind(f.Channel))
+	}
+	if f.EventType != "" {
+		where = append(where, "EVENTTYPE = "+bind(f.EventType))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	offset := (f.Page - 1) * f.PageSize
+	query := `
+		SELECT ` + deliveryColumns + `, TOTAL_COUNT
+		FROM (
+			SELECT d.*, ROW_NUMBER() OVER (ORDER BY ADW_STS_NOTIF_DELIVERY_ID DESC) AS RN,
+				COUNT(*) OVER () AS TOTAL_COUNT
+			FROM ADW_STS_NOTIF_DELIVERY d
+			` + whereSQL + `
+		)
+		WHERE RN > ` + bind(offset) + ` AND RN <= ` + bind(offset+f.PageSize) + `
+		ORDER BY RN`
+
+	var rows []struct {
+		Delivery
+		Total int `db:"TOTAL_COUNT"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("gagal ambil daftar notifikasi: %w", err)
+	}
+
+	list := make([]Delivery, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		list = append(list, row.Delivery)
+		total = row.Total
+	}
+	return list, total, nil
+}
+
+// ResendDelivery menjadwalkan ulang notifikasi PENDING/DEAD untuk dikirim segera dengan hitungan percobaan baru
+func (r *oraRepo) ResendDelivery(ctx context.Context, id int64, userID int64) error {
+	query := `
+		UPDATE ADW_STS_NOTIF_DELIVERY
+		SET STATUS = :1, ATTEMPTS = 0, NEXTATTEMPT = SYSDATE, LOCKEDUNTIL = NULL,
+			UPDATED = SYSDATE, UPDATEDBY = :2
+		WHERE ADW_STS_NOTIF_DELIVERY_ID = :3
+			AND STATUS IN (:4, :5)`
+
+	res, err := r.db.ExecContext(ctx, query, DeliveryPending, userID, id, DeliveryPending, DeliveryDead)
+	if err != nil {
+		return fmt.Errorf("gagal resend notifikasi %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM ADW_STS_NOTIF_DELIVERY WHERE ADW_STS_NOTIF_DELIVERY_ID = :1`, id); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeliveryNotFound
+	}
+	return ErrDeliveryNotResendable
+}
+
+const subscriptionColumns = `ADW_STS_NOTIF_SUBSCRIPTION_ID, NAME, CHANNEL, RECIPIENT, LANG, EVENTTYPES,
+	C_BPARTNER_ID, DRIVER_ID, TNKB_ID, EXCEPTIONSONLY, QUIETSTART, QUIETEND`
+
+// ListSubscriptions mengambil semua langganan aktif
+func (r *oraRepo) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	list := []Subscription{}
+	query := `SELECT ` + subscriptionColumns + `
+		FROM ADW_STS_NOTIF_SUBSCRIPTION
+		WHERE ISACTIVE = 'Y'
+		ORDER BY NAME, ADW_STS_NOTIF_SUBSCRIPTION_ID`
+
+	if err := r.db.SelectContext(ctx, &list, query); err != nil {
+		return nil, fmt.Errorf("gagal ambil langganan notifikasi: %w", err)
+	}
+	for i := range list {
+		list[i].fromDB()
+	}
+	return list, nil
+}
+
+func (r *oraRepo) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	var sub Subscription
+	query := `SELECT ` + subscriptionColumns + `
+		FROM ADW_STS_NOTIF_SUBSCRIPTION
+		WHERE ADW_STS_NOTIF_SUBSCRIPTION_ID = :1 AND ISACTIVE = 'Y'`
+
+	err := r.db.GetContext(ctx, &sub, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal ambil langganan %d: %w", id, err)
+	}
+	sub.fromDB()
+	return &sub, nil
+}
+
+func (r *oraRepo) CreateSubscription(ctx context.Context, sub Subscription, userID int64) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.GetContext(ctx, &id, "SELECT ADW_STS_NOTIF_SUBSCRIPTION_SQ.NEXTVAL FROM DUAL"); err != nil {
+		return 0, fmt.Errorf("gagal ambil sequence: %w", err)
+	}
+
+	sub.toDB()
+	query := `
+		INSERT INTO ADW_STS_NOTIF_SUBSCRIPTION (
+			ADW_STS_NOTIF_SUBSCRIPTION_ID, NAME, CHANNEL, RECIPIENT, LANG, EVENTTYPES,
+			C_BPARTNER_ID, DRIVER_ID, TNKB_ID, EXCEPTIONSONLY, QUIETSTART, QUIETEND,
+			ISACTIVE, CREATED, CREATEDBY, UPDATED, UPDATEDBY
+		) VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11, :12, 'Y', SYSDATE, :13, SYSDATE, :14)`
+
+	_, err = tx.ExecContext(ctx, query,
+		id, sub.Name, sub.Channel, sub.Recipient, sub.Lang, sub.EventTypesRaw,
+		sub.BPartnerID, sub.DriverID, sub.TNKBID, sub.ExceptionsOnlyRaw, sub.QuietStart, sub.QuietEnd,
+		userID, userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("gagal simpan langganan: %w", err)
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *oraRepo) UpdateSubscription(ctx context.Context, sub Subscription, userID int64) error {
+	sub.toDB()
+	query := `
+		UPDATE ADW_STS_NOTIF_SUBSCRIPTION
+		SET NAME = :1, CHANNEL = :2, RECIPIENT = :3, LANG = :4, EVENTTYPES = :5,
+			C_BPARTNER_ID = :6, DRIVER_ID = :7, TNKB_ID = :8, EXCEPTIONSONLY = :9,
+			QUIETSTART = :10, QUIETEND = :11, UPDATED = SYSDATE, UPDATEDBY = :12
+		WHERE ADW_STS_NOTIF_SUBSCRIPTION_ID = :13 AND ISACTIVE = 'Y'`
+
+	res, err := r.db.ExecContext(ctx, query,
+		sub.Name, sub.Channel, sub.Recipient, sub.Lang, sub.EventTypesRaw,
+		sub.BPartnerID, sub.DriverID, sub.TNKBID, sub.ExceptionsOnlyRaw,
+		sub.QuietStart, sub.QuietEnd, userID, sub.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("gagal update langganan %d: %w", sub.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// DeactivateSubscription menonaktifkan langganan (riwayat delivery tetap merujuk ID-nya)
+func (r *oraRepo) DeactivateSubscription(ctx context.Context, id int64, userID int64) error {
+	query := `
+		UPDATE ADW_STS_NOTIF_SUBSCRIPTION
+		SET ISACTIVE = 'N', UPDATED = SYSDATE, UPDATEDBY = :1
+		WHERE ADW_STS_NOTIF_SUBSCRIPTION_ID = :2 AND ISACTIVE = 'Y'`
+
+	res, err := r.db.ExecContext(ctx, query, userID, id)
+	if err != nil {
+		return fmt.Errorf("gagal hapus langganan %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
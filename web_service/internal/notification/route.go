@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Route mengirim event tertentu ke penerima pada satu channel. Events "*" berarti semua event.
+type Route struct {
+	Events  []string `json:"events"`
+	Channel string   `json:"channel"`
+	To      []string `json:"to"`
+	Lang    string   `json:"lang,omitempty"` // bahasa template, default DefaultLang
+}
+
+type routeFile struct {
+	Routes []Route `json:"routes"`
+}
+
+// DefaultRoutes meniru perilaku lama: semua event ke group WA lewat gateway HTTP
+func DefaultRoutes(waGroupID string) []Route {
+	if waGroupID == "" {
+		return nil
+	}
+	return []Route{{
+		Events:  []string{"*"},
+		Channel: ChannelWAGateway,
+		To:      []string{waGroupID},
+	}}
+}
+
+// LoadRoutes membaca aturan routing dari file JSON. File tidak ada -> DefaultRoutes.
+func LoadRoutes(path, waGroupID string) ([]Route, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultRoutes(waGroupID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal baca rute notifikasi %s: %w", path, err)
+	}
+
+	var f routeFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("rute notifikasi %s tidak valid: %w", path, err)
+	}
+	if err := validateRoutes(f.Routes); err != nil {
+		return nil, fmt.Errorf("rute notifikasi %s: %w", path, err)
+	}
+	return f.Routes, nil
+}
+
+// FilterRoutes membuang rute ke channel yang tidak aktif, supaya tidak ada antrian kirim yang pasti gagal
+func FilterRoutes(routes []Route, enabled ...string) []Route {
+	var result []Route
+	for _, r := range routes {
+		if slices.Contains(enabled, r.Channel) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func validateRoutes(routes []Route) error {
+	for i, r := range routes {
+		if !slices.Contains(channelNames, r.Channel) {
+			return fmt.Errorf("rute #%d: channel %q tidak dikenal", i+1, r.Channel)
+		}
+		if len(r.Events) == 0 {
+			return fmt.Errorf("rute #%d: events kosong", i+1)
+		}
+		for _, e := range r.Events {
+			if e != "*" && !slices.Contains(EventTypes, e) {
+				return fmt.Errorf("rute #%d: event %q tidak dikenal", i+1, e)
+			}
+		}
+		if len(r.To) == 0 {
+			return fmt.Errorf("rute #%d: penerima (to) kosong", i+1)
+		}
+		if r.Lang != "" && !validLang(r.Lang) {
+			return fmt.Errorf("rute #%d: bahasa %q tidak valid", i+1, r.Lang)
+		}
+	}
+	return nil
+}
+
+func (r Route) lang() string {
+	if r.Lang == "" {
+		return DefaultLang
+	}
+	return r.Lang
+}
+
+func (r Route) matches(eventType string) bool {
+	return slices.Contains(r.Events, "*") || slices.Contains(r.Events, eventType)
+}
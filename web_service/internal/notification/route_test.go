@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRoutesDefault(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "routes.json")
+
+	// Tanpa file rute & WA_GROUP_ID tidak ada notifikasi yang dikirim
+	routes, err := LoadRoutes(missing, "")
+	if err != nil || len(routes) != 0 {
+		t.Errorf("LoadRoutes tanpa group = %+v, %v, want kosong", routes, err)
+	}
+
+	routes, err = LoadRoutes(missing, "123@g.us")
+	if err != nil || len(routes) != 1 || routes[0].Channel != ChannelWAGateway || routes[0].To[0] != "123@g.us" {
+		t.Errorf("LoadRoutes dengan group = %+v, %v", routes, err)
+	}
+}
+
+func TestLoadRoutesExample(t *testing.T) {
+	routes, err := LoadRoutes("../../templates/notification_routes.example.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) == 0 {
+		t.Error("contoh rute kosong")
+	}
+}
+
+func TestFilterRoutes(t *testing.T) {
+	routes := []Route{
+		{Events: []string{"*"}, Channel: ChannelWAGateway, To: []string{"a@g.us"}},
+		{Events: []string{"*"}, Channel: ChannelEmail, To: []string{"ops@example.com"}},
+	}
+
+	tests := []struct {
+		name    string
+		enabled []string
+		want    []string
+	}{
+		{"semua aktif", []string{ChannelEmail, ChannelWAGateway}, []string{ChannelWAGateway, ChannelEmail}},
+		{"gateway mati", []string{ChannelEmail, ChannelWebhook}, []string{ChannelEmail}},
+		{"tidak ada channel", nil, nil},
+	}
+	for _, tt := range tests {
+		got := FilterRoutes(routes, tt.enabled...)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: FilterRoutes = %+v, want channel %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Channel != tt.want[i] {
+				t.Errorf("%s: rute %d channel = %q, want %q", tt.name, i, got[i].Channel, tt.want[i])
+			}
+		}
+	}
+}
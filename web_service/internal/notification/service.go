@@ -0,0 +1,313 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Publisher diimplementasikan Service; modul lain cukup bergantung pada interface ini
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+type Service interface {
+	Publisher
+
+	ListTemplates(ctx context.Context) ([]MessageTemplate, error)
+	SaveTemplate(ctx context.Context, t MessageTemplate, userID int64) (*MessageTemplate, error)
+	Preview(ctx context.Context, e Event, lang string, draft *MessageTemplate) (*Message, error)
+
+	ListDeliveries(ctx context.Context, f DeliveryFilter) (*DeliveryPage, error)
+	ResendDelivery(ctx context.Context, id int64, userID int64) error
+
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	CreateSubscription(ctx context.Context, sub Subscription, userID int64) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, sub Subscription, userID int64) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64, userID int64) error
+}
+
+type service struct {
+	repo     Repository
+	routes   []Route
+	channels []string // channel yang aktif di DeliveryWorker
+}
+
+// NewService: channel dipakai DeliveryWorker, service hanya merender & menyimpan antrian kirim.
+// channels adalah nama channel yang aktif; langganan ke channel lain tidak diantrikan.
+func NewService(repo Repository, routes []Route, channels []string) Service {
+	return &service{repo: repo, routes: routes, channels: channels}
+}
+
+// recipient adalah satu tujuan hasil resolusi rute statis & langganan
+type recipient struct {
+	channel string
+	to      string
+	lang    string
+	subID   *int64
+	until   *time.Time // jam tenang: kirim setelah waktu ini
+}
+
+// resolve menggabungkan penerima dari file rute dan langganan yang cocok.
+// Channel + penerima yang sama hanya dikirimi sekali (rute statis didahulukan).
+func (s *service) resolve(ctx context.Context, e Event) ([]recipient, error) {
+	var list []recipient
+	seen := make(map[string]bool)
+	add := func(rc recipient) {
+		key := rc.channel + "|" + rc.to
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		list = append(list, rc)
+	}
+
+	for _, r := range s.routes {
+		if !r.matches(e.Type) {
+			continue
+		}
+		for _, to := range r.To {
+			add(recipient{channel: r.Channel, to: to, lang: r.lang()})
+		}
+	}
+
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		// Sama seperti FilterRoutes: channel yang tidak aktif pasti gagal sampai dead-letter
+		if !sub.matches(e) || !slices.Contains(s.channels, sub.Channel) {
+			continue
+		}
+		rc := recipient{channel: sub.Channel, to: sub.Recipient, lang: sub.Lang, subID: &sub.ID}
+		if until, quiet := sub.quietUntil(e.OccurredAt); quiet {
+			rc.until = &until
+		}
+		add(rc)
+	}
+
+	return list, nil
+}
+
+// Publish merender event dan menyimpan satu baris ADW_STS_NOTIF_DELIVERY per channel & penerima.
+// Pengiriman, retry dan dead-letter dikerjakan DeliveryWorker.
+func (s *service) Publish(ctx context.Context, e Event) error {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	recipients, err := s.resolve(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	// Pesan dirender sekali per bahasa
+	payloads := make(map[string]string)
+
+	deliveries := make([]Delivery, 0, len(recipients))
+	for _, rc := range recipients {
+		payload, ok := payloads[rc.lang]
+		if !ok {
+			msg, err := s.render(ctx, e, rc.lang)
+			if err != nil {
+				return err
+			}
+			raw, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			payload = string(raw)
+			payloads[rc.lang] = payload
+		}
+
+		deliveries = append(deliveries, Delivery{
+			EventType:   e.Type,
+			Channel:     rc.channel,
+			Recipient:   rc.to,
+			Lang:        rc.lang,
+			Payload:     payload,
+			SubID:       rc.subID,
+			NextAttempt: rc.until,
+			DedupeKey:   dedupeKey(e.SourceKey, rc.channel, rc.to),
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return s.repo.InsertDeliveries(ctx, deliveries)
+}
+
+// dedupeKey: hash sumber event + channel + penerima; nil jika event tidak punya SourceKey
+func dedupeKey(source, channel, to string) *string {
+	if source == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(source + "|" + channel + "|" + to))
+	key := hex.EncodeToString(sum[:])
+	return &key
+}
+
+func (s *service) render(ctx context.Context, e Event, lang string) (Message, error) {
+	t, err := s.template(ctx, e.Type, lang)
+	if err != nil {
+		return Message{}, err
+	}
+	return renderTemplate(*t, e)
+}
+
+// template mencari template: DB (bahasa diminta) -> DB (DefaultLang) -> bawaan.
+// Jika DB error, template bawaan tetap dipakai supaya notifikasi tidak berhenti.
+func (s *service) template(ctx context.Context, eventType, lang string) (*MessageTemplate, error) {
+	langs := []string{lang}
+	if lang != DefaultLang {
+		langs = append(langs, DefaultLang)
+	}
+
+	for _, l := range langs {
+		t, err := s.repo.GetTemplate(ctx, eventType, l)
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, ErrTemplateNotFound) {
+			fmt.Printf("[NOTIF-WARN]: %v, pakai template bawaan\n", err)
+			break
+		}
+	}
+
+	if t, ok := defaultTemplate(eventType, lang); ok {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("event %q tidak punya template pesan", eventType)
+}
+
+// ListTemplates mengembalikan template DB ditambah template bawaan yang belum di-override
+func (s *service) ListTemplates(ctx context.Context) ([]MessageTemplate, error) {
+	list, err := s.repo.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]bool, len(list))
+	for _, t := range list {
+		stored[t.EventType+"/"+t.Lang] = true
+	}
+	for _, lang := range []string{LangID, LangEN} {
+		for _, eventType := range EventTypes {
+			if stored[eventType+"/"+lang] {
+				continue
+			}
+			if t, ok := defaultTemplate(eventType, lang); ok && t.Lang == lang {
+				list = append(list, t)
+			}
+		}
+	}
+	return list, nil
+}
+
+func (s *service) SaveTemplate(ctx context.Context, t MessageTemplate, userID int64) (*MessageTemplate, error) {
+	if err := validateTemplate(t); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveTemplate(ctx, t, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTemplate(ctx, t.EventType, t.Lang)
+}
+
+// Preview merender template (draft jika diisi, selain itu template aktif) terhadap data event nyata
+func (s *service) Preview(ctx context.Context, e Event, lang string, draft *MessageTemplate) (*Message, error) {
+	if lang == "" {
+		lang = DefaultLang
+	}
+
+	var t *MessageTemplate
+	if draft != nil {
+		draft.EventType, draft.Lang = e.Type, lang
+		if err := validateTemplate(*draft); err != nil {
+			return nil, err
+		}
+		t = draft
+	} else {
+		var err error
+		if t, err = s.template(ctx, e.Type, lang); err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := renderTemplate(*t, e)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (s *service) ListDeliveries(ctx context.Context, f DeliveryFilter) (*DeliveryPage, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = defaultDeliveryPageSize
+	}
+	if f.PageSize > maxDeliveryPageSize {
+		f.PageSize = maxDeliveryPageSize
+	}
+
+	list, total, err := s.repo.ListDeliveries(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tampilkan isi pesan supaya admin bisa melihat apa yang gagal dikirim
+	for i := range list {
+		var msg Message
+		if json.Unmarshal([]byte(list[i].Payload), &msg) == nil {
+			list[i].Message = &msg
+		}
+	}
+
+	return &DeliveryPage{Items: list, Page: f.Page, PageSize: f.PageSize, Total: total}, nil
+}
+
+func (s *service) ResendDelivery(ctx context.Context, id int64, userID int64) error {
+	return s.repo.ResendDelivery(ctx, id, userID)
+}
+
+func (s *service) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *service) CreateSubscription(ctx context.Context, sub Subscription, userID int64) (*Subscription, error) {
+	sub.normalize()
+	if err := sub.validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateSubscription(ctx, sub, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSubscription(ctx, id)
+}
+
+func (s *service) UpdateSubscription(ctx context.Context, sub Subscription, userID int64) (*Subscription, error) {
+	sub.normalize()
+	if err := sub.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSubscription(ctx, sub.ID)
+}
+
+func (s *service) DeleteSubscription(ctx context.Context, id int64, userID int64) error {
+	return s.repo.DeactivateSubscription(ctx, id, userID)
+}
@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type publishRepo struct {
+	Repository
+	subs       []Subscription
+	deliveries []Delivery
+}
+
+func (r *publishRepo) GetTemplate(ctx context.Context, eventType, lang string) (*MessageTemplate, error) {
+	return nil, ErrTemplateNotFound // pakai template bawaan
+}
+
+func (r *publishRepo) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	return r.subs, nil
+}
+
+func (r *publishRepo) InsertDeliveries(ctx context.Context, deliveries []Delivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	repo := &publishRepo{subs: []Subscription{
+		{ID: 1, Channel: ChannelEmail, Recipient: "ops@example.com", Lang: LangID},
+		{ID: 2, Channel: ChannelWAGateway, Recipient: "grup@g.us", Lang: LangID}, // channel tidak aktif
+		{ID: 3, Channel: ChannelWebhook, Recipient: "https://example.com/hook", Lang: LangEN, DriverID: ptr(int64(999))},
+	}}
+	routes := []Route{{Events: []string{"*"}, Channel: ChannelWhatsApp, To: []string{"6281@s.whatsapp.net"}}}
+	s := NewService(repo, routes, []string{ChannelWhatsApp, ChannelEmail, ChannelWebhook})
+
+	event := Event{Type: EventDriverCheckin, OccurredAt: time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC), DriverID: 7, SourceKey: "outbox:42"}
+	if err := s.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]Delivery{}
+	for _, d := range repo.deliveries {
+		got[d.Channel+"|"+d.Recipient] = d
+	}
+	if len(repo.deliveries) != 2 || got[ChannelWhatsApp+"|6281@s.whatsapp.net"].Channel == "" || got[ChannelEmail+"|ops@example.com"].Channel == "" {
+		t.Fatalf("deliveries = %+v, want whatsapp rute + email langganan", repo.deliveries)
+	}
+
+	// Dedupe key berbeda per penerima, sama jika event yang sama diterbitkan ulang
+	first := repo.deliveries
+	repo.deliveries = nil
+	if err := s.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	for i := range first {
+		if first[i].DedupeKey == nil || repo.deliveries[i].DedupeKey == nil || *first[i].DedupeKey != *repo.deliveries[i].DedupeKey {
+			t.Errorf("dedupe key %d tidak stabil: %v / %v", i, first[i].DedupeKey, repo.deliveries[i].DedupeKey)
+		}
+	}
+	if *first[0].DedupeKey == *first[1].DedupeKey {
+		t.Error("dedupe key sama untuk penerima berbeda")
+	}
+
+	// Event tanpa SourceKey (mis. dari luar outbox) tidak di-dedupe
+	repo.deliveries = nil
+	event.SourceKey = ""
+	if err := s.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range repo.deliveries {
+		if d.DedupeKey != nil {
+			t.Errorf("dedupe key tanpa SourceKey = %q", *d.DedupeKey)
+		}
+	}
+}
+
+func TestDedupeKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     [3]string
+		wantSame bool
+	}{
+		{"identik", [3]string{"outbox:1", ChannelEmail, "a@x"}, [3]string{"outbox:1", ChannelEmail, "a@x"}, true},
+		{"outbox lain", [3]string{"outbox:1", ChannelEmail, "a@x"}, [3]string{"outbox:2", ChannelEmail, "a@x"}, false},
+		{"channel lain", [3]string{"outbox:1", ChannelEmail, "a@x"}, [3]string{"outbox:1", ChannelWebhook, "a@x"}, false},
+		{"penerima lain", [3]string{"outbox:1", ChannelEmail, "a@x"}, [3]string{"outbox:1", ChannelEmail, "b@x"}, false},
+	}
+	for _, tt := range tests {
+		a, b := dedupeKey(tt.a[0], tt.a[1], tt.a[2]), dedupeKey(tt.b[0], tt.b[1], tt.b[2])
+		if len(*a) != 64 {
+			t.Errorf("%s: panjang key %d, kolom DEDUPEKEY 64", tt.name, len(*a))
+		}
+		if (*a == *b) != tt.wantSame {
+			t.Errorf("%s: sama = %v, want %v", tt.name, *a == *b, tt.wantSame)
+		}
+	}
+}
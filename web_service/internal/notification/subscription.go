@@ -0,0 +1,167 @@
+package notification
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const quietLayout = "15:04"
+
+// Subscription adalah langganan notifikasi satu penerima (ADW_STS_NOTIF_SUBSCRIPTION).
+// Filter yang kosong berarti semua; semua filter yang diisi harus cocok.
+type Subscription struct {
+	ID             int64    `db:"ADW_STS_NOTIF_SUBSCRIPTION_ID" json:"id"`
+	Name           string   `db:"NAME" json:"name"`
+	Channel        string   `db:"CHANNEL" json:"channel"`
+	Recipient      string   `db:"RECIPIENT" json:"recipient"` // JID group/nomor, email atau URL webhook
+	Lang           string   `db:"LANG" json:"lang"`
+	EventTypes     []string `db:"-" json:"event_types"`
+	BPartnerID     *int64   `db:"C_BPARTNER_ID" json:"customer_id"`
+	DriverID       *int64   `db:"DRIVER_ID" json:"driver_id"`
+	TNKBID         *int64   `db:"TNKB_ID" json:"tnkb_id"`
+	ExceptionsOnly bool     `db:"-" json:"exceptions_only"`      // hanya event pengecualian (mis. di luar geofence)
+	QuietStart     *string  `db:"QUIETSTART" json:"quiet_start"` // HH:MM, notifikasi ditunda sampai QuietEnd
+	QuietEnd       *string  `db:"QUIETEND" json:"quiet_end"`
+
+	// Kolom mentah DB
+	EventTypesRaw     *string `db:"EVENTTYPES" json:"-"` // dipisah koma, NULL = semua event
+	ExceptionsOnlyRaw string  `db:"EXCEPTIONSONLY" json:"-"`
+}
+
+// SubscriptionError: data langganan tidak valid. Handler memetakan ke HTTP 400.
+type SubscriptionError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("langganan tidak valid (%s): %s", e.Field, e.Reason)
+}
+
+func (s *Subscription) normalize() {
+	s.Recipient = strings.TrimSpace(s.Recipient)
+	s.Lang = strings.ToLower(strings.TrimSpace(s.Lang))
+	if s.Lang == "" {
+		s.Lang = DefaultLang
+	}
+	for i := range s.EventTypes {
+		s.EventTypes[i] = strings.ToUpper(strings.TrimSpace(s.EventTypes[i]))
+	}
+	if s.QuietStart != nil && *s.QuietStart == "" {
+		s.QuietStart = nil
+	}
+	if s.QuietEnd != nil && *s.QuietEnd == "" {
+		s.QuietEnd = nil
+	}
+}
+
+func (s Subscription) validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return &SubscriptionError{Field: "name", Reason: "wajib diisi"}
+	}
+	if !slices.Contains(channelNames, s.Channel) {
+		return &SubscriptionError{Field: "channel", Reason: fmt.Sprintf("channel %q tidak dikenal", s.Channel)}
+	}
+	if s.Recipient == "" {
+		return &SubscriptionError{Field: "recipient", Reason: "wajib diisi"}
+	}
+	if !validLang(s.Lang) {
+		return &SubscriptionError{Field: "lang", Reason: "harus kode bahasa 2 huruf"}
+	}
+	for _, e := range s.EventTypes {
+		if !slices.Contains(EventTypes, e) {
+			return &SubscriptionError{Field: "event_types", Reason: fmt.Sprintf("event %q tidak dikenal", e)}
+		}
+	}
+	if (s.QuietStart == nil) != (s.QuietEnd == nil) {
+		return &SubscriptionError{Field: "quiet_start", Reason: "quiet_start dan quiet_end harus diisi bersamaan"}
+	}
+	if s.QuietStart != nil {
+		for _, v := range []string{*s.QuietStart, *s.QuietEnd} {
+			if _, err := time.Parse(quietLayout, v); err != nil {
+				return &SubscriptionError{Field: "quiet_start", Reason: fmt.Sprintf("jam %q harus format HH:MM", v)}
+			}
+		}
+	}
+	return nil
+}
+
+// matches mengecek apakah event lolos semua filter langganan
+func (s Subscription) matches(e Event) bool {
+	if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, e.Type) {
+		return false
+	}
+	if s.ExceptionsOnly && !e.Exception {
+		return false
+	}
+	if s.BPartnerID != nil && *s.BPartnerID != e.CustomerID {
+		return false
+	}
+	if s.DriverID != nil && *s.DriverID != e.DriverID {
+		return false
+	}
+	if s.TNKBID != nil && *s.TNKBID != e.TNKBID {
+		return false
+	}
+	return true
+}
+
+// quietUntil mengembalikan akhir jam tenang jika now berada di dalamnya.
+// Rentang boleh melewati tengah malam (mis. 22:00 - 06:00).
+func (s Subscription) quietUntil(now time.Time) (time.Time, bool) {
+	if s.QuietStart == nil || s.QuietEnd == nil {
+		return time.Time{}, false
+	}
+	start, err1 := time.Parse(quietLayout, *s.QuietStart)
+	end, err2 := time.Parse(quietLayout, *s.QuietEnd)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return time.Time{}, false
+	}
+
+	minuteOf := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+	cur, from, to := minuteOf(now), minuteOf(start), minuteOf(end)
+
+	var quiet bool
+	if from < to {
+		quiet = cur >= from && cur < to
+	} else {
+		quiet = cur >= from || cur < to
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(now.Year(), now.Month(), now.Day(), end.Hour(), end.Minute(), 0, 0, now.Location())
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// fromDB mengisi field turunan setelah scan
+func (s *Subscription) fromDB() {
+	s.EventTypes = []string{}
+	if s.EventTypesRaw != nil {
+		for _, e := range strings.Split(*s.EventTypesRaw, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				s.EventTypes = append(s.EventTypes, e)
+			}
+		}
+	}
+	s.ExceptionsOnly = s.ExceptionsOnlyRaw == "Y"
+}
+
+// toDB menyiapkan kolom mentah sebelum disimpan
+func (s *Subscription) toDB() {
+	s.EventTypesRaw = nil
+	if len(s.EventTypes) > 0 {
+		raw := strings.Join(s.EventTypes, ",")
+		s.EventTypesRaw = &raw
+	}
+	s.ExceptionsOnlyRaw = "N"
+	if s.ExceptionsOnly {
+		s.ExceptionsOnlyRaw = "Y"
+	}
+}
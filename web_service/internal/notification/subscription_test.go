@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestSubscriptionMatches(t *testing.T) {
+	event := Event{Type: EventDriverCheckin, CustomerID: 100, DriverID: 200, TNKBID: 300}
+	exception := Event{Type: EventDriverCheckin, CustomerID: 100, DriverID: 200, TNKBID: 300, Exception: true}
+
+	tests := []struct {
+		name  string
+		sub   Subscription
+		event Event
+		want  bool
+	}{
+		{"tanpa filter", Subscription{}, event, true},
+		{"event cocok", Subscription{EventTypes: []string{EventDriverCheckout, EventDriverCheckin}}, event, true},
+		{"event lain", Subscription{EventTypes: []string{EventDriverCheckout}}, event, false},
+		{"customer cocok", Subscription{BPartnerID: ptr(int64(100))}, event, true},
+		{"customer lain", Subscription{BPartnerID: ptr(int64(101))}, event, false},
+		{"driver cocok", Subscription{DriverID: ptr(int64(200))}, event, true},
+		{"driver lain", Subscription{DriverID: ptr(int64(201))}, event, false},
+		{"TNKB cocok", Subscription{TNKBID: ptr(int64(300))}, event, true},
+		{"TNKB lain", Subscription{TNKBID: ptr(int64(301))}, event, false},
+		{"exceptions only, event biasa", Subscription{ExceptionsOnly: true}, event, false},
+		{"exceptions only, event pengecualian", Subscription{ExceptionsOnly: true}, exception, true},
+		{
+			name: "semua filter cocok",
+			sub: Subscription{
+				EventTypes: []string{EventDriverCheckin}, BPartnerID: ptr(int64(100)),
+				DriverID: ptr(int64(200)), TNKBID: ptr(int64(300)), ExceptionsOnly: true,
+			},
+			event: exception,
+			want:  true,
+		},
+		{
+			name:  "satu filter tidak cocok",
+			sub:   Subscription{BPartnerID: ptr(int64(100)), DriverID: ptr(int64(999))},
+			event: event,
+			want:  false,
+		},
+		{"event tanpa driver", Subscription{DriverID: ptr(int64(200))}, Event{Type: EventDriverCheckin}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.sub.matches(tt.event); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionQuietUntil(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, wib) }
+
+	tests := []struct {
+		name       string
+		start, end *string
+		now        time.Time
+		want       time.Time // zero = tidak sedang jam tenang
+	}{
+		{"tanpa jam tenang", nil, nil, at(4, 23, 0), time.Time{}},
+		{"hanya start", ptr("22:00"), nil, at(4, 23, 0), time.Time{}},
+		{"start = end", ptr("22:00"), ptr("22:00"), at(4, 22, 0), time.Time{}},
+		{"format salah", ptr("22"), ptr("06:00"), at(4, 23, 0), time.Time{}},
+
+		// Rentang di hari yang sama
+		{"siang, di dalam", ptr("12:00"), ptr("13:00"), at(4, 12, 30), at(4, 13, 0)},
+		{"siang, tepat start", ptr("12:00"), ptr("13:00"), at(4, 12, 0), at(4, 13, 0)},
+		{"siang, tepat end", ptr("12:00"), ptr("13:00"), at(4, 13, 0), time.Time{}},
+		{"siang, sebelum", ptr("12:00"), ptr("13:00"), at(4, 11, 59), time.Time{}},
+
+		// Rentang melewati tengah malam
+		{"malam, sebelum tengah malam", ptr("22:00"), ptr("06:00"), at(4, 23, 15), at(5, 6, 0)},
+		{"malam, setelah tengah malam", ptr("22:00"), ptr("06:00"), at(5, 2, 0), at(5, 6, 0)},
+		{"malam, tepat start", ptr("22:00"), ptr("06:00"), at(4, 22, 0), at(5, 6, 0)},
+		{"malam, tepat end", ptr("22:00"), ptr("06:00"), at(5, 6, 0), time.Time{}},
+		{"malam, siang hari", ptr("22:00"), ptr("06:00"), at(4, 12, 0), time.Time{}},
+		{"malam, akhir bulan", ptr("22:00"), ptr("06:00"), time.Date(2026, 3, 31, 23, 0, 0, 0, wib), time.Date(2026, 4, 1, 6, 0, 0, 0, wib)},
+	}
+
+	for _, tt := range tests {
+		sub := Subscription{QuietStart: tt.start, QuietEnd: tt.end}
+		got, quiet := sub.quietUntil(tt.now)
+		if quiet != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("%s: quietUntil(%s) = %s, %v, want %s", tt.name, tt.now.Format("01-02 15:04"), got, quiet, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	valid := func() Subscription {
+		return Subscription{Name: "Ops", Channel: ChannelWebhook, Recipient: "https://example.com/hook", Lang: LangID}
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(*Subscription)
+		field string // kosong = valid
+	}{
+		{"valid", func(s *Subscription) {}, ""},
+		{"jam tenang valid", func(s *Subscription) { s.QuietStart, s.QuietEnd = ptr("22:00"), ptr("06:00") }, ""},
+		{"nama kosong", func(s *Subscription) { s.Name = " " }, "name"},
+		{"channel tidak dikenal", func(s *Subscription) { s.Channel = "sms" }, "channel"},
+		{"penerima kosong", func(s *Subscription) { s.Recipient = "" }, "recipient"},
+		{"bahasa salah", func(s *Subscription) { s.Lang = "ind" }, "lang"},
+		{"event tidak dikenal", func(s *Subscription) { s.EventTypes = []string{"LAIN"} }, "event_types"},
+		{"jam tenang separuh", func(s *Subscription) { s.QuietStart = ptr("22:00") }, "quiet_start"},
+		{"jam tenang salah format", func(s *Subscription) { s.QuietStart, s.QuietEnd = ptr("25:00"), ptr("06:00") }, "quiet_start"},
+	}
+
+	for _, tt := range tests {
+		sub := valid()
+		tt.edit(&sub)
+		err := sub.validate()
+
+		if tt.field == "" {
+			if err != nil {
+				t.Errorf("%s: validate = %v", tt.name, err)
+			}
+			continue
+		}
+		se, ok := err.(*SubscriptionError)
+		if !ok || se.Field != tt.field {
+			t.Errorf("%s: validate = %v, want field %q", tt.name, err, tt.field)
+		}
+	}
+}
+
+func TestSubscriptionDBRoundTrip(t *testing.T) {
+	sub := Subscription{
+		Name: "Ops", Channel: ChannelEmail, Recipient: " ops@example.com ", Lang: " EN ",
+		EventTypes: []string{" driver_checkin", EventDriverCheckout}, ExceptionsOnly: true,
+		QuietStart: ptr(""), QuietEnd: ptr(""),
+	}
+	sub.normalize()
+	if sub.Recipient != "ops@example.com" || sub.Lang != LangEN || sub.QuietStart != nil || sub.QuietEnd != nil {
+		t.Errorf("normalize = %+v", sub)
+	}
+
+	sub.toDB()
+	if sub.EventTypesRaw == nil || *sub.EventTypesRaw != "DRIVER_CHECKIN,"+EventDriverCheckout || sub.ExceptionsOnlyRaw != "Y" {
+		t.Errorf("toDB = %v, %q", sub.EventTypesRaw, sub.ExceptionsOnlyRaw)
+	}
+
+	var loaded Subscription
+	loaded.EventTypesRaw, loaded.ExceptionsOnlyRaw = ptr(" DRIVER_CHECKIN , ,"+EventDriverCheckout), "Y"
+	loaded.fromDB()
+	if len(loaded.EventTypes) != 2 || loaded.EventTypes[0] != "DRIVER_CHECKIN" || !loaded.ExceptionsOnly {
+		t.Errorf("fromDB = %+v", loaded)
+	}
+
+	empty := Subscription{}
+	empty.toDB()
+	empty.fromDB()
+	if empty.EventTypesRaw != nil || len(empty.EventTypes) != 0 || empty.EventTypes == nil || empty.ExceptionsOnly {
+		t.Errorf("langganan kosong = %+v", empty)
+	}
+}
@@ -0,0 +1,197 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+const maxTemplateLen = 4000 // kolom VARCHAR2(4000)
+
+var langPattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+func validLang(lang string) bool {
+	return langPattern.MatchString(lang)
+}
+
+// TemplateError: template tidak bisa diparse / dirender. Handler memetakan ke HTTP 400.
+type TemplateError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("template %s tidak valid: %s", e.Field, e.Reason)
+}
+
+var templateFuncs = template.FuncMap{
+	// inc untuk penomoran range yang mulai dari 1
+	"inc": func(i int) int { return i + 1 },
+	// default mengganti string kosong, mis. {{default "-" .Notes}}
+	"default": func(fallback, v string) string {
+		if strings.TrimSpace(v) == "" {
+			return fallback
+		}
+		return v
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// defaultTemplates dipakai jika ADW_STS_NOTIF_TEMPLATE belum punya template untuk event/bahasa tersebut
+var defaultTemplates = map[string]map[string]MessageTemplate{
+	LangID: {
+		EventDriverCheckin: {
+			Subject: "Driver Check-In Customer - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-In Customer*
+
+Driver: *{{.Driver}}*
+TNKB: *{{.TNKB}}*
+Customer: *{{.Customer}}*
+Waktu   : *{{.Time}}*
+Catatan: {{default "-" .Notes}}
+{{if .Exception}}
+_Perhatian: {{.ExceptionReason}}_
+{{end}}
+*Daftar Surat Jalan:*
+{{range $i, $d := .Details}}{{inc $i}}. *{{$d.DocumentNo}}* - {{$d.CustomerName}}
+{{end}}
+_Total: {{len .Details}} Surat Jalan_`,
+		},
+		EventDriverCheckout: {
+			Subject: "Driver Check-Out Customer - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-Out Customer*
+
+Driver: *{{.Driver}}*
+TNKB: *{{.TNKB}}*
+Customer: *{{.Customer}}*
+Waktu   : *{{.Time}}*
+Catatan: {{default "-" .Notes}}
+{{if .Exception}}
+_Perhatian: {{.ExceptionReason}}_
+{{end}}
+*Daftar Surat Jalan:*
+{{range $i, $d := .Details}}{{inc $i}}. *{{$d.DocumentNo}}* - {{$d.CustomerName}}
+{{end}}
+_Total: {{len .Details}} Surat Jalan_`,
+		},
+		EventDriverCheckoutNoSJ: {
+			Subject: "Driver Check-Out Tanpa SJ - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-Out (Tanpa SJ)*
+
+Driver: *{{.Driver}}*
+Lokasi: *{{.Customer}}*
+Waktu : *{{.Time}}*
+Catatan: {{.Notes}}
+
+_Keterangan: Driver telah meninggalkan lokasi customer tanpa membawa kembali dokumen Surat Jalan._`,
+		},
+	},
+	LangEN: {
+		EventDriverCheckin: {
+			Subject: "Driver Check-In - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-In*
+
+Driver: *{{.Driver}}*
+Plate: *{{.TNKB}}*
+Customer: *{{.Customer}}*
+Time: *{{.Time}}*
+Notes: {{default "-" .Notes}}
+{{if .Exception}}
+_Attention: {{.ExceptionReason}}_
+{{end}}
+*Delivery Notes:*
+{{range $i, $d := .Details}}{{inc $i}}. *{{$d.DocumentNo}}* - {{$d.CustomerName}}
+{{end}}
+_Total: {{len .Details}} delivery notes_`,
+		},
+		EventDriverCheckout: {
+			Subject: "Driver Check-Out - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-Out*
+
+Driver: *{{.Driver}}*
+Plate: *{{.TNKB}}*
+Customer: *{{.Customer}}*
+Time: *{{.Time}}*
+Notes: {{default "-" .Notes}}
+{{if .Exception}}
+_Attention: {{.ExceptionReason}}_
+{{end}}
+*Delivery Notes:*
+{{range $i, $d := .Details}}{{inc $i}}. *{{$d.DocumentNo}}* - {{$d.CustomerName}}
+{{end}}
+_Total: {{len .Details}} delivery notes_`,
+		},
+		EventDriverCheckoutNoSJ: {
+			Subject: "Driver Check-Out Without Delivery Notes - {{.Driver}} ({{.Customer}})",
+			Body: `*Driver Check-Out (No Delivery Notes)*
+
+Driver: *{{.Driver}}*
+Location: *{{.Customer}}*
+Time: *{{.Time}}*
+Notes: {{default "-" .Notes}}
+
+_The driver left the customer location without bringing back the delivery notes._`,
+		},
+	},
+}
+
+// defaultTemplate mencari template bawaan untuk bahasa, lalu DefaultLang
+func defaultTemplate(eventType, lang string) (MessageTemplate, bool) {
+	for _, l := range []string{lang, DefaultLang} {
+		if t, ok := defaultTemplates[l][eventType]; ok {
+			t.EventType = eventType
+			t.Lang = l
+			t.Source = TemplateSourceDefault
+			return t, true
+		}
+	}
+	return MessageTemplate{}, false
+}
+
+// validateTemplate memastikan event, bahasa dan isi template bisa diparse sebelum disimpan
+func validateTemplate(t MessageTemplate) error {
+	if !slices.Contains(EventTypes, t.EventType) {
+		return &TemplateError{Field: "event_type", Reason: fmt.Sprintf("event %q tidak dikenal", t.EventType)}
+	}
+	if !validLang(t.Lang) {
+		return &TemplateError{Field: "lang", Reason: "harus kode bahasa 2 huruf, mis. id / en"}
+	}
+	if strings.TrimSpace(t.Body) == "" {
+		return &TemplateError{Field: "body", Reason: "tidak boleh kosong"}
+	}
+	if len(t.Subject) > maxTemplateLen || len(t.Body) > maxTemplateLen {
+		return &TemplateError{Field: "body", Reason: fmt.Sprintf("maksimal %d karakter", maxTemplateLen)}
+	}
+	_, err := renderTemplate(t, Event{Type: t.EventType})
+	return err
+}
+
+// renderTemplate merender subject & body terhadap data event
+func renderTemplate(t MessageTemplate, e Event) (Message, error) {
+	subject, err := execTemplate("subject", t.Subject, e)
+	if err != nil {
+		return Message{}, err
+	}
+	body, err := execTemplate("body", t.Body, e)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: strings.TrimSpace(subject), Body: strings.TrimSpace(body), Event: e}, nil
+}
+
+func execTemplate(field, text string, e Event) (string, error) {
+	tmpl, err := template.New(field).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", &TemplateError{Field: field, Reason: err.Error()}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return "", &TemplateError{Field: field, Reason: err.Error()}
+	}
+	return buf.String(), nil
+}
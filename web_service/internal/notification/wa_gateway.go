@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type waGatewayChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewWAGatewayChannel mengirim pesan lewat WA gateway HTTP (POST {groupId, message})
+func NewWAGatewayChannel(url string) Channel {
+	return &waGatewayChannel{url: url, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (c *waGatewayChannel) Name() string { return ChannelWAGateway }
+
+func (c *waGatewayChannel) Send(ctx context.Context, to string, msg Message) error {
+	if c.url == "" {
+		return ErrChannelDisabled
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"groupId": to,
+		"message": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("gagal kirim HTTP Post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code tidak 200: %d", resp.StatusCode)
+	}
+	return nil
+}
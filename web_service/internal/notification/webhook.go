@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader berisi "sha256=<hex HMAC body>" jika WEBHOOK_SECRET diisi
+const SignatureHeader = "X-STS-Signature"
+
+type webhookChannel struct {
+	secret string
+	client *http.Client
+}
+
+// NewWebhookChannel mengirim event sebagai JSON ke URL penerima
+func NewWebhookChannel(secret string) Channel {
+	return &webhookChannel{secret: secret, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (c *webhookChannel) Name() string { return ChannelWebhook }
+
+type webhookPayload struct {
+	Event   Event  `json:"event"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+func (c *webhookChannel) Send(ctx context.Context, to string, msg Message) error {
+	body, err := json.Marshal(webhookPayload{Event: msg.Event, Subject: msg.Subject, Message: msg.Body})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, to, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(body)
+		httpReq.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("gagal kirim webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook membalas status %d", resp.StatusCode)
+	}
+	return nil
+}
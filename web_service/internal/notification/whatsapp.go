@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ClientProvider memberi klien whatsmeow yang sedang aktif (berganti setelah re-pair)
+type ClientProvider interface {
+	Client() *whatsmeow.Client
+}
+
+type whatsAppChannel struct {
+	provider ClientProvider
+}
+
+// NewWhatsAppChannel mengirim pesan langsung dengan klien whatsmeow. provider boleh nil (channel nonaktif).
+func NewWhatsAppChannel(provider ClientProvider) Channel {
+	return &whatsAppChannel{provider: provider}
+}
+
+func (c *whatsAppChannel) Name() string { return ChannelWhatsApp }
+
+func (c *whatsAppChannel) Send(ctx context.Context, to string, msg Message) error {
+	if c.provider == nil {
+		return ErrChannelDisabled
+	}
+	client := c.provider.Client()
+	if client == nil {
+		return ErrChannelDisabled
+	}
+	if !client.IsConnected() {
+		return fmt.Errorf("klien WhatsApp tidak terhubung")
+	}
+
+	jid, err := types.ParseJID(to)
+	if err != nil {
+		return fmt.Errorf("JID %q tidak valid: %w", to, err)
+	}
+
+	_, err = client.SendMessage(ctx, jid, &waE2E.Message{
+		Conversation: proto.String(msg.Body),
+	})
+	return err
+}
@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sts/web_service/internal/shared/poller"
+	"time"
+)
+
+const (
+	deliveryPollInterval = 5 * time.Second
+	deliveryBatchSize    = 20
+	deliveryLease        = 2 * time.Minute // SENDING yang lewat lease diambil ulang (worker crash)
+	deliverySendTimeout  = 30 * time.Second
+)
+
+// deliveryBackoff: 30s, 1m, 2m, 4m ... maksimal 2 jam
+var deliveryBackoff = poller.Backoff{Base: 30 * time.Second, Max: 2 * time.Hour}
+
+// DeliveryWorker mengirim notifikasi dari ADW_STS_NOTIF_DELIVERY dengan retry & dead-letter
+type DeliveryWorker struct {
+	*poller.Poller[Delivery]
+}
+
+func NewDeliveryWorker(repo Repository, maxAttempts int, logger *slog.Logger, channels ...Channel) *DeliveryWorker {
+	byName := make(map[string]Channel, len(channels))
+	for _, c := range channels {
+		byName[c.Name()] = c
+	}
+	queue := &deliveryQueue{repo: repo, channels: byName}
+	return &DeliveryWorker{poller.New[Delivery](queue, poller.Options{
+		Name:         "notification",
+		PollInterval: deliveryPollInterval,
+		BatchSize:    deliveryBatchSize,
+		Lease:        deliveryLease,
+		TaskTimeout:  deliverySendTimeout,
+		MaxAttempts:  maxAttempts,
+		Backoff:      deliveryBackoff,
+	}, logger)}
+}
+
+// deliveryQueue menghubungkan ADW_STS_NOTIF_DELIVERY ke poller
+type deliveryQueue struct {
+	repo     Repository
+	channels map[string]Channel
+}
+
+func (q *deliveryQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	return q.repo.ClaimDeliveries(ctx, limit, lease)
+}
+
+func (q *deliveryQueue) Process(ctx context.Context, d Delivery) error {
+	ch, ok := q.channels[d.Channel]
+	if !ok {
+		return fmt.Errorf("%s: %w", d.Channel, ErrChannelDisabled)
+	}
+
+	var msg Message
+	if err := json.Unmarshal([]byte(d.Payload), &msg); err != nil {
+		return fmt.Errorf("payload tidak valid: %w", err)
+	}
+	return ch.Send(ctx, d.Recipient, msg)
+}
+
+func (q *deliveryQueue) Complete(ctx context.Context, d Delivery, _ time.Time) error {
+	return q.repo.MarkDelivered(ctx, d.ID)
+}
+
+func (q *deliveryQueue) Fail(ctx context.Context, d Delivery, _ time.Time, reason string, retryIn time.Duration, dead bool) error {
+	return q.repo.MarkFailed(ctx, d.ID, reason, retryIn, dead)
+}
+
+func (q *deliveryQueue) Attempts(d Delivery) int { return d.Attempts }
+
+func (q *deliveryQueue) LogAttrs(d Delivery) []any {
+	return []any{"id", d.ID, "channel", d.Channel, "to", d.Recipient}
+}
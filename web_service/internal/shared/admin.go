@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/go-chi/render"
+)
+
+// RoleAdmin sesuai nilai claim "title" (AD_USER.TITLE)
+const RoleAdmin = "admin"
+
+// AdminID mengembalikan user ID dari token dan apakah user tersebut admin
+func AdminID(r *http.Request) (int64, bool) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return 0, false
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, false
+	}
+	title, _ := claims["title"].(string)
+	return userID, strings.EqualFold(strings.TrimSpace(title), RoleAdmin)
+}
+
+// AdminOnly menolak request dari user selain admin dengan HTTP 403
+func AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := AdminID(r); !ok {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, map[string]interface{}{
+				"success": false,
+				"message": "hanya admin yang boleh mengakses fitur ini",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -12,6 +12,9 @@ import (
 
 var validate = validator.New()
 
+// ErrBodyTooLarge dikembalikan jika body melewati batas http.MaxBytesReader (handler memetakan ke 413)
+var ErrBodyTooLarge = errors.New("request body terlalu besar")
+
 // BindAndValidate hanya decode & validate, return error biasa
 func BindAndValidate(r *http.Request, v interface{}) error {
 	if reflect.ValueOf(v).Kind() != reflect.Ptr {
@@ -19,6 +22,10 @@ func BindAndValidate(r *http.Request, v interface{}) error {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return ErrBodyTooLarge
+		}
 		log.Printf(
 			"[DECODE] invalid request body | path=%s method=%s error=%v",
 			r.URL.Path,
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -15,6 +17,43 @@ type Config struct {
 	AllowedOrigins []string
 	UploadPath     string
 	BaseURL        string
+	UploadSecret   string // kunci HMAC link unduhan foto bukti (storage.SignedURL)
+
+	PdfTemplatePath string // template layout PDF handover (JSON)
+	QRSecret        string // kunci HMAC token verifikasi QR
+	GeofenceMode    string // OFF, FLAG atau REJECT untuk check-in di luar radius customer
+
+	// Storage file upload & dokumen: local atau s3
+	StorageDriver    string
+	StorageLocalRoot string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3PathStyle      bool
+
+	// Notifikasi: rute event -> channel, plus konfigurasi tiap channel
+	NotifyRoutesPath  string
+	WAGatewayURL      string // kosong = channel wa_gateway tidak aktif
+	WAGroupID         string // penerima default jika file rute tidak ada
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUser          string
+	SMTPPassword      string
+	SMTPFrom          string
+	WebhookSecret     string // kunci HMAC header X-STS-Signature
+	WAEnabled         bool   // sesi whatsmeow langsung (pairing lewat endpoint admin)
+	WASessionDSN      string // sqlite penyimpan sesi whatsmeow
+	NotifyMaxAttempts int    // setelah sekian kali gagal notifikasi masuk dead-letter
+
+	// Penomoran dokumen bundle
+	DocNoFormat   string            // mis. {PREFIX}/{YYYY}/{MM}/{SEQ:6}
+	DocNoReset    string            // YEARLY, MONTHLY atau NEVER
+	DocNoPrefixes map[string]string // status penerimaan -> prefix (HOPT, HITP, HIPM, HIMF)
+
+	// Target SLA per stage (status ADW_STS) untuk analitik dwell time, mis. "RE: DPK_FROM_DEL=24h"
+	SLATargets map[string]string
 }
 
 func LoadConfig() (*Config, error) {
@@ -36,6 +75,55 @@ func LoadConfig() (*Config, error) {
 		AllowedOrigins: origins,
 		UploadPath:     getEnv("UPLOAD_PATH", "./uploads/article/images"),
 		BaseURL:        getEnv("BASE_URL", "http://localhost:8080"),
+		UploadSecret:   getEnv("UPLOAD_SECRET", ""),
+
+		PdfTemplatePath: getEnv("PDF_TEMPLATE_PATH", "templates/handover_pdf.json"),
+		QRSecret:        getEnv("QR_SECRET", ""),
+		GeofenceMode:    strings.ToUpper(getEnv("GEOFENCE_MODE", "FLAG")),
+
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalRoot: getEnv("STORAGE_LOCAL_ROOT", "uploads"),
+		S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+		S3Region:         getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:         getEnv("S3_BUCKET", ""),
+		S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:      getEnv("S3_PATH_STYLE", "true") == "true",
+
+		NotifyRoutesPath:  getEnv("NOTIFY_ROUTES_PATH", "templates/notification_routes.json"),
+		WAGatewayURL:      getEnv("WA_GATEWAY_URL", ""),
+		WAGroupID:         getEnv("WA_GROUP_ID", ""),
+		SMTPHost:          getEnv("SMTP_HOST", ""),
+		SMTPPort:          getEnv("SMTP_PORT", "587"),
+		SMTPUser:          getEnv("SMTP_USER", ""),
+		SMTPPassword:      getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:          getEnv("SMTP_FROM", ""),
+		WebhookSecret:     getEnv("WEBHOOK_SECRET", ""),
+		WAEnabled:         getEnv("WA_ENABLED", "false") == "true",
+		WASessionDSN:      getEnv("WA_SESSION_DSN", "file:whatsapp_session.db?_pragma=foreign_keys(1)"),
+		NotifyMaxAttempts: getEnvInt("NOTIFY_MAX_ATTEMPTS", 6),
+
+		DocNoFormat: getEnv("DOCNO_FORMAT", "{PREFIX}/{YYYY}/{MM}/{SEQ:6}"),
+		DocNoReset:  strings.ToUpper(getEnv("DOCNO_RESET", "YEARLY")),
+		DocNoPrefixes: parsePairs(getEnv("DOCNO_PREFIXES",
+			"RE: DPK_FROM_DEL=HOPT,RE: DEL_FROM_DPK=HITP,RE: MKT_FROM_DEL=HIPM,RE: FAT_FROM_MKT=HIMF")),
+
+		SLATargets: parsePairs(getEnv("SLA_TARGETS",
+			"RE: DPK_FROM_DEL=24h,HO: DPK_TO_DRIVER=72h,HO: DRIVER_CHECKIN=24h,HO: DRIVER_CHECKOUT=48h,"+
+				"RE: DPK_FROM_DRIVER=24h,RE: DEL_FROM_DPK=48h,RE: MKT_FROM_DEL=72h")),
+	}
+
+	// Foto bukti driver hanya bisa diunduh lewat link bertanda tangan, jadi secret wajib ada
+	if cfg.UploadSecret == "" {
+		return nil, errors.New("UPLOAD_SECRET wajib diisi")
+	}
+
+	// Token QR dibuka publik tanpa login; jangan memakai ulang JWT secret
+	if cfg.QRSecret == "" {
+		return nil, errors.New("QR_SECRET wajib diisi")
+	}
+	if cfg.QRSecret == cfg.JWTSecret {
+		return nil, errors.New("QR_SECRET harus berbeda dari JWT_SECRET")
 	}
 
 	return cfg, nil
@@ -48,3 +136,28 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt membaca env angka; nilai tidak valid memakai default
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(getEnv(key, "")); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+// parsePairs membaca format "key=value,key=value". Key boleh mengandung spasi/titik dua.
+func parsePairs(raw string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(item[:idx])
+		value := strings.TrimSpace(item[idx+1:])
+		if key != "" && value != "" {
+			pairs[key] = value
+		}
+	}
+	return pairs
+}
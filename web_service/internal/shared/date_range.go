@@ -0,0 +1,39 @@
+package shared
+
+import "time"
+
+// ParseDateRange mengolah input string menjadi range waktu yang valid.
+// Default From: Tanggal 1 bulan ini jam 00:00:00
+// Default To: From + 1 bulan (jika to kosong)
+func ParseDateRange(fromStr, toStr string) (time.Time, time.Time) {
+	now := time.Now()
+	loc := now.Location()
+
+	var dateFrom, dateTo time.Time
+
+	// 1. Logika Parsing FROM
+	parsedFrom, err := time.Parse("2006-01-02", fromStr)
+	if err == nil {
+		dateFrom = time.Date(parsedFrom.Year(), parsedFrom.Month(), parsedFrom.Day(), 0, 0, 0, 0, loc)
+	} else {
+		// Default: Tanggal 1 bulan berjalan
+		dateFrom = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	}
+
+	// 2. Logika Parsing TO
+	parsedTo, err := time.Parse("2006-01-02", toStr)
+	if err == nil {
+		// Ditambah 1 hari agar mencakup data sampai akhir hari yang dipilih (23:59:59)
+		dateTo = time.Date(parsedTo.Year(), parsedTo.Month(), parsedTo.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	} else {
+		if fromStr == "" {
+			// Jika dua-duanya kosong atau invalid, ambil range 1 bulan (sampai awal bulan depan)
+			dateTo = dateFrom.AddDate(0, 1, 0)
+		} else {
+			// Jika user kirim From tapi To kosong/salah, set range 1 hari saja
+			dateTo = dateFrom.AddDate(0, 0, 1)
+		}
+	}
+
+	return dateFrom, dateTo
+}
@@ -1,12 +1,14 @@
 package db
 
 import (
+	"errors"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/sijms/go-ora/v2"
+	"github.com/sijms/go-ora/v2/network"
 )
 
 func NewOracleDB(driver, dsn string) (*sqlx.DB, error) {
@@ -29,3 +31,12 @@ func NewOracleDB(driver, dsn string) (*sqlx.DB, error) {
 	log.Println("Oracle DB connected")
 	return db, nil
 }
+
+// IsUniqueViolation mengecek apakah error berasal dari ORA-00001 (unique constraint)
+func IsUniqueViolation(err error) bool {
+	var oraErr *network.OracleError
+	if errors.As(err, &oraErr) {
+		return oraErr.ErrCode == 1
+	}
+	return false
+}
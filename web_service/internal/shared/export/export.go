@@ -0,0 +1,187 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format export dari parameter ?format=; kosong / json berarti respons JSON biasa
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("format harus json, csv atau xlsx")
+
+// Writer menulis baris satu per satu langsung ke response (tanpa menampung semua baris)
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+// ParseFormat menormalkan nilai ?format=
+func ParseFormat(raw string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(raw)); f {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV, FormatXLSX:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// NewWriter membuat writer CSV / XLSX; header ditulis sebagai baris pertama
+func NewWriter(w io.Writer, format string, header []string) (Writer, error) {
+	var ew Writer
+	switch format {
+	case FormatCSV:
+		ew = newCSVWriter(w)
+	case FormatXLSX:
+		xw, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		ew = xw
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	row := make([]any, len(header))
+	for i, h := range header {
+		row[i] = h
+	}
+	if err := ew.Write(row); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+// SetHeaders menandai response sebagai file unduhan, mis. shipments-20260301.csv
+func SetHeaders(w http.ResponseWriter, format, name string) {
+	contentType := "text/csv; charset=utf-8"
+	if format == FormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// Columns mengambil nama kolom dari tag json struct (field json:"-" dilewati) supaya export sama dengan respons JSON
+func Columns(v any) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var cols []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := jsonName(t.Field(i)); ok {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
+
+// Values mengambil nilai field dengan urutan yang sama dengan Columns; pointer nil menjadi nil
+func Values(v any) []any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	t := rv.Type()
+
+	var values []any
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := jsonName(t.Field(i)); !ok {
+			continue
+		}
+		f := rv.Field(i)
+		if f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				values = append(values, nil)
+				continue
+			}
+			f = f.Elem()
+		}
+		values = append(values, f.Interface())
+	}
+	return values
+}
+
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// formatText dipakai CSV: tanggal tanpa jam ditulis YYYY-MM-DD
+func formatText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 {
+			return x.Format("2006-01-02")
+		}
+		return x.Format("2006-01-02 15:04:05")
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	// BOM supaya Excel membaca UTF-8 dengan benar
+	io.WriteString(w, "\ufeff")
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(row []any) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = formatText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
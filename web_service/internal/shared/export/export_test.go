@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type exportRow struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name,omitempty"`
+	Driver   *string    `json:"driver"`
+	Date     *time.Time `json:"date"`
+	Secret   string     `json:"-"`
+	NoTag    bool
+	internal string
+}
+
+func TestColumnsValues(t *testing.T) {
+	driver := "Budi"
+	date := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	wantCols := []string{"id", "name", "driver", "date", "NoTag"}
+	if got := Columns(exportRow{}); !reflect.DeepEqual(got, wantCols) {
+		t.Errorf("Columns = %v, want %v", got, wantCols)
+	}
+	// Pointer ke struct menghasilkan kolom yang sama
+	if got := Columns(&exportRow{}); !reflect.DeepEqual(got, wantCols) {
+		t.Errorf("Columns(pointer) = %v, want %v", got, wantCols)
+	}
+
+	tests := []struct {
+		name string
+		row  exportRow
+		want []any
+	}{
+		{"pointer nil", exportRow{ID: 1, Name: "A", Secret: "x", internal: "y"}, []any{int64(1), "A", nil, nil, false}},
+		{"pointer terisi", exportRow{ID: 2, Driver: &driver, Date: &date, NoTag: true}, []any{int64(2), "", "Budi", date, true}},
+	}
+	for _, tt := range tests {
+		got := Values(&tt.row)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Values = %v, want %v", tt.name, got, tt.want)
+		}
+		if len(got) != len(wantCols) {
+			t.Errorf("%s: jumlah nilai %d, kolom %d", tt.name, len(got), len(wantCols))
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  error
+	}{
+		{"", FormatJSON, nil},
+		{"json", FormatJSON, nil},
+		{" CSV ", FormatCSV, nil},
+		{"Xlsx", FormatXLSX, nil},
+		{"pdf", "", ErrUnknownFormat},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.raw)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q, %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, ""},
+		{"teks", "teks"},
+		{time.Time{}, ""},
+		{time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), "2026-03-04"},
+		{time.Date(2026, 3, 4, 8, 5, 9, 0, time.UTC), "2026-03-04 08:05:09"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{float32(2.5), "2.5"},
+	}
+	for _, tt := range tests {
+		if got := formatText(tt.v); got != tt.want {
+			t.Errorf("formatText(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV, []string{"no", "customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]any{"SJ/001", `PT "Maju", Tbk`})
+	w.Write([]any{nil, "baris\nbaru"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body, ok := strings.CutPrefix(buf.String(), "\ufeff")
+	if !ok {
+		t.Fatalf("CSV tanpa BOM: %q", buf.String())
+	}
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"no", "customer"}, {"SJ/001", `PT "Maju", Tbk`}, {"", "baris\nbaru"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV = %q, want %q", records, want)
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, FormatJSON, nil); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("NewWriter(json) = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "A"}, {1, "B"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {51, "AZ"}, {52, "BA"},
+		{701, "ZZ"}, {702, "AAA"}, {16383, "XFD"}, // kolom terakhir Excel
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.i); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestExcelSerial(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name string
+		t    time.Time
+		want float64
+	}{
+		{"epoch", time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), 0},
+		{"1900-03-01", time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 61},
+		{"tanggal", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), 46085},
+		{"tengah hari", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), 46085.5},
+		// Jam dinding yang dipakai, bukan UTC: 06:00 WIB tetap 06:00 di Excel
+		{"zona WIB", time.Date(2026, 3, 4, 6, 0, 0, 0, wib), 46085.25},
+	}
+	for _, tt := range tests {
+		if got := excelSerial(tt.t); got != tt.want {
+			t.Errorf("%s: excelSerial = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// xlsxCell hanya field yang dicek dari <c> di sheet1.xml
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Style  string `xml:"s,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Num   string     `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatXLSX, []string{"no", "customer", "tanggal", "jam", "jumlah", "aktif", "kosong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Write([]any{
+		"SJ/001", `PT <Maju> & "Jaya"`,
+		time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC),
+		int64(12), true, nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("bukan zip valid: %v", err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = data
+	}
+
+	// Semua bagian harus ada dan berupa XML yang well-formed
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		data, ok := parts[name]
+		if !ok {
+			t.Errorf("bagian %s tidak ada", name)
+			continue
+		}
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s bukan XML valid: %v", name, err)
+				break
+			}
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 2 || sheet.Rows[0].Num != "1" || sheet.Rows[1].Num != "2" {
+		t.Fatalf("rows = %+v", sheet.Rows)
+	}
+
+	header := sheet.Rows[0].Cells
+	if len(header) != 7 || header[6].Ref != "G1" || header[0].Inline != "no" || header[0].Style != "3" {
+		t.Errorf("header = %+v", header)
+	}
+
+	// Cell nil tidak ditulis
+	want := []xlsxCell{
+		{Ref: "A2", Type: "inlineStr", Inline: "SJ/001"},
+		{Ref: "B2", Type: "inlineStr", Inline: `PT <Maju> & "Jaya"`},
+		{Ref: "C2", Style: "1", Value: "46085"},
+		{Ref: "D2", Style: "2", Value: "46085.5"},
+		{Ref: "E2", Value: "12"},
+		{Ref: "F2", Type: "b", Value: "1"},
+	}
+	got := sheet.Rows[1].Cells
+	if len(got) != len(want) {
+		t.Fatalf("cells = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cell %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if !strings.Contains(string(parts["[Content_Types].xml"]), "/xl/worksheets/sheet1.xml") {
+		t.Error("Content_Types tanpa sheet1.xml")
+	}
+}
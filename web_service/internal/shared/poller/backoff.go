@@ -0,0 +1,21 @@
+package poller
+
+import "time"
+
+// Backoff eksponensial: Base, 2×Base, 4×Base ... maksimal Max
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay mengembalikan jeda sebelum percobaan berikutnya; attempt dimulai dari 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= b.Max {
+			return b.Max
+		}
+	}
+	return d
+}
@@ -0,0 +1,143 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Queue adalah tabel antrian yang diproses Poller (outbox, notifikasi, dsb).
+// Claim menandai item sebagai sedang diproses selama lease dan menaikkan jumlah percobaan.
+// Fail menjadwalkan ulang item retryIn dari sekarang; dihitung di DB (SYSDATE) seperti lease.
+type Queue[T any] interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]T, error)
+	Process(ctx context.Context, item T) error
+	Complete(ctx context.Context, item T, started time.Time) error
+	Fail(ctx context.Context, item T, started time.Time, reason string, retryIn time.Duration, dead bool) error
+
+	// Attempts: percobaan ke berapa, sudah termasuk claim yang sedang berjalan
+	Attempts(item T) int
+	// LogAttrs: atribut slog untuk log kegagalan, mis. "id", 12
+	LogAttrs(item T) []any
+}
+
+type Options struct {
+	Name         string // prefix pesan log, mis. "outbox"
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration // item yang lewat lease diambil ulang (worker crash)
+	TaskTimeout  time.Duration
+	MaxAttempts  int // setelah percobaan ini item menjadi dead-letter
+	Backoff      Backoff
+}
+
+// Poller mengambil item dari Queue secara berkala: claim -> process -> complete / fail dengan backoff
+type Poller[T any] struct {
+	queue  Queue[T]
+	opts   Options
+	logger *slog.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New[T any](queue Queue[T], opts Options, logger *slog.Logger) *Poller[T] {
+	return &Poller[T]{queue: queue, opts: opts, logger: logger}
+}
+
+// Start menjalankan poller di background
+func (p *Poller[T]) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			p.drain(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop menghentikan polling dan menunggu item yang sedang diproses selesai.
+// Item yang belum selesai saat ctx habis akan diambil ulang setelah lease lewat.
+func (p *Poller[T]) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain memproses batch sampai antrian kosong atau poller dihentikan
+func (p *Poller[T]) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		items, err := p.queue.Claim(ctx, p.opts.BatchSize, p.opts.Lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.logger.Error(p.opts.Name+" claim failed", "error", err)
+			}
+			return
+		}
+		if len(items) == 0 {
+			return
+		}
+
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return
+			}
+			p.process(item)
+		}
+	}
+}
+
+// process menjalankan satu item. Context tidak ikut dibatalkan saat shutdown
+// supaya item yang sudah diambil selesai diproses.
+func (p *Poller[T]) process(item T) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.TaskTimeout)
+	defer cancel()
+
+	started := time.Now()
+	errRun := p.queue.Process(ctx, item)
+
+	if errRun == nil {
+		if err := p.queue.Complete(ctx, item, started); err != nil {
+			p.logger.Error(p.opts.Name+" complete failed", append(p.queue.LogAttrs(item), "error", err)...)
+		}
+		return
+	}
+
+	attempts := p.queue.Attempts(item)
+	dead := attempts >= p.opts.MaxAttempts
+	retryIn := p.opts.Backoff.Delay(attempts)
+	p.logger.Warn(p.opts.Name+" attempt failed",
+		append(p.queue.LogAttrs(item), "attempt", attempts, "dead", dead, "error", errRun)...)
+
+	if err := p.queue.Fail(ctx, item, started, errRun.Error(), retryIn, dead); err != nil {
+		p.logger.Error(p.opts.Name+" fail update failed", append(p.queue.LogAttrs(item), "error", err)...)
+	}
+}
@@ -0,0 +1,161 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: 30 * time.Second, Max: time.Hour}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour}, // 64 menit dipotong ke Max
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := b.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+type fakeItem struct {
+	ID       int
+	Attempts int
+	Err      error
+}
+
+type failCall struct {
+	ID     int
+	Reason string
+	Delay  time.Duration
+	Dead   bool
+}
+
+// fakeQueue mengembalikan batch sesuai urutan, lalu kosong
+type fakeQueue struct {
+	mu        sync.Mutex
+	batches   [][]fakeItem
+	claims    int
+	completed []int
+	failed    []failCall
+}
+
+func (q *fakeQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]fakeItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.claims++
+	if len(q.batches) == 0 {
+		return nil, nil
+	}
+	batch := q.batches[0]
+	q.batches = q.batches[1:]
+	return batch, nil
+}
+
+func (q *fakeQueue) Process(ctx context.Context, item fakeItem) error { return item.Err }
+
+func (q *fakeQueue) Complete(ctx context.Context, item fakeItem, started time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.completed = append(q.completed, item.ID)
+	return nil
+}
+
+func (q *fakeQueue) Fail(ctx context.Context, item fakeItem, started time.Time, reason string, retryIn time.Duration, dead bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.failed = append(q.failed, failCall{ID: item.ID, Reason: reason, Delay: retryIn, Dead: dead})
+	return nil
+}
+
+func (q *fakeQueue) Attempts(item fakeItem) int { return item.Attempts }
+
+func (q *fakeQueue) LogAttrs(item fakeItem) []any { return []any{"id", item.ID} }
+
+func testOptions() Options {
+	return Options{
+		Name:         "test",
+		PollInterval: time.Hour,
+		BatchSize:    10,
+		Lease:        time.Minute,
+		TaskTimeout:  time.Second,
+		MaxAttempts:  3,
+		Backoff:      Backoff{Base: time.Second, Max: 10 * time.Second},
+	}
+}
+
+func TestPollerDrain(t *testing.T) {
+	errSend := errors.New("gateway down")
+	q := &fakeQueue{batches: [][]fakeItem{
+		{{ID: 1, Attempts: 1}, {ID: 2, Attempts: 1, Err: errSend}},
+		{{ID: 3, Attempts: 2, Err: errSend}, {ID: 4, Attempts: 3, Err: errSend}},
+	}}
+	p := New[fakeItem](q, testOptions(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	p.drain(context.Background())
+
+	// Batch diambil sampai Claim kosong
+	if q.claims != 3 {
+		t.Errorf("claims = %d, want 3", q.claims)
+	}
+	if len(q.completed) != 1 || q.completed[0] != 1 {
+		t.Errorf("completed = %v, want [1]", q.completed)
+	}
+	want := []failCall{
+		{ID: 2, Reason: "gateway down", Delay: time.Second},
+		{ID: 3, Reason: "gateway down", Delay: 2 * time.Second},
+		{ID: 4, Reason: "gateway down", Delay: 4 * time.Second, Dead: true}, // percobaan ke-MaxAttempts
+	}
+	if len(q.failed) != len(want) {
+		t.Fatalf("failed = %+v, want %+v", q.failed, want)
+	}
+	for i := range want {
+		if q.failed[i] != want[i] {
+			t.Errorf("failed[%d] = %+v, want %+v", i, q.failed[i], want[i])
+		}
+	}
+}
+
+func TestPollerStartStop(t *testing.T) {
+	q := &fakeQueue{batches: [][]fakeItem{{{ID: 1, Attempts: 1}}}}
+	p := New[fakeItem](q, testOptions(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Stop sebelum Start tidak error
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop sebelum Start = %v", err)
+	}
+
+	p.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		q.mu.Lock()
+		done := len(q.completed) == 1
+		q.mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Stop(ctx); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+	if len(q.completed) != 1 {
+		t.Errorf("completed = %v, want [1]", q.completed)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrivatePrefix: file di bawah prefix ini (foto bukti check-in / check-out) tidak bisa diunduh
+// lewat link biasa, hanya lewat SignedURL yang diberikan ke user yang sudah login.
+const PrivatePrefix = "events/"
+
+var ErrInvalidSignature = errors.New("link file tidak valid atau sudah kedaluwarsa")
+
+// IsPrivate menandai key yang wajib diakses dengan SignedURL
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(KeyFromPath(key), PrivatePrefix)
+}
+
+// SignedURL membangun link unduhan yang hanya berlaku sampai expires: .../key?expires=<unix>&sig=<hmac>
+func SignedURL(baseURL, key, secret string, expires time.Time) string {
+	key = KeyFromPath(key)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", exp)
+	q.Set("sig", signKey(secret, key, exp))
+	return URL(baseURL, key) + "?" + q.Encode()
+}
+
+// verifySignature memeriksa parameter expires & sig untuk key yang sudah dibersihkan (CleanKey)
+func verifySignature(secret, key string, q url.Values, now time.Time) error {
+	exp := q.Get("expires")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(signKey(secret, key, exp))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signKey(secret, key, expires string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
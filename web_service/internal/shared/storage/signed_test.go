@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedURLHandler(t *testing.T) {
+	st, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, key := range []string{"events/2026/03/foto.jpg", "handover/bundle.pdf"} {
+		if err := st.Put(ctx, key, strings.NewReader("isi "+key), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const secret = "rahasia"
+	h := Handler(st, PublicPath, secret)
+	valid := SignedURL("http://api", "events/2026/03/foto.jpg", secret, time.Now().Add(time.Hour))
+	expired := SignedURL("http://api", "events/2026/03/foto.jpg", secret, time.Now().Add(-time.Minute))
+	otherSecret := SignedURL("http://api", "events/2026/03/foto.jpg", "lain", time.Now().Add(time.Hour))
+	otherKey := SignedURL("http://api", "events/2026/03/lain.jpg", secret, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"file publik tanpa tanda tangan", "/uploads/handover/bundle.pdf", http.StatusOK},
+		{"foto bukti dengan tanda tangan", strings.TrimPrefix(valid, "http://api"), http.StatusOK},
+		{"foto bukti tanpa tanda tangan", "/uploads/events/2026/03/foto.jpg", http.StatusForbidden},
+		{"foto bukti lewat path traversal", "/uploads/handover/../events/2026/03/foto.jpg", http.StatusForbidden},
+		{"foto bukti dengan slash ganda", "/uploads//events/2026/03/foto.jpg", http.StatusForbidden},
+		{"link kedaluwarsa", strings.TrimPrefix(expired, "http://api"), http.StatusForbidden},
+		{"secret lain", strings.TrimPrefix(otherSecret, "http://api"), http.StatusForbidden},
+		{"tanda tangan key lain", "/uploads/events/2026/03/foto.jpg?" + mustQuery(t, otherKey), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL, err = url.Parse(tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.target, rec.Code, tt.want)
+		}
+		if rec.Code == http.StatusOK && IsPrivate(req.URL.Path[len(PublicPath):]) && rec.Header().Get("Cache-Control") != "private, no-store" {
+			t.Errorf("%s: Cache-Control = %q", tt.name, rec.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+func mustQuery(t *testing.T, raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.RawQuery
+}
+
+func TestIsPrivate(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"events/2026/03/a.jpg", true},
+		{"uploads/events/2026/03/a.jpg", true}, // path lama dengan prefix uploads/
+		{"/events/a.jpg", true},
+		{"handover/a.pdf", false},
+		{"eventsx/a.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := IsPrivate(tt.key); got != tt.want {
+			t.Errorf("IsPrivate(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"sts/web_service/internal/shared/config"
+)
+
+// PublicPath adalah prefix route untuk mengunduh file (BaseURL + PublicPath + "/" + key)
+const PublicPath = "/uploads"
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("file tidak ditemukan")
+	ErrInvalidKey = errors.New("storage key tidak valid")
+)
+
+// Storage menyimpan file upload & dokumen yang dihasilkan. Key memakai "/" (mis. handover/handover_x.pdf).
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New memilih backend sesuai STORAGE_DRIVER
+func New(cfg *config.Config) (Storage, error) {
+	switch strings.ToLower(cfg.StorageDriver) {
+	case "", DriverLocal:
+		return NewLocal(cfg.StorageLocalRoot)
+	case DriverS3:
+		return NewS3(S3Options{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			UsePathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER %q tidak dikenal (local, s3)", cfg.StorageDriver)
+	}
+}
+
+// CleanKey menormalkan key dan menolak path traversal
+func CleanKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// KeyFromPath mengubah nilai lama berbentuk path lokal (uploads/handover/x.pdf) menjadi key (handover/x.pdf)
+func KeyFromPath(p string) string {
+	p = strings.TrimLeft(strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "./"), "/")
+	return strings.TrimPrefix(p, strings.TrimPrefix(PublicPath, "/")+"/")
+}
+
+// URL membangun link unduhan publik untuk key
+func URL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + PublicPath + "/" + KeyFromPath(key)
+}
+
+// Handler melayani GET {prefix}/* dari storage, dipakai untuk route /uploads.
+// Key di bawah PrivatePrefix hanya dilayani jika link ditandatangani dengan secret (SignedURL).
+func Handler(st Storage, prefix, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := CleanKey(strings.TrimPrefix(r.URL.Path, prefix))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// Dicek setelah CleanKey supaya "a/../events/x" tidak lolos sebagai file publik
+		if IsPrivate(key) {
+			if err := verifySignature(secret, key, r.URL.Query(), time.Now()); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Cache-Control", "private, no-store")
+		}
+
+		rc, err := st.Open(r.Context(), key)
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to read file", http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+
+		// File lokal mendukung Range/If-Modified-Since lewat ServeContent
+		if rs, ok := rc.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, path.Base(key), modTime(rc), rs)
+			return
+		}
+
+		io.Copy(w, rc)
+	})
+}
@@ -0,0 +1,90 @@
+package shipment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+const maxKeysetLimit = 1000
+
+// KeysetPage parameter paging berbasis cursor; Limit 0 berarti semua baris (perilaku lama)
+type KeysetPage struct {
+	Cursor    string
+	Limit     int
+	WithTotal bool
+}
+
+// historyCursor = kunci urutan baris terakhir GetHistory
+type historyCursor struct {
+	BundleCreated time.Time `json:"c"`
+	MovementDate  time.Time `json:"m"`
+	MInOutID      int64     `json:"i"`
+	BundleLineID  int64     `json:"l"`
+}
+
+// progressCursor = kunci urutan baris terakhir GetDailyProgress
+type progressCursor struct {
+	Score      int    `json:"s"`
+	DocumentNo string `json:"d"`
+	MInOutID   int64  `json:"i"`
+	Driver     string `json:"r"`
+	TNKB       string `json:"t"`
+}
+
+func encodeCursor(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// keyCol adalah satu kolom urutan keyset
+type keyCol struct {
+	Col  string
+	Desc bool
+}
+
+// keysetAfter menyusun predikat "baris sesudah cursor" untuk urutan multi kolom:
+// (c1 > v1) OR (c1 = v1 AND ((c2 > v2) OR (c2 = v2 AND ...))). Placeholder dibuat berurutan sesuai teks SQL.
+func keysetAfter(cols []keyCol, values []interface{}, bind func(interface{}) string) string {
+	op := ">"
+	if cols[0].Desc {
+		op = "<"
+	}
+	cmp := cols[0].Col + " " + op + " " + bind(values[0])
+	if len(cols) == 1 {
+		return cmp
+	}
+	eq := cols[0].Col + " = " + bind(values[0])
+	return "(" + cmp + " OR (" + eq + " AND " + keysetAfter(cols[1:], values[1:], bind) + "))"
+}
+
+// orderBy menyusun ORDER BY dari kolom keyset
+func orderBy(cols []keyCol) string {
+	s := ""
+	for i, c := range cols {
+		if i > 0 {
+			s += ", "
+		}
+		s += c.Col
+		if c.Desc {
+			s += " DESC"
+		} else {
+			s += " ASC"
+		}
+	}
+	return s
+}
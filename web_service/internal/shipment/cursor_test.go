@@ -0,0 +1,130 @@
+package shipment
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	h := historyCursor{
+		BundleCreated: time.Date(2026, 3, 4, 8, 15, 30, 0, time.UTC),
+		MovementDate:  time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		MInOutID:      1234567,
+		BundleLineID:  89,
+	}
+	var gotH historyCursor
+	if err := decodeCursor(encodeCursor(h), &gotH); err != nil {
+		t.Fatal(err)
+	}
+	if !gotH.BundleCreated.Equal(h.BundleCreated) || !gotH.MovementDate.Equal(h.MovementDate) ||
+		gotH.MInOutID != h.MInOutID || gotH.BundleLineID != h.BundleLineID {
+		t.Errorf("historyCursor = %+v, want %+v", gotH, h)
+	}
+
+	p := progressCursor{Score: 3, DocumentNo: "SJ/2026/001", MInOutID: 42, Driver: "Budi", TNKB: "B 1234 XY"}
+	var gotP progressCursor
+	if err := decodeCursor(encodeCursor(p), &gotP); err != nil {
+		t.Fatal(err)
+	}
+	if gotP != p {
+		t.Errorf("progressCursor = %+v, want %+v", gotP, p)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"bukan base64", "%%%"},
+		{"base64 standar dengan padding", "eyJzIjoxfQ=="},
+		{"bukan JSON", encodeRaw("halo")},
+		{"tipe field salah", encodeRaw(`{"s":"tiga"}`)},
+		{"kosong", ""},
+	}
+
+	for _, tt := range tests {
+		var c progressCursor
+		if err := decodeCursor(tt.cursor, &c); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: decodeCursor = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+// encodeRaw meng-encode string apa adanya seperti encodeCursor (tanpa json.Marshal)
+func encodeRaw(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestKeysetAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		cols   []keyCol
+		values []interface{}
+		start  int // jumlah bind yang sudah ada sebelum predikat
+		want   string
+		args   []interface{}
+	}{
+		{
+			name:   "satu kolom ASC",
+			cols:   []keyCol{{Col: "M_InOut_ID"}},
+			values: []interface{}{int64(10)},
+			want:   "M_InOut_ID > :1",
+			args:   []interface{}{int64(10)},
+		},
+		{
+			name:   "satu kolom DESC",
+			cols:   []keyCol{{Col: "Score", Desc: true}},
+			values: []interface{}{3},
+			want:   "Score < :1",
+			args:   []interface{}{3},
+		},
+		{
+			name:   "dua kolom",
+			cols:   []keyCol{{Col: "MovementDate", Desc: true}, {Col: "M_InOut_ID"}},
+			values: []interface{}{"2026-03-04", int64(10)},
+			want:   "(MovementDate < :1 OR (MovementDate = :2 AND M_InOut_ID > :3))",
+			args:   []interface{}{"2026-03-04", "2026-03-04", int64(10)},
+		},
+		{
+			name:   "tiga kolom, bind melanjutkan filter sebelumnya",
+			cols:   []keyCol{{Col: "a"}, {Col: "b", Desc: true}, {Col: "c"}},
+			values: []interface{}{1, 2, 3},
+			start:  2,
+			want:   "(a > :3 OR (a = :4 AND (b < :5 OR (b = :6 AND c > :7))))",
+			args:   []interface{}{"f1", "f2", 1, 1, 2, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []interface{}
+			for i := 0; i < tt.start; i++ {
+				args = append(args, "f"+strconv.Itoa(i+1))
+			}
+			bind := func(v interface{}) string {
+				args = append(args, v)
+				return ":" + strconv.Itoa(len(args))
+			}
+
+			if got := keysetAfter(tt.cols, tt.values, bind); got != tt.want {
+				t.Errorf("keysetAfter =\n %s\nwant\n %s", got, tt.want)
+			}
+			// Placeholder :N harus sesuai urutan args (go-ora bind sesuai posisi di teks SQL)
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	cols := []keyCol{{Col: "Score", Desc: true}, {Col: "DocumentNo"}, {Col: "M_InOut_ID"}}
+	if got, want := orderBy(cols), "Score DESC, DocumentNo ASC, M_InOut_ID ASC"; got != want {
+		t.Errorf("orderBy = %q, want %q", got, want)
+	}
+}
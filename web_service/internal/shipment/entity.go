@@ -16,7 +16,47 @@ type Shipment struct {
 	ADWTMSID     *int64    `db:"ADW_TMS_ID" json:"tms_id"`
 }
 
+const (
+	defaultShipmentPageSize = 50
+	maxShipmentPageSize     = 500
+)
+
+// ShipmentFilter untuk GET /shipments; nilai nol berarti tanpa filter
+type ShipmentFilter struct {
+	Stage      string
+	Statuses   []string // ADW_STS.STATUS mentah, mis. "HO: DPK_TO_DRIVER"
+	From       time.Time
+	To         time.Time
+	CustomerID int64
+	DriverID   int64
+	TNKBID     int64
+	TMSMatched *bool // true: sudah match TMS (ADW_TMS_ID terisi)
+	Sort       string
+	Page       int
+	PageSize   int // 0 = semua baris (route lama)
+}
+
+type ShipmentPage struct {
+	Items    []Shipment `json:"items"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+	Total    int        `json:"total"`
+}
+
+type HistoryPage struct {
+	Items      []ShipmentHistory
+	NextCursor string
+	Total      *int
+}
+
+type ProgressPage struct {
+	Items      []ShipmentProgress
+	NextCursor string
+	Total      *int
+}
+
 type ShipmentProgress struct {
+	MInOutID     int64     `db:"M_INOUT_ID" json:"-"`
 	DocumentNo   string    `db:"DOCUMENTNO" json:"documentno"`
 	MatchTMS     string    `db:"MATCHTMS" json:"matchtms"`
 	Customer     string    `db:"CUSTOMER" json:"customer"`
@@ -33,6 +73,7 @@ type ShipmentProgress struct {
 	ComebackMkt  int       `db:"COMEBACKMKT" json:"comebackmkt"`
 	ComebackFat  int       `db:"COMEBACKFAT" json:"comebackfat"`
 	FinishFat    int       `db:"FINISHFAT" json:"finishfat"`
+	Score        int       `db:"SCORE" json:"-"` // jumlah tahap yang sudah dilalui, kunci urutan
 }
 
 type ShipmentHistory struct {
@@ -44,6 +85,35 @@ type ShipmentHistory struct {
 	Status         string    `db:"STATUS" json:"status"`
 	BundleNo       *string   `db:"BUNDLENO" json:"bundle_no"` // Gunakan pointer untuk null safety
 	AttachmentPath *string   `db:"ATTACHMENT" json:"attachment_path"`
+	BundleCreated  time.Time `db:"BUNDLECREATED" json:"-"` // kunci keyset
+	BundleLineID   int64     `db:"BUNDLELINEID" json:"-"`
+}
+
+// TimelineEntry satu baris ADW_STS_EVENT untuk GET /shipments/{m_inout_id}/timeline
+type TimelineEntry struct {
+	EventID          int64      `db:"ADW_STS_EVENT_ID" json:"event_id"`
+	StsID            int64      `db:"ADW_STS_ID" json:"sts_id"`
+	EventType        string     `db:"EVENTTYPE" json:"event_type"`
+	PrevActorID      *int64     `db:"PREVACTOR" json:"prev_actor_id"`
+	PrevActor        *string    `db:"PREVACTORNAME" json:"prev_actor_name"`
+	CurrentActorID   *int64     `db:"CURRENTACTOR" json:"current_actor_id"`
+	CurrentActor     *string    `db:"CURRENTACTORNAME" json:"current_actor_name"`
+	DriverID         *int64     `db:"DRIVERBY" json:"driver_id"`
+	Driver           *string    `db:"DRIVERNAME" json:"driver_name"`
+	TNKBID           *int64     `db:"TNKB_ID" json:"tnkb_id"`
+	TNKBNo           *string    `db:"TNKBNO" json:"tnkb_no"`
+	CustomerID       *int64     `db:"CURRENTCUSTOMER" json:"customer_id"`
+	Customer         *string    `db:"CUSTOMERNAME" json:"customer_name"`
+	Notes            *string    `db:"NOTES" json:"notes"`
+	PrevCreated      *time.Time `db:"PREVCREATED" json:"prev_created"`
+	Created          time.Time  `db:"CREATED" json:"created"`
+	PrevEventCreated *time.Time `db:"PREVEVENTCREATED" json:"-"`
+	PrevStageSeconds *int64     `json:"prev_stage_seconds"` // lama di stage sebelumnya, nil untuk event pertama
+	BundleID         *int64     `db:"ADW_STS_BUNDLE_ID" json:"bundle_id"`
+	BundleNo         *string    `db:"BUNDLENO" json:"bundle_no"`
+	Attachment       *string    `db:"ATTACHMENT" json:"attachment_path"`
+	BundleURL        string     `json:"bundle_url,omitempty"`
+	PdfURL           string     `json:"pdf_url,omitempty"`
 }
 
 type Customer struct {
@@ -53,7 +123,7 @@ type Customer struct {
 
 type Driver struct {
 	ID   int64  `db:"AD_USER_ID" json:"driver_by"`
-	Name string `db:"NAME", json:"driver_name"`
+	Name string `db:"NAME" json:"driver_name"`
 }
 
 type DriverRequestUpdate struct {
@@ -64,14 +134,16 @@ type DriverRequestUpdate struct {
 
 type TNKB struct {
 	ID   int64  `db:"ADW_TMS_TNKB_ID" json:"tnkb_id"`
-	Name string `db:"NAME", json:"tnkb_no"`
+	Name string `db:"NAME" json:"tnkb_no"`
 }
 
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Count   int         `json:"count"`
-	Data    interface{} `json:"data,omitempty"`
+	Success    bool        `json:"success"`
+	Message    string      `json:"message,omitempty"`
+	Count      int         `json:"count"`
+	Total      *int        `json:"total,omitempty"`       // hanya jika diminta (withTotal=true)
+	NextCursor string      `json:"next_cursor,omitempty"` // kosong = halaman terakhir
+	Data       interface{} `json:"data,omitempty"`
 }
 
 type UpdateShipmentRequest struct {
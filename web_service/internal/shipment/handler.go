@@ -2,10 +2,13 @@ package shipment
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sts/web_service/internal/shared"
+	"sts/web_service/internal/shared/export"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -21,80 +24,112 @@ func NewHandler(s Service) *handler {
 
 func (h *handler) RegisterProtectedRoutes(r chi.Router) {
 	r.Route("/shipments", func(r chi.Router) {
+		r.Get("/", h.ListShipments)
+
 		r.Get("/customers", h.GetCustomers)
 		r.Get("/drivers", h.GetDrivers)
 		r.Put("/drivers", h.UpdateDriver)
 		r.Get("/tnkbs", h.GetTnkbs)
-		r.Get("/pending", h.GetPendingShipments)
-		r.Get("/prepare", h.GetPrepareShipments)
-		r.Get("/preparetoleave", h.GetPrepareToLeaveShipments)
+
+		// Route per stage = alias GET /shipments?stage=<stage> tanpa paging
+		r.Get("/pending", h.stageAlias(StagePending))
+		r.Get("/prepare", h.stageAlias(StagePrepare))
+		r.Get("/preparetoleave", h.stageAlias(StagePrepareToLeave))
 		r.Get("/in-transit", h.GetInTransitShipments)
 		r.Get("/on-customer", h.GetOnCustomerShipments)
-		r.Get("/comeback", h.GetComebackShipments)
-		r.Get("/comebacktodelivery", h.GetComebackToDeliveryShipments)
-		r.Get("/receiptcomebacktodelivery", h.GetReceiptComebackToDeliveryShipments)
+		r.Get("/comeback", h.stageAlias(StageComeback))
+		r.Get("/comebacktodelivery", h.stageAlias(StageComebackToDelivery))
+		r.Get("/receiptcomebacktodelivery", h.stageAlias(StageReceiptComebackToDelivery))
 
-		r.Get("/comebacktomarketing", h.GetComebackToMarketingShipments)
-		r.Get("/receiptcomebacktomarketing", h.GetReceiptComebackToMarketingShipments)
+		r.Get("/comebacktomarketing", h.stageAlias(StageComebackToMarketing))
+		r.Get("/receiptcomebacktomarketing", h.stageAlias(StageReceiptComebackToMarketing))
 
-		r.Get("/comebacktofat", h.GetComebackToFatShipments)
-		r.Get("/receiptcomebacktofat", h.GetReceiptComebackToFatShipments)
+		r.Get("/comebacktofat", h.stageAlias(StageComebackToFat))
+		r.Get("/receiptcomebacktofat", h.stageAlias(StageReceiptComebackToFat))
 
 		r.Get("/history", h.GetHistoryShipments)
 		r.Get("/progress", h.GetShipmentProgress)
+		r.Get("/{m_inout_id}/timeline", h.GetTimeline)
 
-		r.Get("/outstanding/dpk", h.GetOutstandingDPKShipments)
-		r.Get("/outstanding/delivery", h.GetOutstandingDeliveryShipments)
+		r.Get("/outstanding/dpk", h.stageAlias(StageOutstandingDPK))
+		r.Get("/outstanding/delivery", h.stageAlias(StageOutstandingDelivery))
 		r.Post("/edit/drivertnkb", h.HandleEditShipment)
 		r.Post("/outstanding/cancel", h.CancelOutstanding)
 	})
 }
 
-func (h *handler) GetHistoryShipments(w http.ResponseWriter, r *http.Request) {
-	// 1. Ambil parameter dari query URL
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-
-	// 2. Panggil service
-	list, err := h.service.GetHistory(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
+// ListShipments: GET /shipments?stage=&status=&dateFrom=&dateTo=&customerId=&driverId=&tnkbId=&tmsMatched=&sort=&page=&pageSize=
+// format=csv|xlsx mengunduh semua baris hasil filter (page & pageSize diabaikan).
+func (h *handler) ListShipments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	f := ShipmentFilter{
+		Stage: q.Get("stage"),
+		Sort:  q.Get("sort"),
+	}
+	for _, st := range strings.Split(q.Get("status"), ",") {
+		if st = strings.TrimSpace(st); st != "" {
+			f.Statuses = append(f.Statuses, st)
+		}
+	}
+
+	var invalid []string
+	parseID := func(key string) int64 {
+		v := q.Get(key)
+		if v == "" {
+			return 0
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			invalid = append(invalid, key)
+		}
+		return id
+	}
+	f.CustomerID = parseID("customerId")
+	f.DriverID = parseID("driverId")
+	f.TNKBID = parseID("tnkbId")
+	f.Page = int(parseID("page"))
+	f.PageSize = int(parseID("pageSize"))
+
+	if v := q.Get("tmsMatched"); v != "" {
+		matched, err := strconv.ParseBool(v)
+		if err != nil {
+			invalid = append(invalid, "tmsMatched")
+		}
+		f.TMSMatched = &matched
+	}
+
+	if len(invalid) > 0 {
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed to get shipment history",
+			Message: "parameter tidak valid: " + strings.Join(invalid, ", "),
 		})
 		return
 	}
 
-	// 3. Handle data kosong agar return array [] bukan null
-	if list == nil {
-		list = []ShipmentHistory{}
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	if format != export.FormatJSON {
+		exportRows(w, r, format, "shipments", func(fn func(Shipment) error) error {
+			return h.service.ExportShipments(r.Context(), q.Get("dateFrom"), q.Get("dateTo"), f, fn)
+		})
+		return
 	}
 
-	countData := len(list)
-
-	// 4. Kirim response
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
-}
-
-func (h *handler) GetShipmentProgress(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-	data, err := h.service.FetchProgress(r.Context(), from, to)
+	page, err := h.service.ListShipments(r.Context(), q.Get("dateFrom"), q.Get("dateTo"), f)
 	if err != nil {
+		if errors.Is(err, ErrUnknownStage) || errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrDriverRequired) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
 			r.URL.Path,
@@ -104,100 +139,102 @@ func (h *handler) GetShipmentProgress(w http.ResponseWriter, r *http.Request) {
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get progress shipment",
+			Message: "Failed get shipments",
 		})
 		return
 	}
 
-	if data == nil {
-		data = []ShipmentProgress{}
-	}
-
-	countData := len(data)
-
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
 		Success: true,
 		Message: "OK",
-		Count:   countData,
-		Data:    data,
+		Count:   len(page.Items),
+		Data:    page,
 	})
 }
 
-func (h *handler) GetDrivers(w http.ResponseWriter, r *http.Request) {
-
-	list, err := h.service.GetDriver()
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
+// stageAlias melayani route lama per stage dengan format respons lama (Data = array SJ)
+func (h *handler) stageAlias(stage string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := r.URL.Query().Get("dateFrom")
+		to := r.URL.Query().Get("dateTo")
+
+		format, ok := exportFormat(w, r)
+		if !ok {
+			return
+		}
+		if format != export.FormatJSON {
+			exportRows(w, r, format, stage, func(fn func(Shipment) error) error {
+				return h.service.ExportShipments(r.Context(), from, to, ShipmentFilter{Stage: stage}, fn)
+			})
+			return
+		}
+
+		list, err := h.service.ListStage(r.Context(), stage, from, to)
+		if err != nil {
+			log.Printf(
+				"[SERVICE]: path=%s method=%s error=%v",
+				r.URL.Path,
+				r.Method,
+				err,
+			)
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, APIResponse{
+				Success: false,
+				Message: "Failed get shipments",
+			})
+			return
+		}
+
+		if list == nil {
+			list = []Shipment{}
+		}
+
+		countData := len(list)
+
+		render.Status(r, http.StatusOK)
 		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get drivers",
+			Success: true,
+			Message: "OK",
+			Count:   countData,
+			Data:    list,
 		})
-
-		return
 	}
-
-	if list == nil {
-		list = []Driver{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
 }
 
-func (h *handler) GetCustomers(w http.ResponseWriter, r *http.Request) {
-
-	list, err := h.service.GetAllCustomers()
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
+// GetHistoryShipments: ?limit= mengaktifkan keyset pagination, ?cursor= dari next_cursor, ?withTotal=true untuk total.
+// Tanpa limit semua baris dikembalikan seperti sebelumnya. format=csv|xlsx mengunduh semua baris.
+func (h *handler) GetHistoryShipments(w http.ResponseWriter, r *http.Request) {
+	// 1. Ambil parameter dari query URL
+	from := r.URL.Query().Get("dateFrom")
+	to := r.URL.Query().Get("dateTo")
 
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get tnkbs",
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	if format != export.FormatJSON {
+		exportRows(w, r, format, "shipment-history", func(fn func(ShipmentHistory) error) error {
+			return h.service.ExportHistory(r.Context(), from, to, fn)
 		})
-
 		return
 	}
 
-	if list == nil {
-		list = []Customer{}
+	page, ok := keysetParams(w, r)
+	if !ok {
+		return
 	}
 
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
-}
-
-func (h *handler) GetTnkbs(w http.ResponseWriter, r *http.Request) {
-
-	list, err := h.service.GetTnkb()
+	// 2. Panggil service
+	result, err := h.service.GetHistory(r.Context(), from, to, page)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
 			r.URL.Path,
@@ -208,265 +245,232 @@ func (h *handler) GetTnkbs(w http.ResponseWriter, r *http.Request) {
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get tnkbs",
+			Message: "Failed to get shipment history",
 		})
-
 		return
 	}
 
+	// 3. Handle data kosong agar return array [] bukan null
+	list := result.Items
 	if list == nil {
-		list = []TNKB{}
+		list = []ShipmentHistory{}
 	}
 
 	countData := len(list)
 
+	// 4. Kirim response
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
+		Success:    true,
+		Message:    "OK",
+		Count:      countData,
+		Total:      result.Total,
+		NextCursor: result.NextCursor,
+		Data:       list,
 	})
 }
 
-func (h *handler) GetPendingShipments(w http.ResponseWriter, r *http.Request) {
+// GetShipmentProgress: parameter paging sama dengan GetHistoryShipments
+func (h *handler) GetShipmentProgress(w http.ResponseWriter, r *http.Request) {
 	from := r.URL.Query().Get("dateFrom")
 	to := r.URL.Query().Get("dateTo")
 
-	list, err := h.service.GetPending(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	if format != export.FormatJSON {
+		exportRows(w, r, format, "shipment-progress", func(fn func(ShipmentProgress) error) error {
+			return h.service.ExportProgress(r.Context(), from, to, fn)
 		})
-
 		return
 	}
 
-	if list == nil {
-		list = []Shipment{}
+	page, ok := keysetParams(w, r)
+	if !ok {
+		return
 	}
 
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
-}
-
-func (h *handler) GetPrepareShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-
-	list, err := h.service.GetPrepare(from, to)
+	result, err := h.service.FetchProgress(r.Context(), from, to, page)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
 			r.URL.Path,
 			r.Method,
 			err,
 		)
-
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get shipments",
+			Message: "Failed get progress shipment",
 		})
-
 		return
 	}
 
-	if list == nil {
-		list = []Shipment{}
+	data := result.Items
+	if data == nil {
+		data = []ShipmentProgress{}
 	}
 
-	countData := len(list)
+	countData := len(data)
 
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
+		Success:    true,
+		Message:    "OK",
+		Count:      countData,
+		Total:      result.Total,
+		NextCursor: result.NextCursor,
+		Data:       data,
 	})
 }
 
-func (h *handler) GetPrepareToLeaveShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+// GetTimeline: GET /shipments/{m_inout_id}/timeline, semua event STS satu SJ urut waktu
+func (h *handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "m_inout_id"), 10, 64)
+	if err != nil || id <= 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{Success: false, Message: "m_inout_id tidak valid"})
+		return
+	}
 
-	list, err := h.service.GetPrepareToLeave(from, to)
+	list, err := h.service.GetTimeline(r.Context(), id)
 	if err != nil {
+		if errors.Is(err, ErrTimelineNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
 			r.URL.Path,
 			r.Method,
 			err,
 		)
-
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get shipments",
+			Message: "Failed get shipment timeline",
 		})
-
 		return
 	}
 
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
 		Success: true,
 		Message: "OK",
-		Count:   countData,
+		Count:   len(list),
 		Data:    list,
 	})
 }
 
-func (h *handler) GetInTransitShipments(w http.ResponseWriter, r *http.Request) {
-	driverIDStr := r.URL.Query().Get("driverId")
-	driverID, _ := strconv.Atoi(driverIDStr)
-
-	list, err := h.service.GetInTransit(int64(driverID))
+// exportFormat membaca ?format= (json, csv, xlsx); menulis 400 jika tidak valid
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
 	if err != nil {
-		log.Printf(
-			"[SERVICESS] path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-		render.Status(r, http.StatusInternalServerError)
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
-		return
+		return "", false
 	}
-
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Data:    list,
-	})
+	return format, true
 }
 
-func (h *handler) GetOnCustomerShipments(w http.ResponseWriter, r *http.Request) {
-	customerIDStr := r.URL.Query().Get("customerId")
-	driverIDStr := r.URL.Query().Get("driverId")
-	customerID, _ := strconv.Atoi(customerIDStr)
-	driverID, _ := strconv.Atoi(driverIDStr)
-
-	list, err := h.service.GetOnCustomer(int64(customerID), int64(driverID))
-	if err != nil {
-		log.Printf(
-			"[SERVICESS] path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+// exportRows menulis hasil stream sebagai file CSV/XLSX dengan kolom = tag json T.
+// Header response baru dikirim saat baris pertama (atau saat selesai tanpa baris),
+// jadi error sebelum itu (stage/sort salah, query gagal) masih dibalas JSON.
+func exportRows[T any](w http.ResponseWriter, r *http.Request, format, name string, stream func(fn func(T) error) error) {
+	var zero T
+	var ew export.Writer
+	started := false
+
+	start := func() error {
+		started = true
+		export.SetHeaders(w, format, name)
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		ew, err = export.NewWriter(w, format, export.Columns(zero))
+		return err
+	}
+
+	err := stream(func(item T) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return ew.Write(export.Values(item))
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil {
 		return
 	}
 
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Data:    list,
-	})
-}
-
-func (h *handler) GetComebackShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+	log.Printf(
+		"[SERVICE]: path=%s method=%s error=%v",
+		r.URL.Path,
+		r.Method,
+		err,
+	)
 
-	list, err := h.service.GetComeback(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
-		return
+	if started {
+		// File sudah terkirim sebagian: putuskan koneksi supaya client tidak menganggap file lengkap
+		panic(http.ErrAbortHandler)
 	}
 
-	if list == nil {
-		list = []Shipment{}
+	if errors.Is(err, ErrUnknownStage) || errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrDriverRequired) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
+		return
 	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
+	render.Status(r, http.StatusInternalServerError)
 	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
+		Success: false,
+		Message: "Failed export " + name,
 	})
 }
 
-func (h *handler) GetComebackToDeliveryShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-
-	list, err := h.service.GetComebackToDelivery(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
-		return
-	}
-
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
+// keysetParams membaca limit, cursor dan withTotal; menulis 400 jika tidak valid
+func keysetParams(w http.ResponseWriter, r *http.Request) (KeysetPage, bool) {
+	q := r.URL.Query()
+	page := KeysetPage{Cursor: q.Get("cursor")}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{Success: false, Message: "limit tidak valid"})
+			return page, false
+		}
+		page.Limit = limit
+	}
+	if v := q.Get("withTotal"); v != "" {
+		withTotal, err := strconv.ParseBool(v)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, APIResponse{Success: false, Message: "withTotal tidak valid"})
+			return page, false
+		}
+		page.WithTotal = withTotal
+	}
+	return page, true
 }
 
-func (h *handler) GetReceiptComebackToDeliveryShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+func (h *handler) GetDrivers(w http.ResponseWriter, r *http.Request) {
 
-	list, err := h.service.GetReceiptComebackToDelivery(from, to)
+	list, err := h.service.GetDriver()
 	if err != nil {
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
@@ -478,14 +482,14 @@ func (h *handler) GetReceiptComebackToDeliveryShipments(w http.ResponseWriter, r
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get shipments",
+			Message: "Failed get drivers",
 		})
 
 		return
 	}
 
 	if list == nil {
-		list = []Shipment{}
+		list = []Driver{}
 	}
 
 	countData := len(list)
@@ -499,11 +503,9 @@ func (h *handler) GetReceiptComebackToDeliveryShipments(w http.ResponseWriter, r
 	})
 }
 
-func (h *handler) GetComebackToMarketingShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+func (h *handler) GetCustomers(w http.ResponseWriter, r *http.Request) {
 
-	list, err := h.service.GetComebackToMarketing(from, to)
+	list, err := h.service.GetAllCustomers()
 	if err != nil {
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
@@ -515,14 +517,14 @@ func (h *handler) GetComebackToMarketingShipments(w http.ResponseWriter, r *http
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get shipments",
+			Message: "Failed get tnkbs",
 		})
 
 		return
 	}
 
 	if list == nil {
-		list = []Shipment{}
+		list = []Customer{}
 	}
 
 	countData := len(list)
@@ -536,11 +538,9 @@ func (h *handler) GetComebackToMarketingShipments(w http.ResponseWriter, r *http
 	})
 }
 
-func (h *handler) GetReceiptComebackToMarketingShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+func (h *handler) GetTnkbs(w http.ResponseWriter, r *http.Request) {
 
-	list, err := h.service.GetReceiptComebackToMarketing(from, to)
+	list, err := h.service.GetTnkb()
 	if err != nil {
 		log.Printf(
 			"[SERVICE]: path=%s method=%s error=%v",
@@ -552,14 +552,14 @@ func (h *handler) GetReceiptComebackToMarketingShipments(w http.ResponseWriter,
 		render.Status(r, http.StatusInternalServerError)
 		render.JSON(w, r, APIResponse{
 			Success: false,
-			Message: "Failed get shipments",
+			Message: "Failed get tnkbs",
 		})
 
 		return
 	}
 
 	if list == nil {
-		list = []Shipment{}
+		list = []TNKB{}
 	}
 
 	countData := len(list)
@@ -573,76 +573,50 @@ func (h *handler) GetReceiptComebackToMarketingShipments(w http.ResponseWriter,
 	})
 }
 
-func (h *handler) GetComebackToFatShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+func (h *handler) GetInTransitShipments(w http.ResponseWriter, r *http.Request) {
+	driverIDStr := r.URL.Query().Get("driverId")
+	driverID, _ := strconv.Atoi(driverIDStr)
 
-	list, err := h.service.GetComebackToFat(from, to)
+	list, err := h.service.GetInTransit(r.Context(), int64(driverID))
 	if err != nil {
 		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
+			"[SERVICESS] path=%s method=%s error=%v",
 			r.URL.Path,
 			r.Method,
 			err,
 		)
-
 		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
+		render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
 		return
 	}
 
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
 		Success: true,
-		Message: "OK",
-		Count:   countData,
 		Data:    list,
 	})
 }
 
-func (h *handler) GetReceiptComebackToFatShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
+func (h *handler) GetOnCustomerShipments(w http.ResponseWriter, r *http.Request) {
+	customerIDStr := r.URL.Query().Get("customerId")
+	driverIDStr := r.URL.Query().Get("driverId")
+	customerID, _ := strconv.Atoi(customerIDStr)
+	driverID, _ := strconv.Atoi(driverIDStr)
 
-	list, err := h.service.GetReceiptComebackToFat(from, to)
+	list, err := h.service.GetOnCustomer(int64(customerID), int64(driverID))
 	if err != nil {
 		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
+			"[SERVICESS] path=%s method=%s error=%v",
 			r.URL.Path,
 			r.Method,
 			err,
 		)
-
 		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
+		render.JSON(w, r, APIResponse{Success: false, Message: err.Error()})
 		return
 	}
 
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
 	render.JSON(w, r, APIResponse{
 		Success: true,
-		Message: "OK",
-		Count:   countData,
 		Data:    list,
 	})
 }
@@ -690,80 +664,6 @@ func (h *handler) HandleEditShipment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *handler) GetOutstandingDPKShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-
-	list, err := h.service.GetOutstandingDPK(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
-		return
-	}
-
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
-}
-
-func (h *handler) GetOutstandingDeliveryShipments(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("dateFrom")
-	to := r.URL.Query().Get("dateTo")
-
-	list, err := h.service.GetOutstandingDelivery(from, to)
-	if err != nil {
-		log.Printf(
-			"[SERVICE]: path=%s method=%s error=%v",
-			r.URL.Path,
-			r.Method,
-			err,
-		)
-
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, APIResponse{
-			Success: false,
-			Message: "Failed get shipments",
-		})
-
-		return
-	}
-
-	if list == nil {
-		list = []Shipment{}
-	}
-
-	countData := len(list)
-
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, APIResponse{
-		Success: true,
-		Message: "OK",
-		Count:   countData,
-		Data:    list,
-	})
-}
-
 func (h *handler) CancelOutstanding(w http.ResponseWriter, r *http.Request) {
 	data := &CancelOutstandingRequest{}
 
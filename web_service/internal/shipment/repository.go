@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,29 +15,25 @@ type Repository interface {
 	GetAllCustomers() ([]Customer, error)
 	GetDriver() ([]Driver, error)
 	GetTnkb() ([]TNKB, error)
-	GetPending(from, to time.Time) ([]Shipment, error)
-	GetPrepare(from, to time.Time) ([]Shipment, error)
-	GetPrepareToLeave(from, to time.Time) ([]Shipment, error)
-	GetInTransitCustomer(from, to time.Time, driverID int64) ([]Shipment, error)
-	GetOnCustomer(from, to time.Time, customerID, driverID int64) ([]Shipment, error)
-
-	GetComeback(from, to time.Time) ([]Shipment, error)
-	GetComebackToDelivery(from, to time.Time) ([]Shipment, error)
-	GetReceiptComebackToDelivery(from, to time.Time) ([]Shipment, error)
 
-	GetComebackToMarketing(from, to time.Time) ([]Shipment, error)
-	GetReceiptComebackToMarketing(from, to time.Time) ([]Shipment, error)
+	// Semua stage dashboard memakai satu query dengan filter
+	ListShipments(ctx context.Context, f ShipmentFilter) ([]Shipment, int, error)
+	GetOnCustomer(from, to time.Time, customerID, driverID int64) ([]Shipment, error)
 
-	GetComebackToFat(from, to time.Time) ([]Shipment, error)
-	GetReceiptComebackToFat(from, to time.Time) ([]Shipment, error)
+	//Progress Shipment (keyset pagination, cursor halaman berikutnya dikembalikan)
+	GetDailyProgress(ctx context.Context, from, to time.Time, page KeysetPage) ([]ShipmentProgress, string, error)
+	CountDailyProgress(ctx context.Context, from, to time.Time) (int, error)
 
-	//Progress Shipment
-	GetDailyProgress(ctx context.Context, from, to time.Time) ([]ShipmentProgress, error)
+	GetHistory(ctx context.Context, from, to time.Time, page KeysetPage) ([]ShipmentHistory, string, error)
+	CountHistory(ctx context.Context, from, to time.Time) (int, error)
 
-	GetHistory(from, to time.Time) ([]ShipmentHistory, error)
+	// Export: baris dikirim satu per satu ke fn langsung dari cursor DB
+	StreamShipments(ctx context.Context, f ShipmentFilter, fn func(Shipment) error) error
+	StreamDailyProgress(ctx context.Context, from, to time.Time, fn func(ShipmentProgress) error) error
+	StreamHistory(ctx context.Context, from, to time.Time, fn func(ShipmentHistory) error) error
 
-	GetOutstandingDPK(from, to time.Time) ([]Shipment, error)
-	GetOutstandingDelivery(from, to time.Time) ([]Shipment, error)
+	GetByDocumentNo(ctx context.Context, documentNo string) ([]Shipment, error)
+	GetTimeline(ctx context.Context, mInOutID int64) ([]TimelineEntry, error)
 
 	UpdateDriverTnkb(ctx context.Context, inoutID int64, driverID int64, tnkbID int64) error
 
@@ -175,9 +173,10 @@ func (r *oraRepo) ExecuteOutstandingCancel(ctx context.Context, inoutID int64, n
 	return tx.Commit()
 }
 
-func (r *oraRepo) GetDailyProgress(ctx context.Context, from, to time.Time) ([]ShipmentProgress, error) {
-	query := `
+// progressBase adalah rekap per SJ (tanpa ORDER BY); :1 dan :2 = rentang MovementDate
+const progressBase = `
     SELECT 
+        io.M_INOUT_ID,
         io.DOCUMENTNO, 
 		CASE
 			WHEN io.ADW_TMS_ID IS NOT NULL THEN 'Y'
@@ -234,20 +233,94 @@ func (r *oraRepo) GetDailyProgress(ctx context.Context, from, to time.Time) ([]S
 		AND cb.ISSUBCONTRACT = 'N'
 		AND co.ISMILKRUN = 'N'
 		-- AND t.ISMILKRUN = 'N'
-    GROUP BY io.DOCUMENTNO, io.ADW_TMS_ID, cb.VALUE, io.MOVEMENTDATE, au.NAME, au2.NAME, t.DRIVER_NAME, att.NAME, t.TNKB 
-    ORDER BY (DELIVERY + ONDPK + ONDRIVER + ONCUSTOMER + OUTCUSTOMER + 
-              COMEBACKDPK + COMEBACKDEL + COMEBACKMKT + COMEBACKFAT) DESC, DOCUMENTNO ASC`
+    GROUP BY io.M_INOUT_ID, io.DOCUMENTNO, io.ADW_TMS_ID, cb.VALUE, io.MOVEMENTDATE, au.NAME, au2.NAME, t.DRIVER_NAME, att.NAME, t.TNKB`
+
+// progressKeys: urutan progress (skor tertinggi dulu) ditambah kolom unik supaya stabil antar halaman
+var progressKeys = []keyCol{
+	{Col: "SCORE", Desc: true},
+	{Col: "DOCUMENTNO"},
+	{Col: "M_INOUT_ID"},
+	{Col: "DRIVER"},
+	{Col: "TNKB"},
+}
+
+// GetDailyProgress mengambil rekap progress per SJ dengan keyset pagination.
+// Mengembalikan cursor halaman berikutnya ("" jika sudah habis).
+func (r *oraRepo) GetDailyProgress(ctx context.Context, from, to time.Time, page KeysetPage) ([]ShipmentProgress, string, error) {
+	args := []interface{}{from, to}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+
+	where := ""
+	if page.Cursor != "" {
+		var c progressCursor
+		if err := decodeCursor(page.Cursor, &c); err != nil {
+			return nil, "", err
+		}
+		where = "WHERE " + keysetAfter(progressKeys, []interface{}{c.Score, c.DocumentNo, c.MInOutID, c.Driver, c.TNKB}, bind)
+	}
+
+	query := limitRows(progressQuery(where), page.Limit, bind)
 
 	var results []ShipmentProgress
-	err := r.db.SelectContext(ctx, &results, query, from, to)
-	return results, err
+	if err := r.db.SelectContext(ctx, &results, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if page.Limit > 0 && len(results) > page.Limit {
+		results = results[:page.Limit]
+		last := results[len(results)-1]
+		next = encodeCursor(progressCursor{
+			Score:      last.Score,
+			DocumentNo: last.DocumentNo,
+			MInOutID:   last.MInOutID,
+			Driver:     last.Driver,
+			TNKB:       last.TNKB,
+		})
+	}
+	return results, next, nil
 }
 
-func (r *oraRepo) GetHistory(from, to time.Time) ([]ShipmentHistory, error) {
-	var list []ShipmentHistory
+// StreamDailyProgress mengirim semua baris progress (tanpa paging) dengan urutan yang sama
+func (r *oraRepo) StreamDailyProgress(ctx context.Context, from, to time.Time, fn func(ShipmentProgress) error) error {
+	return streamRows(ctx, r.db, progressQuery(""), []interface{}{from, to}, fn)
+}
 
-	// Query melakukan join ke bundle_line dan bundle untuk mendapatkan PDF
+// progressQuery: progressBase + SCORE, diurutkan sesuai progressKeys; where = predikat cursor (opsional)
+func progressQuery(where string) string {
 	query := `
+		SELECT p.*,
+			(DELIVERY + ONDPK + ONDRIVER + ONCUSTOMER + OUTCUSTOMER + 
+			 COMEBACKDPK + COMEBACKDEL + COMEBACKMKT + COMEBACKFAT) AS SCORE
+		FROM (` + progressBase + `
+		) p`
+	return `
+		SELECT * FROM (` + query + `
+		) ` + where + `
+		ORDER BY ` + orderBy(progressKeys)
+}
+
+func (r *oraRepo) CountDailyProgress(ctx context.Context, from, to time.Time) (int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+progressBase+`)`, from, to)
+	return total, err
+}
+
+// limitRows membatasi hasil query berurutan ke limit+1 baris (baris ekstra = penanda ada halaman berikutnya)
+func limitRows(query string, limit int, bind func(interface{}) string) string {
+	if limit <= 0 {
+		return query
+	}
+	return `
+		SELECT * FROM (` + query + `
+		) WHERE ROWNUM <= ` + bind(limit+1)
+}
+
+// historyBase: satu baris per SJ per bundle; :1 dan :2 = rentang MovementDate
+const historyBase = `
         SELECT 
             mi.M_InOut_ID, 
             mi.DocumentNo, 
@@ -256,7 +329,10 @@ func (r *oraRepo) GetHistory(from, to time.Time) ([]ShipmentHistory, error) {
             NVL(au.NAME, '-') Driver,
             sts.STATUS,
             asb.DOCUMENTNO as BundleNo,
-            asb.ATTACHMENT
+            asb.ATTACHMENT,
+            -- SJ tanpa bundle tampil paling atas (sama seperti CREATED DESC NULLS FIRST)
+            NVL(asb.CREATED, DATE '9999-12-31') AS BundleCreated,
+            NVL(asbl.ADW_STS_BUNDLE_LINE_ID, 0) AS BundleLineID
         FROM ADW_STS sts
         JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
         JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
@@ -270,16 +346,72 @@ func (r *oraRepo) GetHistory(from, to time.Time) ([]ShipmentHistory, error) {
 				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
 				FROM ADW_STS_SETTING 
 				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-        ORDER BY asb.CREATED DESC, mi.movementdate ASC
-    `
+			)`
+
+// historyKeys: bundle terbaru dulu, lalu tanggal SJ; M_InOut_ID & bundle line membuat urutan unik
+var historyKeys = []keyCol{
+	{Col: "BundleCreated", Desc: true},
+	{Col: "MovementDate"},
+	{Col: "M_InOut_ID"},
+	{Col: "BundleLineID"},
+}
 
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
+// GetHistory mengambil riwayat SJ + PDF bundle dengan keyset pagination.
+// Mengembalikan cursor halaman berikutnya ("" jika sudah habis).
+func (r *oraRepo) GetHistory(ctx context.Context, from, to time.Time, page KeysetPage) ([]ShipmentHistory, string, error) {
+	args := []interface{}{from, to}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
 	}
 
-	return list, nil
+	where := ""
+	if page.Cursor != "" {
+		var c historyCursor
+		if err := decodeCursor(page.Cursor, &c); err != nil {
+			return nil, "", err
+		}
+		where = "WHERE " + keysetAfter(historyKeys, []interface{}{c.BundleCreated, c.MovementDate, c.MInOutID, c.BundleLineID}, bind)
+	}
+
+	query := limitRows(historyQuery(where), page.Limit, bind)
+
+	var list []ShipmentHistory
+	if err := r.db.SelectContext(ctx, &list, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if page.Limit > 0 && len(list) > page.Limit {
+		list = list[:page.Limit]
+		last := list[len(list)-1]
+		next = encodeCursor(historyCursor{
+			BundleCreated: last.BundleCreated,
+			MovementDate:  last.MovementDate,
+			MInOutID:      last.M_InOut_ID,
+			BundleLineID:  last.BundleLineID,
+		})
+	}
+	return list, next, nil
+}
+
+// StreamHistory mengirim semua baris riwayat (tanpa paging) dengan urutan yang sama
+func (r *oraRepo) StreamHistory(ctx context.Context, from, to time.Time, fn func(ShipmentHistory) error) error {
+	return streamRows(ctx, r.db, historyQuery(""), []interface{}{from, to}, fn)
+}
+
+// historyQuery: historyBase diurutkan sesuai historyKeys; where = predikat cursor (opsional)
+func historyQuery(where string) string {
+	return `
+		SELECT * FROM (` + historyBase + `
+		) ` + where + `
+		ORDER BY ` + orderBy(historyKeys)
+}
+
+func (r *oraRepo) CountHistory(ctx context.Context, from, to time.Time) (int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+historyBase+`)`, from, to)
+	return total, err
 }
 
 func (r *oraRepo) GetAllCustomers() ([]Customer, error) {
@@ -347,209 +479,171 @@ func (r *oraRepo) GetTnkb() ([]TNKB, error) {
 	return list, nil
 }
 
-// For Delivery
-func (r *oraRepo) GetPending(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO,
-			mi.ADW_TMS_ID
-		FROM M_InOut mi
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN ADW_STS sts ON mi.M_INOUT_ID = sts.M_INOUT_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
-		  -- AND mi.ADW_TMS_ID IS NULL
-  		  -- AND mi.C_INVOICE_ID IS NULL
-		  AND mi.AD_Client_ID = 1000000
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'N'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			mi.ADW_TMS_ID ASC NULLS FIRST,
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
+// ListShipments adalah query tunggal untuk semua stage dashboard. Baris ADW_STS ganda per SJ
+// diambil yang terbaru; PageSize 0 berarti tanpa paging (dipakai route lama).
+func (r *oraRepo) ListShipments(ctx context.Context, f ShipmentFilter) ([]Shipment, int, error) {
+	query, args, err := shipmentsQuery(f)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return list, nil
-}
-
-func (r *oraRepo) GetPrepare(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
+	var rows []shipmentRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("gagal ambil daftar SJ: %w", err)
+	}
 
-	// 	query := `
-	// 		SELECT
-	// 			mi.M_InOut_ID,
-	// 			mi.DocumentNo,
-	// 			mi.MovementDate,
-	// 			cb.Value Customer,
-	// 			au.NAME Driver,
-	// 			att.NAME TNKBNO
-	// 		FROM ADW_STS sts
-	// 		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID
-	// 		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID
-	// 		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID
-	// 		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID
-	// 		WHERE mi.movementdate >= :1
-	// 		  AND mi.movementdate < :2
-	// --		  AND mi.ADW_TMS_ID IS NULL
-	// --  		  AND mi.C_INVOICE_ID IS NULL
-	// 		  AND mi.IsSoTrx = 'Y'
-	// 		  AND mi.INSTS = 'Y'
-	// 		  AND sts.STATUS = 'HO: DEL_TO_DPK'
-	// 		  AND mi.MOVEMENTDATE >= (
-	// 				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD'))
-	// 				FROM ADW_STS_SETTING
-	// 				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-	// 			)
-	// 		ORDER BY
-	// 			movementdate ASC
-	// 	`
+	list := make([]Shipment, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		list = append(list, row.Shipment)
+		total = row.Total
+	}
+	return list, total, nil
+}
 
-	query := `
-	SELECT 
-    M_InOut_ID, 
-    DocumentNo, 
-    MovementDate, 
-    Customer, 
-    Driver, 
-    TNKBNO
-FROM (
-    SELECT 
-        mi.M_InOut_ID, 
-        mi.DocumentNo, 
-        mi.MovementDate,
-        cb.Value AS Customer,
-        au.NAME AS Driver,
-        att.NAME AS TNKBNO,
-        -- Memberi nomor baris per DocumentNo, diurutkan dari ID status terbaru
-        ROW_NUMBER() OVER (PARTITION BY mi.M_InOut_ID ORDER BY sts.ADW_STS_ID DESC) as rn
-    FROM ADW_STS sts
-    JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-    JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-    LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-    LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-    WHERE mi.movementdate >= :1
-	  AND mi.movementdate < :2
-      AND mi.IsSoTrx = 'Y'
-      AND mi.INSTS = 'Y'
-      AND sts.STATUS = 'HO: DEL_TO_DPK'
-      AND mi.MOVEMENTDATE >= (
-            SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-            FROM ADW_STS_SETTING 
-            WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-        )
-) 
-WHERE rn = 1 -- Hanya ambil baris pertama (terbaru) untuk setiap M_InOut_ID
-ORDER BY MovementDate ASC
-`
-
-	err := r.db.Select(&list, query, from, to)
+// StreamShipments memakai query ListShipments (filter & urutan sama); PageSize 0 = semua baris
+func (r *oraRepo) StreamShipments(ctx context.Context, f ShipmentFilter, fn func(Shipment) error) error {
+	query, args, err := shipmentsQuery(f)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	return list, nil
+	return streamRows(ctx, r.db, query, args, func(row shipmentRow) error {
+		return fn(row.Shipment)
+	})
 }
 
-func (r *oraRepo) GetPrepareToLeave(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'RE: DPK_FROM_DEL'
-		  -- AND sts.STATUS = 'RE: DPK_FROM_DEL'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
+// shipmentRow = baris ListShipments beserta total seluruh hasil filter
+type shipmentRow struct {
+	Shipment
+	Total int `db:"TOTAL_COUNT"`
+}
 
-	err := r.db.Select(&list, query, from, to)
+func shipmentsQuery(f ShipmentFilter) (string, []interface{}, error) {
+	def := stages[f.Stage]
+	order, err := orderClause(f.Sort, def)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	return list, nil
-}
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return ":" + strconv.Itoa(len(args))
+	}
+	bindList := func(values []string) string {
+		binds := make([]string, len(values))
+		for i, v := range values {
+			binds[i] = bind(v)
+		}
+		return strings.Join(binds, ", ")
+	}
 
-func (r *oraRepo) GetInTransitCustomer(from, to time.Time, driverID int64) ([]Shipment, error) {
-	var list []Shipment
+	where := []string{
+		"mi.MovementDate >= " + bind(f.From),
+		"mi.MovementDate < " + bind(f.To),
+		"mi.IsSoTrx = 'Y'",
+		`mi.MOVEMENTDATE >= (
+					SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
+					FROM ADW_STS_SETTING 
+					WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
+				  )`,
+	}
+	if def.Pending {
+		where = append(where, "mi.AD_Client_ID = 1000000", "mi.INSTS = 'N'")
+	} else {
+		where = append(where, "mi.INSTS = 'Y'", "sts.ADW_STS_ID IS NOT NULL")
+	}
+	if len(def.Statuses) > 0 {
+		op := "IN"
+		if def.Exclude {
+			op = "NOT IN"
+		}
+		where = append(where, "sts.STATUS "+op+" ("+bindList(def.Statuses)+")")
+	}
+	if def.NeedSPP {
+		where = append(where, "mi.SPPNO IS NOT NULL")
+	}
+	if len(f.Statuses) > 0 {
+		where = append(where, "sts.STATUS IN ("+bindList(f.Statuses)+")")
+	}
+	if f.CustomerID > 0 {
+		where = append(where, "mi.C_BPartner_ID = "+bind(f.CustomerID))
+	}
+	if f.DriverID > 0 {
+		where = append(where, "sts.DRIVERBY = "+bind(f.DriverID))
+	}
+	if f.TNKBID > 0 {
+		where = append(where, "sts.TNKB_ID = "+bind(f.TNKBID))
+	}
+	if f.TMSMatched != nil {
+		if *f.TMSMatched {
+			where = append(where, "mi.ADW_TMS_ID IS NOT NULL")
+		} else {
+			where = append(where, "mi.ADW_TMS_ID IS NULL")
+		}
+	}
+
+	paging := ""
+	if f.PageSize > 0 {
+		offset := (f.Page - 1) * f.PageSize
+		paging = "WHERE RNUM > " + bind(offset) + " AND RNUM <= " + bind(offset+f.PageSize)
+	}
 
 	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			cb.C_BPartner_ID CustomerID,
-			au.NAME Driver,
-			att.ADW_TMS_TNKB_ID TNKBID,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'HO: DPK_TO_DRIVER'
-		  AND sts.DRIVERBY = :3
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
+		SELECT M_InOut_ID, DocumentNo, MovementDate, Customer, CustomerID, Status,
+			Driver, TNKBID, TNKBNO, SPPNO, ADW_TMS_ID, TOTAL_COUNT
+		FROM (
+			SELECT x.*,
+				ROW_NUMBER() OVER (ORDER BY ` + order + `) AS RNUM,
+				COUNT(*) OVER () AS TOTAL_COUNT
+			FROM (
+				SELECT 
+					mi.M_InOut_ID, 
+					mi.DocumentNo, 
+					mi.MovementDate,
+					cb.Value AS Customer,
+					cb.C_BPartner_ID AS CustomerID,
+					sts.Status,
+					au.NAME AS Driver,
+					att.ADW_TMS_TNKB_ID AS TNKBID,
+					att.NAME AS TNKBNO,
+					mi.SPPNO,
+					mi.ADW_TMS_ID,
+					-- SJ dengan lebih dari satu baris ADW_STS: ambil yang terbaru
+					ROW_NUMBER() OVER (PARTITION BY mi.M_InOut_ID ORDER BY sts.ADW_STS_ID DESC) AS DUP
+				FROM M_InOut mi
+				JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
+				LEFT JOIN ADW_STS sts ON mi.M_INOUT_ID = sts.M_INOUT_ID 
+				LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
+				LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
+				WHERE ` + strings.Join(where, "\n\t\t\t\t  AND ") + `
+			) x
+			WHERE DUP = 1
+		)
+		` + paging + `
+		ORDER BY RNUM`
 
-	err := r.db.Select(&list, query, from, to, driverID)
+	return query, args, nil
+}
+
+// streamRows membaca hasil query baris per baris (tanpa menampung semuanya) dan memanggil fn untuk tiap baris
+func streamRows[T any](ctx context.Context, db *sqlx.DB, query string, args []interface{}, fn func(T) error) error {
+	rows, err := db.QueryxContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rows.Close()
 
-	return list, nil
+	for rows.Next() {
+		var item T
+		if err := rows.StructScan(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 func (r *oraRepo) GetOnCustomer(from, to time.Time, customerID, driverID int64) ([]Shipment, error) {
@@ -617,295 +711,9 @@ func (r *oraRepo) GetOnCustomer(from, to time.Time, customerID, driverID int64)
 	return list, nil
 }
 
-func (r *oraRepo) GetComeback(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  -- AND mi.ADW_TMS_ID IS NULL
---  	  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS IN ('HO: DPK_TO_DRIVER', 'HO: DRIVER_CHECKIN', 'HO: DRIVER_CHECKOUT')
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetComebackToDelivery(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'RE: DPK_FROM_DRIVER'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetReceiptComebackToDelivery(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'HO: DPK_TO_DEL'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetComebackToMarketing(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'RE: DEL_FROM_DPK'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetReceiptComebackToMarketing(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT DISTINCT
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			mi.SPPNO,
-			sts.Status
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  -- AND sts.STATUS IN ('HO: DEL_TO_MKT', 'HO: DRIVER_CHECKIN', 'HO: DRIVER_CHECKOUT', 'HO: DEL_TO_DPK')
-		  AND sts.STATUS = 'HO: DEL_TO_MKT'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY 
-			-- 1. Prioritaskan yang SPPNO tidak null dan tidak kosong
-			CASE 
-				WHEN mi.SPPNO IS NOT NULL AND mi.SPPNO <> ' ' THEN 0 
-				ELSE 1 
-			END ASC,
-			-- 2. Baru kemudian urutkan berdasarkan tanggal
-			mi.MovementDate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetComebackToFat(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			mi.SPPNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'RE: MKT_FROM_DEL'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		  AND mi.SPPNO IS NOT NULL
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetReceiptComebackToFat(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
-
-	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'HO: MKT_TO_FAT'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
-	`
-
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-func (r *oraRepo) GetOutstandingDPK(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
+// GetByDocumentNo mencari surat jalan (M_InOut.DocumentNo) beserta status STS terakhirnya
+func (r *oraRepo) GetByDocumentNo(ctx context.Context, documentNo string) ([]Shipment, error) {
+	list := []Shipment{}
 
 	query := `
 		SELECT 
@@ -915,29 +723,20 @@ func (r *oraRepo) GetOutstandingDPK(from, to time.Time) ([]Shipment, error) {
 			cb.Value Customer,
 			au.NAME Driver,
 			att.NAME TNKBNO,
-			sts.Status
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
+			sts.Status,
+			mi.ADW_TMS_ID
+		FROM M_InOut mi
 		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
+		LEFT JOIN ADW_STS sts ON mi.M_INOUT_ID = sts.M_INOUT_ID 
 		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
 		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
+		WHERE UPPER(mi.DocumentNo) = UPPER(:1)
+		  AND mi.AD_Client_ID = 1000000
 		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS = 'HO: DPK_TO_DRIVER'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
+		ORDER BY mi.MovementDate DESC
 	`
 
-	err := r.db.Select(&list, query, from, to)
+	err := r.db.SelectContext(ctx, &list, query, documentNo)
 	if err != nil {
 		return nil, err
 	}
@@ -945,41 +744,65 @@ func (r *oraRepo) GetOutstandingDPK(from, to time.Time) ([]Shipment, error) {
 	return list, nil
 }
 
-func (r *oraRepo) GetOutstandingDelivery(from, to time.Time) ([]Shipment, error) {
-	var list []Shipment
+// GetTimeline mengambil semua ADW_STS_EVENT satu SJ urut waktu.
+// Bundle dicocokkan lewat bundle line dengan BUNDLE_TYPE = EVENTTYPE yang dibuat dalam 1 menit dari event
+// (satu request handover membuat event & bundle sekaligus); jika lebih dari satu, ambil yang paling dekat.
+func (r *oraRepo) GetTimeline(ctx context.Context, mInOutID int64) ([]TimelineEntry, error) {
+	list := []TimelineEntry{}
 
 	query := `
-		SELECT 
-			mi.M_InOut_ID, 
-			mi.DocumentNo, 
-			mi.MovementDate,
-			cb.Value Customer,
-			au.NAME Driver,
-			att.NAME TNKBNO,
-			sts.Status
-		FROM ADW_STS sts
-		JOIN M_InOut mi ON sts.M_INOUT_ID = mi.M_INOUT_ID  
-		JOIN C_BPartner cb ON mi.C_BPartner_ID = cb.C_BPartner_ID 
-		LEFT JOIN AD_USER au ON sts.DRIVERBY = au.AD_USER_ID 
-		LEFT JOIN ADW_TMS_TNKB att ON att.ADW_TMS_TNKB_ID = sts.TNKB_ID 
-		WHERE mi.movementdate >= :1
-		  AND mi.movementdate < :2
---		  AND mi.ADW_TMS_ID IS NULL
---  		  AND mi.C_INVOICE_ID IS NULL
-		  AND mi.IsSoTrx = 'Y'
-		  AND mi.INSTS = 'Y'
-		  AND sts.STATUS <> 'RE: DEL_FROM_DPK'
-		  AND mi.MOVEMENTDATE >= (
-				SELECT NVL(MAX(DATE_VALUE), TO_DATE('2026-02-01', 'YYYY-MM-DD')) 
-				FROM ADW_STS_SETTING 
-				WHERE SETTING_KEY = 'GLOBAL_CUTOFF_DATE'
-			)
-		ORDER BY
-			movementdate ASC
+		SELECT
+			ADW_STS_EVENT_ID, ADW_STS_ID, EVENTTYPE,
+			PREVACTOR, PREVACTORNAME, CURRENTACTOR, CURRENTACTORNAME,
+			DRIVERBY, DRIVERNAME, TNKB_ID, TNKBNO, CURRENTCUSTOMER, CUSTOMERNAME,
+			NOTES, PREVCREATED, CREATED,
+			-- Dihitung setelah duplikat bundle dibuang (analytic dievaluasi sesudah WHERE)
+			LAG(CREATED) OVER (ORDER BY CREATED, ADW_STS_EVENT_ID) AS PREVEVENTCREATED,
+			ADW_STS_BUNDLE_ID, BUNDLENO, ATTACHMENT
+		FROM (
+			SELECT
+				evt.ADW_STS_EVENT_ID,
+				evt.ADW_STS_ID,
+				evt.EVENTTYPE,
+				evt.PREVACTOR,
+				prev_user.NAME AS PREVACTORNAME,
+				evt.CURRENTACTOR,
+				curr_user.NAME AS CURRENTACTORNAME,
+				evt.DRIVERBY,
+				drv.NAME AS DRIVERNAME,
+				evt.TNKB_ID,
+				att.NAME AS TNKBNO,
+				evt.CURRENTCUSTOMER,
+				cb.Value AS CUSTOMERNAME,
+				evt.NOTES,
+				evt.PREVCREATED,
+				evt.CREATED,
+				bnd.ADW_STS_BUNDLE_ID,
+				bnd.DOCUMENTNO AS BUNDLENO,
+				bnd.ATTACHMENT,
+				ROW_NUMBER() OVER (
+					PARTITION BY evt.ADW_STS_EVENT_ID
+					ORDER BY ABS(bnd.CREATED - evt.CREATED) ASC NULLS LAST, bnd.ADW_STS_BUNDLE_ID DESC
+				) AS RN
+			FROM ADW_STS sts
+			JOIN ADW_STS_EVENT evt ON evt.ADW_STS_ID = sts.ADW_STS_ID
+			LEFT JOIN AD_USER prev_user ON evt.PREVACTOR = prev_user.AD_USER_ID
+			LEFT JOIN AD_USER curr_user ON evt.CURRENTACTOR = curr_user.AD_USER_ID
+			LEFT JOIN AD_USER drv ON evt.DRIVERBY = drv.AD_USER_ID
+			LEFT JOIN ADW_TMS_TNKB att ON evt.TNKB_ID = att.ADW_TMS_TNKB_ID
+			LEFT JOIN C_BPartner cb ON evt.CURRENTCUSTOMER = cb.C_BPartner_ID
+			LEFT JOIN ADW_STS_BUNDLE_LINE bln ON bln.ADW_STS_ID = evt.ADW_STS_ID
+			LEFT JOIN ADW_STS_BUNDLE bnd ON bnd.ADW_STS_BUNDLE_ID = bln.ADW_STS_BUNDLE_ID
+				AND bnd.BUNDLE_TYPE = evt.EVENTTYPE
+				AND bnd.CREATED BETWEEN evt.CREATED - 1/1440 AND evt.CREATED + 1/1440
+			WHERE sts.M_INOUT_ID = :1
+			  AND evt.ISACTIVE = 'Y'
+		)
+		WHERE RN = 1
+		ORDER BY CREATED ASC, ADW_STS_EVENT_ID ASC
 	`
 
-	err := r.db.Select(&list, query, from, to)
-	if err != nil {
+	if err := r.db.SelectContext(ctx, &list, query, mInOutID); err != nil {
 		return nil, err
 	}
 
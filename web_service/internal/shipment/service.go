@@ -2,36 +2,41 @@ package shipment
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+	"sts/web_service/internal/shared"
+	"sts/web_service/internal/shared/config"
+	"sts/web_service/internal/shared/storage"
 	"time"
 )
 
+var ErrTimelineNotFound = errors.New("belum ada event STS untuk SJ ini")
+
 type Service interface {
 	GetAllCustomers() ([]Customer, error)
 	GetDriver() ([]Driver, error)
 	GetTnkb() ([]TNKB, error)
-	GetPending(fromStr, toStr string) ([]Shipment, error)
-	GetPrepare(fromStr, toStr string) ([]Shipment, error)
-	GetPrepareToLeave(fromStr, toStr string) ([]Shipment, error)
-	GetInTransit(driverID int64) ([]Shipment, error)
-	GetOnCustomer(customerID, driverID int64) ([]Shipment, error)
-
-	GetComeback(fromStr, toStr string) ([]Shipment, error)
-	GetComebackToDelivery(fromStr, toStr string) ([]Shipment, error)
-	GetReceiptComebackToDelivery(fromStr, toStr string) ([]Shipment, error)
 
-	GetComebackToMarketing(fromStr, toStr string) ([]Shipment, error)
-	GetReceiptComebackToMarketing(fromStr, toStr string) ([]Shipment, error)
+	// ListShipments untuk GET /shipments (filter + paging)
+	ListShipments(ctx context.Context, fromStr, toStr string, f ShipmentFilter) (*ShipmentPage, error)
+	// ListStage mengembalikan semua SJ satu stage, dipakai route lama (/pending, /prepare, ...)
+	ListStage(ctx context.Context, stage, fromStr, toStr string) ([]Shipment, error)
+	GetInTransit(ctx context.Context, driverID int64) ([]Shipment, error)
+	GetOnCustomer(customerID, driverID int64) ([]Shipment, error)
 
-	GetComebackToFat(fromStr, toStr string) ([]Shipment, error)
-	GetReceiptComebackToFat(fromStr, toStr string) ([]Shipment, error)
+	FetchProgress(ctx context.Context, fromStr, toStr string, page KeysetPage) (*ProgressPage, error)
+	GetHistory(ctx context.Context, fromStr, toStr string, page KeysetPage) (*HistoryPage, error)
 
-	FetchProgress(ctx context.Context, fromStr, toStr string) ([]ShipmentProgress, error)
-	GetHistory(fromStr, toStr string) ([]ShipmentHistory, error)
+	// Export CSV/XLSX: semua baris sesuai filter dikirim ke fn satu per satu (tanpa paging)
+	ExportShipments(ctx context.Context, fromStr, toStr string, f ShipmentFilter, fn func(Shipment) error) error
+	ExportProgress(ctx context.Context, fromStr, toStr string, fn func(ShipmentProgress) error) error
+	ExportHistory(ctx context.Context, fromStr, toStr string, fn func(ShipmentHistory) error) error
 
-	GetOutstandingDPK(fromStr, toStr string) ([]Shipment, error)
-	GetOutstandingDelivery(fromStr, toStr string) ([]Shipment, error)
+	FindByDocumentNo(ctx context.Context, documentNo string) ([]Shipment, error)
+	GetTimeline(ctx context.Context, mInOutID int64) ([]TimelineEntry, error)
 
 	UpdateDriverTnkb(ctx context.Context, inoutID int64, driverID int64, tnkbID int64) error
 
@@ -42,46 +47,11 @@ type Service interface {
 
 type service struct {
 	repo Repository
+	cfg  *config.Config
 }
 
-func NewService(r Repository) Service {
-	return &service{repo: r}
-}
-
-// parseDateRange mengolah input string menjadi range waktu yang valid.
-// Default From: Tanggal 1 bulan ini jam 00:00:00
-// Default To: From + 1 bulan (jika to kosong)
-func parseDateRange(fromStr, toStr string) (time.Time, time.Time) {
-	now := time.Now()
-	loc := now.Location()
-
-	var dateFrom, dateTo time.Time
-
-	// 1. Logika Parsing FROM
-	parsedFrom, err := time.Parse("2006-01-02", fromStr)
-	if err == nil {
-		dateFrom = time.Date(parsedFrom.Year(), parsedFrom.Month(), parsedFrom.Day(), 0, 0, 0, 0, loc)
-	} else {
-		// Default: Tanggal 1 bulan berjalan
-		dateFrom = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
-	}
-
-	// 2. Logika Parsing TO
-	parsedTo, err := time.Parse("2006-01-02", toStr)
-	if err == nil {
-		// Ditambah 1 hari agar mencakup data sampai akhir hari yang dipilih (23:59:59)
-		dateTo = time.Date(parsedTo.Year(), parsedTo.Month(), parsedTo.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
-	} else {
-		if fromStr == "" {
-			// Jika dua-duanya kosong atau invalid, ambil range 1 bulan (sampai awal bulan depan)
-			dateTo = dateFrom.AddDate(0, 1, 0)
-		} else {
-			// Jika user kirim From tapi To kosong/salah, set range 1 hari saja
-			dateTo = dateFrom.AddDate(0, 0, 1)
-		}
-	}
-
-	return dateFrom, dateTo
+func NewService(r Repository, cfg *config.Config) Service {
+	return &service{repo: r, cfg: cfg}
 }
 
 func (s *service) UpdateDriverTnkb(ctx context.Context, inoutID int64, driverID int64, tnkbID int64) error {
@@ -94,10 +64,24 @@ func (s *service) UpdateDriverTnkb(ctx context.Context, inoutID int64, driverID
 	return s.repo.UpdateDriverTnkb(ctx, inoutID, driverID, tnkbID)
 }
 
-func (s *service) FetchProgress(ctx context.Context, fromStr, toStr string) ([]ShipmentProgress, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+func (s *service) FetchProgress(ctx context.Context, fromStr, toStr string, page KeysetPage) (*ProgressPage, error) {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
+	page.Limit = min(page.Limit, maxKeysetLimit)
 
-	return s.repo.GetDailyProgress(ctx, dateFrom, dateTo)
+	list, next, err := s.repo.GetDailyProgress(ctx, dateFrom, dateTo, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &ProgressPage{Items: list, NextCursor: next}
+
+	if page.WithTotal {
+		total, err := s.repo.CountDailyProgress(ctx, dateFrom, dateTo)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+	return result, nil
 }
 
 func (s *service) GetAllCustomers() ([]Customer, error) {
@@ -112,31 +96,98 @@ func (s *service) GetTnkb() ([]TNKB, error) {
 	return s.repo.GetTnkb()
 }
 
-func (s *service) GetPending(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+func (s *service) GetHistory(ctx context.Context, fromStr, toStr string, page KeysetPage) (*HistoryPage, error) {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
+	page.Limit = min(page.Limit, maxKeysetLimit)
+
+	list, next, err := s.repo.GetHistory(ctx, dateFrom, dateTo, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &HistoryPage{Items: list, NextCursor: next}
+
+	if page.WithTotal {
+		total, err := s.repo.CountHistory(ctx, dateFrom, dateTo)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+	return result, nil
+}
+
+func (s *service) ListShipments(ctx context.Context, fromStr, toStr string, f ShipmentFilter) (*ShipmentPage, error) {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize <= 0 {
+		f.PageSize = defaultShipmentPageSize
+	}
+	if f.PageSize > maxShipmentPageSize {
+		f.PageSize = maxShipmentPageSize
+	}
+	f.From, f.To = shared.ParseDateRange(fromStr, toStr)
+
+	list, total, err := s.list(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShipmentPage{Items: list, Page: f.Page, PageSize: f.PageSize, Total: total}, nil
+}
+
+func (s *service) ListStage(ctx context.Context, stage, fromStr, toStr string) ([]Shipment, error) {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
 
-	return s.repo.GetPending(dateFrom, dateTo)
+	list, _, err := s.list(ctx, ShipmentFilter{Stage: stage, From: dateFrom, To: dateTo})
+	return list, err
 }
 
-func (s *service) GetHistory(fromStr, toStr string) ([]ShipmentHistory, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+func (s *service) list(ctx context.Context, f ShipmentFilter) ([]Shipment, int, error) {
+	if err := validateStage(f); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repo.ListShipments(ctx, f)
+}
 
-	return s.repo.GetHistory(dateFrom, dateTo)
+func validateStage(f ShipmentFilter) error {
+	def, ok := stages[f.Stage]
+	if f.Stage != "" && !ok {
+		return fmt.Errorf("%w: %q (pilihan: %s)", ErrUnknownStage, f.Stage, strings.Join(Stages(), ", "))
+	}
+	if def.NeedDriver && f.DriverID <= 0 {
+		return fmt.Errorf("%w: %q", ErrDriverRequired, f.Stage)
+	}
+	return nil
 }
 
-func (s *service) GetPrepare(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+func (s *service) ExportShipments(ctx context.Context, fromStr, toStr string, f ShipmentFilter, fn func(Shipment) error) error {
+	if err := validateStage(f); err != nil {
+		return err
+	}
+	f.From, f.To = shared.ParseDateRange(fromStr, toStr)
+	f.Page, f.PageSize = 0, 0
 
-	return s.repo.GetPrepare(dateFrom, dateTo)
+	return s.repo.StreamShipments(ctx, f, fn)
 }
 
-func (s *service) GetPrepareToLeave(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+func (s *service) ExportProgress(ctx context.Context, fromStr, toStr string, fn func(ShipmentProgress) error) error {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
+	return s.repo.StreamDailyProgress(ctx, dateFrom, dateTo, fn)
+}
 
-	return s.repo.GetPrepareToLeave(dateFrom, dateTo)
+func (s *service) ExportHistory(ctx context.Context, fromStr, toStr string, fn func(ShipmentHistory) error) error {
+	dateFrom, dateTo := shared.ParseDateRange(fromStr, toStr)
+	return s.repo.StreamHistory(ctx, dateFrom, dateTo, fn)
 }
 
-func (s *service) GetInTransit(driverID int64) ([]Shipment, error) {
+func (s *service) GetInTransit(ctx context.Context, driverID int64) ([]Shipment, error) {
+	// Tanpa driver tidak ada SJ yang dikembalikan (aplikasi driver selalu kirim driverId)
+	if driverID <= 0 {
+		return nil, nil
+	}
+
 	now := time.Now()
 	loc := now.Location()
 
@@ -147,7 +198,8 @@ func (s *service) GetInTransit(driverID int64) ([]Shipment, error) {
 	// Ambil sampai 3 hari ke depan jam 00:00:00
 	dateTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 3)
 
-	return s.repo.GetInTransitCustomer(dateFrom, dateTo, driverID)
+	list, _, err := s.list(ctx, ShipmentFilter{Stage: StageInTransit, From: dateFrom, To: dateTo, DriverID: driverID})
+	return list, err
 }
 
 func (s *service) GetOnCustomer(customerID, driverID int64) ([]Shipment, error) {
@@ -208,59 +260,42 @@ func (s *service) GetOnCustomer(customerID, driverID int64) ([]Shipment, error)
 	return list, nil
 }
 
-
-func (s *service) GetComeback(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetComeback(dateFrom, dateTo)
-}
-
-func (s *service) GetComebackToDelivery(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetComebackToDelivery(dateFrom, dateTo)
-}
-
-func (s *service) GetReceiptComebackToDelivery(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetReceiptComebackToDelivery(dateFrom, dateTo)
-}
-
-func (s *service) GetComebackToMarketing(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetComebackToMarketing(dateFrom, dateTo)
-}
-
-func (s *service) GetReceiptComebackToMarketing(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetReceiptComebackToMarketing(dateFrom, dateTo)
-}
-
-func (s *service) GetComebackToFat(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetComebackToFat(dateFrom, dateTo)
+func (s *service) FindByDocumentNo(ctx context.Context, documentNo string) ([]Shipment, error) {
+	return s.repo.GetByDocumentNo(ctx, strings.TrimSpace(documentNo))
 }
 
-func (s *service) GetReceiptComebackToFat(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
-
-	return s.repo.GetReceiptComebackToFat(dateFrom, dateTo)
-}
-
-func (s *service) GetOutstandingDPK(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+// GetTimeline melengkapi event dengan lama stage sebelumnya serta link bundle & PDF
+func (s *service) GetTimeline(ctx context.Context, mInOutID int64) ([]TimelineEntry, error) {
+	list, err := s.repo.GetTimeline(ctx, mInOutID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, ErrTimelineNotFound
+	}
 
-	return s.repo.GetOutstandingDPK(dateFrom, dateTo)
-}
+	baseURL := strings.TrimRight(s.cfg.BaseURL, "/")
+	for i := range list {
+		e := &list[i]
 
-func (s *service) GetOutstandingDelivery(fromStr, toStr string) ([]Shipment, error) {
-	dateFrom, dateTo := parseDateRange(fromStr, toStr)
+		// Stage sebelumnya dimulai di event sebelumnya; event pertama memakai PREVCREATED jika ada
+		start := e.PrevEventCreated
+		if start == nil {
+			start = e.PrevCreated
+		}
+		if start != nil {
+			secs := int64(e.Created.Sub(*start).Seconds())
+			e.PrevStageSeconds = &secs
+		}
 
-	return s.repo.GetOutstandingDelivery(dateFrom, dateTo)
+		if e.BundleNo != nil && *e.BundleNo != "" {
+			e.BundleURL = baseURL + "/handover/bundles/" + url.PathEscape(*e.BundleNo)
+		}
+		if e.Attachment != nil && *e.Attachment != "" {
+			e.PdfURL = storage.URL(s.cfg.BaseURL, *e.Attachment)
+		}
+	}
+	return list, nil
 }
 
 func (s *service) CancelOutstanding(ctx context.Context, id int64, currentStatus string) error {
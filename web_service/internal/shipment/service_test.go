@@ -0,0 +1,110 @@
+package shipment
+
+import (
+	"context"
+	"errors"
+	"sts/web_service/internal/shared/config"
+	"testing"
+	"time"
+)
+
+type timelineRepo struct {
+	Repository
+	list []TimelineEntry
+	err  error
+}
+
+func (r *timelineRepo) GetTimeline(ctx context.Context, mInOutID int64) ([]TimelineEntry, error) {
+	return r.list, r.err
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestGetTimelineNotFound(t *testing.T) {
+	dbErr := errors.New("db down")
+	tests := []struct {
+		name string
+		repo *timelineRepo
+		want error
+	}{
+		{"belum ada event", &timelineRepo{list: []TimelineEntry{}}, ErrTimelineNotFound},
+		{"nil dari repository", &timelineRepo{}, ErrTimelineNotFound},
+		{"error repository diteruskan", &timelineRepo{err: dbErr}, dbErr},
+	}
+
+	for _, tt := range tests {
+		s := NewService(tt.repo, &config.Config{BaseURL: "https://sts.example.com"})
+		list, err := s.GetTimeline(context.Background(), 1001)
+		if !errors.Is(err, tt.want) || list != nil {
+			t.Errorf("%s: GetTimeline = %v, %v, want nil, %v", tt.name, list, err, tt.want)
+		}
+	}
+}
+
+func TestGetTimeline(t *testing.T) {
+	t0 := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return t0.Add(time.Duration(minutes) * time.Minute) }
+
+	// Urutan dari repository (CREATED, ADW_STS_EVENT_ID) dipertahankan apa adanya
+	repo := &timelineRepo{list: []TimelineEntry{
+		{EventID: 1, EventType: "HO: DEL_TO_DPK", PrevCreated: ptr(at(-30)), Created: at(0),
+			BundleNo: ptr("HOPT/2026/03/000001"), Attachment: ptr("uploads/bundles/HOPT-000001.pdf")},
+		{EventID: 2, EventType: "HO: DPK_FROM_DEL", PrevCreated: ptr(at(0)), PrevEventCreated: ptr(at(0)), Created: at(45),
+			BundleNo: ptr("")},
+		{EventID: 5, EventType: "HO: DPK_TO_DRIVER", PrevEventCreated: ptr(at(45)), Created: at(45)},
+		{EventID: 4, EventType: "HO: DRIVER_CHECKIN", PrevEventCreated: ptr(at(45)), Created: at(165)},
+	}}
+	s := NewService(repo, &config.Config{BaseURL: "https://sts.example.com/"})
+
+	list, err := s.GetTimeline(context.Background(), 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		eventID   int64
+		prevStage *int64
+		bundleURL string
+		pdfURL    string
+	}{
+		{1, ptr[int64](1800), "https://sts.example.com/handover/bundles/HOPT%2F2026%2F03%2F000001", "https://sts.example.com/uploads/bundles/HOPT-000001.pdf"},
+		{2, ptr[int64](2700), "", ""},
+		{5, ptr[int64](0), "", ""},
+		{4, ptr[int64](7200), "", ""},
+	}
+	if len(list) != len(want) {
+		t.Fatalf("GetTimeline = %d event, want %d", len(list), len(want))
+	}
+	for i, w := range want {
+		e := list[i]
+		if e.EventID != w.eventID {
+			t.Errorf("event %d: ID = %d, want %d", i, e.EventID, w.eventID)
+		}
+		if (e.PrevStageSeconds == nil) != (w.prevStage == nil) || (w.prevStage != nil && *e.PrevStageSeconds != *w.prevStage) {
+			t.Errorf("event %d: prev_stage_seconds = %v, want %v", w.eventID, deref(e.PrevStageSeconds), deref(w.prevStage))
+		}
+		if e.BundleURL != w.bundleURL {
+			t.Errorf("event %d: bundle_url = %q, want %q", w.eventID, e.BundleURL, w.bundleURL)
+		}
+		if e.PdfURL != w.pdfURL {
+			t.Errorf("event %d: pdf_url = %q, want %q", w.eventID, e.PdfURL, w.pdfURL)
+		}
+	}
+
+	// Event pertama tanpa PREVCREATED tidak punya lama stage sebelumnya
+	repo.list = []TimelineEntry{{EventID: 9, Created: t0}}
+	list, err = s.GetTimeline(context.Background(), 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].PrevStageSeconds != nil {
+		t.Errorf("event pertama tanpa PREVCREATED: prev_stage_seconds = %d, want nil", *list[0].PrevStageSeconds)
+	}
+}
+
+func deref(p *int64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
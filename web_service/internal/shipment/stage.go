@@ -0,0 +1,112 @@
+package shipment
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Stage dashboard; nilainya sama dengan path route lama (/shipments/<stage>).
+// /on-customer tidak termasuk: filternya event terakhir per customer / driver (GetOnCustomer), bukan ADW_STS.STATUS.
+const (
+	StagePending                    = "pending"
+	StagePrepare                    = "prepare"
+	StagePrepareToLeave             = "preparetoleave"
+	StageInTransit                  = "in-transit"
+	StageComeback                   = "comeback"
+	StageComebackToDelivery         = "comebacktodelivery"
+	StageReceiptComebackToDelivery  = "receiptcomebacktodelivery"
+	StageComebackToMarketing        = "comebacktomarketing"
+	StageReceiptComebackToMarketing = "receiptcomebacktomarketing"
+	StageComebackToFat              = "comebacktofat"
+	StageReceiptComebackToFat       = "receiptcomebacktofat"
+	StageOutstandingDPK             = "outstanding-dpk"
+	StageOutstandingDelivery        = "outstanding-delivery"
+)
+
+var (
+	ErrUnknownStage   = errors.New("stage tidak dikenal")
+	ErrInvalidSort    = errors.New("sort tidak dikenal")
+	ErrDriverRequired = errors.New("driverId wajib diisi untuk stage ini")
+)
+
+const (
+	orderByMovementDate = "MovementDate ASC"
+	orderBySPPFirst     = "CASE WHEN SPPNO IS NOT NULL AND SPPNO <> ' ' THEN 0 ELSE 1 END ASC, MovementDate ASC"
+	orderByTMSFirst     = "ADW_TMS_ID ASC NULLS FIRST, MovementDate ASC"
+)
+
+// stageDef adalah filter ADW_STS.STATUS untuk satu stage
+type stageDef struct {
+	Statuses   []string
+	Exclude    bool   // true: STATUS NOT IN Statuses
+	Pending    bool   // SJ belum masuk STS (M_InOut.INSTS = 'N')
+	NeedSPP    bool   // hanya SJ yang sudah punya SPPNO
+	NeedDriver bool   // wajib difilter driverId (route lama /in-transit untuk aplikasi driver)
+	OrderBy    string // urutan default stage
+}
+
+var stages = map[string]stageDef{
+	StagePending:                    {Pending: true, OrderBy: orderByTMSFirst},
+	StagePrepare:                    {Statuses: []string{"HO: DEL_TO_DPK"}},
+	StagePrepareToLeave:             {Statuses: []string{"RE: DPK_FROM_DEL"}},
+	StageInTransit:                  {Statuses: []string{"HO: DPK_TO_DRIVER"}, NeedDriver: true},
+	StageComeback:                   {Statuses: []string{"HO: DPK_TO_DRIVER", "HO: DRIVER_CHECKIN", "HO: DRIVER_CHECKOUT"}},
+	StageComebackToDelivery:         {Statuses: []string{"RE: DPK_FROM_DRIVER"}},
+	StageReceiptComebackToDelivery:  {Statuses: []string{"HO: DPK_TO_DEL"}},
+	StageComebackToMarketing:        {Statuses: []string{"RE: DEL_FROM_DPK"}},
+	StageReceiptComebackToMarketing: {Statuses: []string{"HO: DEL_TO_MKT"}, OrderBy: orderBySPPFirst},
+	StageComebackToFat:              {Statuses: []string{"RE: MKT_FROM_DEL"}, NeedSPP: true},
+	StageReceiptComebackToFat:       {Statuses: []string{"HO: MKT_TO_FAT"}},
+	StageOutstandingDPK:             {Statuses: []string{"HO: DPK_TO_DRIVER"}},
+	StageOutstandingDelivery:        {Statuses: []string{"RE: DEL_FROM_DPK"}, Exclude: true},
+}
+
+// sortColumns: nilai parameter sort -> kolom hasil query (awalan "-" untuk DESC)
+var sortColumns = map[string]string{
+	"movement_date": "MovementDate",
+	"document_no":   "DocumentNo",
+	"customer":      "Customer",
+	"driver":        "Driver",
+	"tnkb":          "TNKBNO",
+	"status":        "Status",
+}
+
+// Stages mengembalikan daftar stage yang dikenal (untuk pesan error / frontend)
+func Stages() []string {
+	keys := make([]string, 0, len(stages))
+	for k := range stages {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// orderClause menyusun ORDER BY; M_InOut_ID selalu jadi penentu terakhir agar urutan stabil antar halaman
+func orderClause(sort string, def stageDef) (string, error) {
+	order := def.OrderBy
+	if order == "" {
+		order = orderByMovementDate
+	}
+
+	if sort = strings.TrimSpace(sort); sort != "" {
+		var parts []string
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			dir := "ASC"
+			if strings.HasPrefix(field, "-") {
+				dir = "DESC"
+				field = field[1:]
+			}
+			col, ok := sortColumns[field]
+			if !ok {
+				return "", fmt.Errorf("%w: %q", ErrInvalidSort, field)
+			}
+			parts = append(parts, col+" "+dir+" NULLS LAST")
+		}
+		order = strings.Join(parts, ", ")
+	}
+
+	return order + ", M_InOut_ID ASC", nil
+}
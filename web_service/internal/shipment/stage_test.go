@@ -0,0 +1,78 @@
+package shipment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderClause(t *testing.T) {
+	tests := []struct {
+		name string
+		sort string
+		def  stageDef
+		want string
+	}{
+		{"default stage", "", stageDef{}, "MovementDate ASC, M_InOut_ID ASC"},
+		{"default pending", "", stages[StagePending], orderByTMSFirst + ", M_InOut_ID ASC"},
+		{"default SPP dulu", " ", stages[StageReceiptComebackToMarketing], orderBySPPFirst + ", M_InOut_ID ASC"},
+		{"satu kolom", "customer", stageDef{}, "Customer ASC NULLS LAST, M_InOut_ID ASC"},
+		{"DESC", "-movement_date", stageDef{}, "MovementDate DESC NULLS LAST, M_InOut_ID ASC"},
+		{
+			name: "beberapa kolom menggantikan default stage",
+			sort: "driver, -tnkb,document_no",
+			def:  stages[StagePending],
+			want: "Driver ASC NULLS LAST, TNKBNO DESC NULLS LAST, DocumentNo ASC NULLS LAST, M_InOut_ID ASC",
+		},
+		{"status", "status", stageDef{}, "Status ASC NULLS LAST, M_InOut_ID ASC"},
+	}
+
+	for _, tt := range tests {
+		got, err := orderClause(tt.sort, tt.def)
+		if err != nil {
+			t.Errorf("%s: orderClause(%q) error %v", tt.name, tt.sort, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: orderClause(%q) =\n %s\nwant\n %s", tt.name, tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestOrderClauseInvalid(t *testing.T) {
+	// Nilai sort masuk ke teks SQL, jadi hanya nama dari sortColumns yang boleh
+	for _, sort := range []string{"M_InOut_ID", "customer;DROP TABLE x", "movement_date,", "--customer", "Customer", "-"} {
+		if got, err := orderClause(sort, stageDef{}); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("orderClause(%q) = %q, %v, want ErrInvalidSort", sort, got, err)
+		}
+	}
+}
+
+func TestValidateStage(t *testing.T) {
+	tests := []struct {
+		name string
+		f    ShipmentFilter
+		want error
+	}{
+		{"tanpa stage", ShipmentFilter{}, nil},
+		{"stage dikenal", ShipmentFilter{Stage: StagePrepare}, nil},
+		{"stage tidak dikenal", ShipmentFilter{Stage: "lain"}, ErrUnknownStage},
+		{"on-customer bukan stage", ShipmentFilter{Stage: "on-customer", CustomerID: 1}, ErrUnknownStage},
+		{"in-transit tanpa driver", ShipmentFilter{Stage: StageInTransit}, ErrDriverRequired},
+		{"in-transit dengan driver", ShipmentFilter{Stage: StageInTransit, DriverID: 7}, nil},
+	}
+
+	for _, tt := range tests {
+		if err := validateStage(tt.f); !errors.Is(err, tt.want) {
+			t.Errorf("%s: validateStage = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestStagesDefined(t *testing.T) {
+	for _, stage := range Stages() {
+		def := stages[stage]
+		if !def.Pending && len(def.Statuses) == 0 {
+			t.Errorf("stage %q tanpa filter status", stage)
+		}
+	}
+}
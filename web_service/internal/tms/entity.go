@@ -10,7 +10,7 @@ type APIResponse struct {
 }
 
 type SearchDriver struct {
-	AD_USER_ID int64  `db:"AD_USER_ID", json:"AD_USER_ID"`
+	AD_USER_ID int64  `db:"AD_USER_ID" json:"AD_USER_ID"`
 	Name       string `db:"NAME" json:"NAME"`
 }
 
@@ -21,14 +21,45 @@ type ShipmentByDriver struct {
 }
 
 type CustomerLog struct {
-	BPartnerID    int64      `db:"C_BPARTNER_ID" json:"c_bpartner_id"`
-	CustomerName  string     `db:"CUSTOMER" json:"customer_name"` // Pastikan ini CUSTOMER (huruf besar)
-	CheckInID     *int64     `db:"CHECKIN_ID" json:"checkin_id"`
-	CheckIn       *time.Time `db:"CHECKIN" json:"checkin"`
-	CheckInNotes  string     `db:"CHECKIN_NOTES" json:"checkin_notes"`
+	BPartnerID   int64      `db:"C_BPARTNER_ID" json:"c_bpartner_id"`
+	CustomerName string     `db:"CUSTOMER" json:"customer_name"` // Pastikan ini CUSTOMER (huruf besar)
+	CheckInID    *int64     `db:"CHECKIN_ID" json:"checkin_id"`
+	CheckIn      *time.Time `db:"CHECKIN" json:"checkin"`
+	CheckInNotes string     `db:"CHECKIN_NOTES" json:"checkin_notes"`
+
+	CheckInLatitude  *float64 `db:"CHECKIN_LATITUDE" json:"checkin_latitude"`
+	CheckInLongitude *float64 `db:"CHECKIN_LONGITUDE" json:"checkin_longitude"`
+	CheckInAccuracy  *float64 `db:"CHECKIN_ACCURACY" json:"checkin_accuracy"`
+	CheckInGeofence  *string  `db:"CHECKIN_GEOFENCE" json:"checkin_geofence"` // INSIDE, OUTSIDE, NO_GEOFENCE, NO_GPS
+	CheckInDistance  *float64 `db:"CHECKIN_DISTANCE" json:"checkin_distance_m"`
+	NeedsReview      bool     `db:"-" json:"needs_review"` // check-in di luar radius geofence / event tanpa GPS
+
 	CheckOutID    *int64     `db:"CHECKOUT_ID" json:"checkout_id"`
 	CheckOut      *time.Time `db:"CHECKOUT" json:"checkout"`
 	CheckOutNotes string     `db:"CHECKOUT_NOTES" json:"checkout_notes"`
+
+	CheckOutLatitude  *float64 `db:"CHECKOUT_LATITUDE" json:"checkout_latitude"`
+	CheckOutLongitude *float64 `db:"CHECKOUT_LONGITUDE" json:"checkout_longitude"`
+	CheckOutAccuracy  *float64 `db:"CHECKOUT_ACCURACY" json:"checkout_accuracy"`
+	CheckOutGeofence  *string  `db:"CHECKOUT_GEOFENCE" json:"checkout_geofence"` // NO_GPS jika tanpa koordinat
+
+	CheckInPhotos  []EventPhoto `db:"-" json:"checkin_photos"`
+	CheckOutPhotos []EventPhoto `db:"-" json:"checkout_photos"`
+}
+
+// Foto bukti check-in / check-out (ADW_STS_EVENT_PHOTO)
+type EventPhoto struct {
+	ID          int64      `db:"ADW_STS_EVENT_PHOTO_ID" json:"id"`
+	EventID     int64      `db:"ADW_STS_EVENT_ID" json:"event_id"`
+	PhotoKey    string     `db:"PHOTOKEY" json:"-"`
+	ThumbKey    string     `db:"THUMBKEY" json:"-"`
+	URL         string     `db:"-" json:"url"`
+	ThumbURL    string     `db:"-" json:"thumb_url"`
+	ContentType string     `db:"CONTENTTYPE" json:"content_type"`
+	Width       int        `db:"WIDTH" json:"width"`
+	Height      int        `db:"HEIGHT" json:"height"`
+	TakenAt     *time.Time `db:"TAKENAT" json:"taken_at"` // dari EXIF, null jika tidak ada
+	Created     time.Time  `db:"CREATED" json:"created"`
 }
 
 type UpdateLogRequest struct {
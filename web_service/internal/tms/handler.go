@@ -2,7 +2,6 @@ package tms
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -148,10 +147,6 @@ func (h *handler) UpdateCustomerLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("test EventID : ", req.EventID)
-	fmt.Printf("test EventTime : ", req.EventTime)
-	fmt.Printf("test Notes : ", req.Notes)
-
 	// Panggil Service
 	err := h.service.UpdateLog(r.Context(), req.EventID, req.EventTime, req.Notes)
 	if err != nil {
@@ -13,6 +13,7 @@ type Repository interface {
 	ShipmentByDriver(ctx context.Context, driverID int64) ([]ShipmentByDriver, error)
 	GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog, error)
 	UpdateEventLog(ctx context.Context, eventID int64, eventTime string, notes string) error
+	GetPhotosByEventIDs(ctx context.Context, eventIDs []int64) ([]EventPhoto, error)
 }
 
 type oraRepo struct {
@@ -75,18 +76,26 @@ func (r *oraRepo) GetDriverByName(ctx context.Context, searchKey string) ([]Sear
 	return sDriver, nil
 }
 
-func (r *oraRepo) GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog, error) {
-	list := []CustomerLog{}
-	query := `
+// logsByTMSQuery: satu baris per customer, event check-in & check-out dipivot jadi kolom CHECKIN_* / CHECKOUT_*
+const logsByTMSQuery = `
 		SELECT 
 			mi.C_BPARTNER_ID,
 			cbp.VALUE AS CUSTOMER,
 			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.ADW_STS_EVENT_ID END) AS CHECKIN_ID,
 			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.CREATED END) AS CHECKIN,
 			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.NOTES END) AS CHECKIN_NOTES,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.LATITUDE END) AS CHECKIN_LATITUDE,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.LONGITUDE END) AS CHECKIN_LONGITUDE,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.GPSACCURACY END) AS CHECKIN_ACCURACY,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.GEOFENCESTATUS END) AS CHECKIN_GEOFENCE,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKIN' THEN ase.GEOFENCEDISTANCE END) AS CHECKIN_DISTANCE,
 			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.ADW_STS_EVENT_ID END) AS CHECKOUT_ID,
 			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.CREATED END) AS CHECKOUT,
-			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.NOTES END) AS CHECKOUT_NOTES
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.NOTES END) AS CHECKOUT_NOTES,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.LATITUDE END) AS CHECKOUT_LATITUDE,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.LONGITUDE END) AS CHECKOUT_LONGITUDE,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.GPSACCURACY END) AS CHECKOUT_ACCURACY,
+			MAX(CASE WHEN ase.EVENTTYPE = 'HO: DRIVER_CHECKOUT' THEN ase.GEOFENCESTATUS END) AS CHECKOUT_GEOFENCE
 		FROM ADW_STS_EVENT ase
 		JOIN ADW_STS t ON t.ADW_STS_ID = ase.ADW_STS_ID
 		JOIN M_INOUT mi ON mi.M_INOUT_ID = t.M_INOUT_ID
@@ -95,7 +104,10 @@ func (r *oraRepo) GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog,
 		GROUP BY mi.C_BPARTNER_ID, cbp.VALUE
 		ORDER BY CHECKIN ASC
 	`
-	err := r.db.SelectContext(ctx, &list, query, tmsID)
+
+func (r *oraRepo) GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog, error) {
+	list := []CustomerLog{}
+	err := r.db.SelectContext(ctx, &list, logsByTMSQuery, tmsID)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +115,34 @@ func (r *oraRepo) GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog,
 	return list, nil
 }
 
+func (r *oraRepo) GetPhotosByEventIDs(ctx context.Context, eventIDs []int64) ([]EventPhoto, error) {
+	photos := []EventPhoto{}
+	if len(eventIDs) == 0 {
+		return photos, nil
+	}
+
+	placeholders := make([]string, len(eventIDs))
+	args := make([]interface{}, len(eventIDs))
+	for i, id := range eventIDs {
+		placeholders[i] = fmt.Sprintf(":%d", i+1)
+		args[i] = id
+	}
+
+	query := `
+		SELECT ADW_STS_EVENT_PHOTO_ID, ADW_STS_EVENT_ID, PHOTOKEY, THUMBKEY,
+			CONTENTTYPE, WIDTH, HEIGHT, TAKENAT, CREATED
+		FROM ADW_STS_EVENT_PHOTO
+		WHERE ISACTIVE = 'Y'
+			AND ADW_STS_EVENT_ID IN (` + strings.Join(placeholders, ",") + `)
+		ORDER BY ADW_STS_EVENT_ID, LINE`
+
+	if err := r.db.SelectContext(ctx, &photos, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get event photos: %w", err)
+	}
+
+	return photos, nil
+}
+
 func (r *oraRepo) UpdateEventLog(ctx context.Context, eventID int64, eventTime string, notes string) error {
 	query := `UPDATE ADW_STS_EVENT 
           SET CREATED = TO_DATE(:1, 'YYYY-MM-DD HH24:MI:SS'), 
@@ -124,6 +164,5 @@ func (r *oraRepo) UpdateEventLog(ctx context.Context, eventID int64, eventTime s
 		return fmt.Errorf("gagal update: data dengan ID %d tidak ditemukan", eventID)
 	}
 
-	fmt.Printf("Berhasil update %d baris\n", rows)
 	return nil
 }
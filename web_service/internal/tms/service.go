@@ -4,8 +4,21 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
+
+	"sts/web_service/internal/shared/config"
+	"sts/web_service/internal/shared/storage"
+)
+
+// Masa berlaku link foto bukti di respons log customer
+const photoURLTTL = time.Hour
+
+// Nilai ADW_STS_EVENT.GEOFENCESTATUS (lihat handover/geofence.go)
+const (
+	geofenceOutside = "OUTSIDE"
+	geofenceNoGPS   = "NO_GPS"
 )
 
 type Service interface {
@@ -17,10 +30,11 @@ type Service interface {
 
 type service struct {
 	repo Repository
+	cfg  *config.Config
 }
 
-func NewService(r Repository) Service {
-	return &service{repo: r}
+func NewService(r Repository, cfg *config.Config) Service {
+	return &service{repo: r, cfg: cfg}
 }
 
 func (s *service) SearchDriver(ctx context.Context, searchKey string) ([]SearchDriver, error) {
@@ -52,7 +66,58 @@ func (s *service) ShipmentByDriver(ctx context.Context, driverID int64) ([]Shipm
 }
 
 func (s *service) GetCustomerLogs(ctx context.Context, tmsID int64) ([]CustomerLog, error) {
-	return s.repo.GetLogsByTMS(ctx, tmsID)
+	logs, err := s.repo.GetLogsByTMS(ctx, tmsID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Foto bukti diambil sekali untuk semua event check-in / check-out
+	var eventIDs []int64
+	for _, l := range logs {
+		if l.CheckInID != nil {
+			eventIDs = append(eventIDs, *l.CheckInID)
+		}
+		if l.CheckOutID != nil {
+			eventIDs = append(eventIDs, *l.CheckOutID)
+		}
+	}
+
+	photos, err := s.repo.GetPhotosByEventIDs(ctx, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Foto bukti tidak publik, link hanya berlaku sebentar untuk user yang membuka log
+	expires := time.Now().Add(photoURLTTL)
+	byEvent := make(map[int64][]EventPhoto)
+	for _, p := range photos {
+		p.URL = storage.SignedURL(s.cfg.BaseURL, p.PhotoKey, s.cfg.UploadSecret, expires)
+		p.ThumbURL = storage.SignedURL(s.cfg.BaseURL, p.ThumbKey, s.cfg.UploadSecret, expires)
+		byEvent[p.EventID] = append(byEvent[p.EventID], p)
+	}
+
+	for i := range logs {
+		logs[i].NeedsReview = needsReview(logs[i])
+
+		logs[i].CheckInPhotos = []EventPhoto{}
+		logs[i].CheckOutPhotos = []EventPhoto{}
+		if id := logs[i].CheckInID; id != nil && byEvent[*id] != nil {
+			logs[i].CheckInPhotos = byEvent[*id]
+		}
+		if id := logs[i].CheckOutID; id != nil && byEvent[*id] != nil {
+			logs[i].CheckOutPhotos = byEvent[*id]
+		}
+	}
+
+	return logs, nil
+}
+
+// needsReview: check-in di luar geofence, atau check-in / check-out yang dikirim tanpa GPS
+func needsReview(l CustomerLog) bool {
+	is := func(status *string, values ...string) bool {
+		return status != nil && slices.Contains(values, *status)
+	}
+	return is(l.CheckInGeofence, geofenceOutside, geofenceNoGPS) || is(l.CheckOutGeofence, geofenceNoGPS)
 }
 
 func (s *service) UpdateLog(ctx context.Context, eventID int64, rawTime string, notes string) error {
@@ -0,0 +1,119 @@
+package tms
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"sts/web_service/internal/shared/config"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestLogsByTMSQueryColumns(t *testing.T) {
+	// Setiap field CustomerLog yang punya tag db harus ada di SELECT, kalau tidak nilainya selalu null
+	selected := map[string]bool{}
+	for _, m := range regexp.MustCompile(`(?:AS |mi\.)(\w+),?\n`).FindAllStringSubmatch(logsByTMSQuery, -1) {
+		selected[m[1]] = true
+	}
+
+	typ := reflect.TypeOf(CustomerLog{})
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("db")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if !selected[tag] {
+			t.Errorf("kolom %s (%s) tidak ada di logsByTMSQuery", tag, typ.Field(i).Name)
+		}
+	}
+
+	// Kolom GPS diambil dari event yang sesuai
+	for _, want := range []string{
+		"'HO: DRIVER_CHECKIN' THEN ase.GEOFENCESTATUS END) AS CHECKIN_GEOFENCE",
+		"'HO: DRIVER_CHECKIN' THEN ase.GPSACCURACY END) AS CHECKIN_ACCURACY",
+		"'HO: DRIVER_CHECKOUT' THEN ase.LATITUDE END) AS CHECKOUT_LATITUDE",
+		"'HO: DRIVER_CHECKOUT' THEN ase.GEOFENCESTATUS END) AS CHECKOUT_GEOFENCE",
+	} {
+		if !strings.Contains(logsByTMSQuery, want) {
+			t.Errorf("logsByTMSQuery tidak mengandung %q", want)
+		}
+	}
+}
+
+func TestNeedsReview(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkIn  *string
+		checkOut *string
+		want     bool
+	}{
+		{"belum ada event", nil, nil, false},
+		{"di dalam geofence", ptr("INSIDE"), nil, false},
+		{"customer tanpa geofence", ptr("NO_GEOFENCE"), nil, false},
+		{"di luar geofence", ptr("OUTSIDE"), nil, true},
+		{"check-in tanpa GPS", ptr("NO_GPS"), nil, true},
+		{"check-out tanpa GPS", ptr("INSIDE"), ptr("NO_GPS"), true},
+		{"check-out dengan GPS", ptr("INSIDE"), nil, false},
+	}
+	for _, tt := range tests {
+		l := CustomerLog{CheckInGeofence: tt.checkIn, CheckOutGeofence: tt.checkOut}
+		if got := needsReview(l); got != tt.want {
+			t.Errorf("%s: needsReview = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type fakeRepo struct {
+	Repository
+	logs     []CustomerLog
+	photos   []EventPhoto
+	eventIDs []int64
+}
+
+func (f *fakeRepo) GetLogsByTMS(ctx context.Context, tmsID int64) ([]CustomerLog, error) {
+	return f.logs, nil
+}
+
+func (f *fakeRepo) GetPhotosByEventIDs(ctx context.Context, eventIDs []int64) ([]EventPhoto, error) {
+	f.eventIDs = eventIDs
+	return f.photos, nil
+}
+
+func TestGetCustomerLogs(t *testing.T) {
+	repo := &fakeRepo{
+		logs: []CustomerLog{
+			{BPartnerID: 1, CheckInID: ptr(int64(10)), CheckInGeofence: ptr("OUTSIDE"), CheckInLatitude: ptr(-6.2), CheckOutID: ptr(int64(11))},
+			{BPartnerID: 2, CheckInID: ptr(int64(20)), CheckInGeofence: ptr("INSIDE")},
+		},
+		photos: []EventPhoto{
+			{EventID: 10, PhotoKey: "events/2026/03/a.jpg", ThumbKey: "events/2026/03/a_thumb.jpg"},
+			{EventID: 11, PhotoKey: "events/2026/03/b.jpg", ThumbKey: "events/2026/03/b_thumb.jpg"},
+		},
+	}
+	s := NewService(repo, &config.Config{BaseURL: "http://api", UploadSecret: "rahasia"})
+
+	logs, err := s.GetCustomerLogs(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo.eventIDs, []int64{10, 11, 20}) {
+		t.Errorf("event id foto = %v", repo.eventIDs)
+	}
+
+	if !logs[0].NeedsReview || logs[1].NeedsReview {
+		t.Errorf("NeedsReview = %v, %v, want true, false", logs[0].NeedsReview, logs[1].NeedsReview)
+	}
+	if logs[0].CheckInLatitude == nil || *logs[0].CheckInLatitude != -6.2 {
+		t.Errorf("CheckInLatitude = %v", logs[0].CheckInLatitude)
+	}
+	if len(logs[0].CheckInPhotos) != 1 || len(logs[0].CheckOutPhotos) != 1 || logs[1].CheckInPhotos == nil || len(logs[1].CheckInPhotos) != 0 {
+		t.Errorf("foto = %+v / %+v", logs[0], logs[1])
+	}
+	// Link foto bukti selalu bertanda tangan
+	if u := logs[0].CheckInPhotos[0].URL; !strings.HasPrefix(u, "http://api/uploads/events/2026/03/a.jpg?") || !strings.Contains(u, "sig=") {
+		t.Errorf("URL foto = %q", u)
+	}
+}
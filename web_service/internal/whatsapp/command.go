@@ -0,0 +1,348 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sts/web_service/internal/shipment"
+	"sts/web_service/internal/tms"
+	"time"
+	"unicode"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Perintah yang dikenali dari pesan masuk
+const (
+	CmdStatus         = "status"
+	CmdDriver         = "driver"
+	CmdOutstandingDPK = "outstanding dpk"
+	CmdHelp           = "help"
+)
+
+// Status log perintah di ADW_STS_WA_COMMAND_LOG
+const (
+	CommandOK       = "OK"
+	CommandError    = "ERROR"
+	CommandRejected = "REJECTED" // nomor tidak terhubung ke AD_User
+)
+
+const commandFailedReply = "Maaf, terjadi kesalahan saat mengambil data. Coba lagi nanti."
+
+const (
+	commandTimeout        = 30 * time.Second
+	commandMaxAge         = 10 * time.Minute // pesan lama (offline / sync) tidak dijawab
+	commandOutstandingAge = 90               // hari ke belakang untuk outstanding dpk
+	commandMaxRows        = 20
+	commandMaxDrivers     = 3
+)
+
+// Sender adalah AD_User yang nomornya cocok dengan pengirim pesan
+type Sender struct {
+	UserID int64   `db:"AD_USER_ID"`
+	Name   string  `db:"NAME"`
+	Title  *string `db:"TITLE"`
+	Phone  string  `db:"-"`
+}
+
+type CommandLog struct {
+	UserID    *int64
+	Phone     string
+	ChatJID   string
+	MessageID string
+	Command   string
+	Args      string
+	Status    string
+	Reply     string
+}
+
+// CommandHandler menjawab perintah status SJ dari chat WhatsApp (grup STS atau pribadi)
+type CommandHandler struct {
+	manager   *Manager
+	repo      Repository
+	shipments shipment.Service
+	tms       tms.Service
+	logger    *slog.Logger
+}
+
+func NewCommandHandler(m *Manager, repo Repository, shipments shipment.Service, tmsService tms.Service, logger *slog.Logger) *CommandHandler {
+	return &CommandHandler{manager: m, repo: repo, shipments: shipments, tms: tmsService, logger: logger}
+}
+
+// HandleEvent didaftarkan lewat Manager.AddEventHandler
+func (h *CommandHandler) HandleEvent(evt any) {
+	e, ok := evt.(*events.Message)
+	if !ok || e.Info.IsFromMe || time.Since(e.Info.Timestamp) > commandMaxAge {
+		return
+	}
+
+	text := e.Message.GetConversation()
+	if text == "" {
+		text = e.Message.GetExtendedTextMessage().GetText()
+	}
+	cmd, args := parseCommand(text)
+	if cmd == "" {
+		return
+	}
+
+	// Query DB jangan sampai menahan event loop whatsmeow
+	go h.handle(e, cmd, args)
+}
+
+func (h *CommandHandler) handle(e *events.Message, cmd, args string) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	client := h.manager.Client()
+	if client == nil {
+		return
+	}
+
+	entry := CommandLog{
+		ChatJID:   e.Info.Chat.String(),
+		MessageID: e.Info.ID,
+		Command:   cmd,
+		Args:      args,
+	}
+
+	phones := senderPhones(ctx, client, e.Info)
+	if len(phones) > 0 {
+		entry.Phone = phones[0]
+	}
+
+	sender, err := h.repo.FindUserByPhone(ctx, phones...)
+	if errors.Is(err, ErrUnknownSender) {
+		entry.Status = CommandRejected
+		entry.Reply = err.Error()
+		h.log(ctx, entry)
+		// Di grup cukup diam, supaya obrolan biasa tidak dibalas
+		if !e.Info.IsGroup {
+			h.reply(ctx, client, e, "Maaf, nomor ini belum terdaftar di sistem STS.")
+		}
+		return
+	}
+	if err != nil {
+		// Gagal cek nomor (DB error dsb): bukan berarti nomor tidak terdaftar
+		h.logger.Error("whatsapp sender lookup failed", "command", cmd, "phone", entry.Phone, "error", err)
+		entry.Status = CommandError
+		entry.Reply = err.Error()
+		h.reply(ctx, client, e, commandFailedReply)
+		h.log(ctx, entry)
+		return
+	}
+	entry.UserID = &sender.UserID
+	entry.Phone = sender.Phone
+
+	reply, err := h.run(ctx, cmd, args)
+	if err != nil {
+		h.logger.Error("whatsapp command failed", "command", cmd, "args", args, "error", err)
+		entry.Status = CommandError
+		entry.Reply = err.Error()
+		reply = commandFailedReply
+	} else {
+		entry.Status = CommandOK
+		entry.Reply = reply
+	}
+
+	if err := h.reply(ctx, client, e, reply); err != nil && entry.Status == CommandOK {
+		entry.Status = CommandError
+		entry.Reply = "gagal kirim balasan: " + err.Error()
+	}
+	h.log(ctx, entry)
+}
+
+func (h *CommandHandler) run(ctx context.Context, cmd, args string) (string, error) {
+	switch cmd {
+	case CmdStatus:
+		return h.status(ctx, args)
+	case CmdDriver:
+		return h.driver(ctx, args)
+	case CmdOutstandingDPK:
+		return h.outstandingDPK(ctx)
+	default:
+		return helpText, nil
+	}
+}
+
+func (h *CommandHandler) status(ctx context.Context, documentNo string) (string, error) {
+	list, err := h.shipments.FindByDocumentNo(ctx, documentNo)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return fmt.Sprintf("SJ *%s* tidak ditemukan.", documentNo), nil
+	}
+
+	var b strings.Builder
+	for i, s := range list {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		status := "Belum masuk STS"
+		if s.Status != nil {
+			status = *s.Status
+		}
+		fmt.Fprintf(&b, "*SJ %s*\nCustomer: %s\nTanggal: %s\nStatus: %s\nDriver: %s\nTNKB: %s",
+			s.DocumentNo, s.Customer, s.MovementDate.Format("02 Jan 2006"), status,
+			valueOr(s.Driver, "-"), valueOr(s.TNKBNo, "-"))
+	}
+	return b.String(), nil
+}
+
+func (h *CommandHandler) driver(ctx context.Context, name string) (string, error) {
+	drivers, err := h.tms.SearchDriver(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if len(drivers) == 0 {
+		return fmt.Sprintf("Driver *%s* tidak ditemukan.", name), nil
+	}
+
+	var b strings.Builder
+	for i, d := range drivers {
+		if i == commandMaxDrivers {
+			fmt.Fprintf(&b, "\n\n...dan %d driver lain, perjelas nama.", len(drivers)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		list, err := h.tms.ShipmentByDriver(ctx, d.AD_USER_ID)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "*%s* — %d SJ di perjalanan", d.Name, len(list))
+		for j, s := range list {
+			if j == commandMaxRows {
+				fmt.Fprintf(&b, "\n...dan %d SJ lain", len(list)-j)
+				break
+			}
+			fmt.Fprintf(&b, "\n%d. %s", j+1, s.SuratJalan)
+			if s.TNKBNo != "" {
+				fmt.Fprintf(&b, " (%s)", s.TNKBNo)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func (h *CommandHandler) outstandingDPK(ctx context.Context) (string, error) {
+	now := time.Now()
+	from := now.AddDate(0, 0, -commandOutstandingAge).Format("2006-01-02")
+	list, err := h.shipments.ListStage(ctx, shipment.StageOutstandingDPK, from, now.Format("2006-01-02"))
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return "Tidak ada SJ outstanding di DPK.", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Outstanding DPK: %d SJ*", len(list))
+	for i, s := range list {
+		if i == commandMaxRows {
+			fmt.Fprintf(&b, "\n...dan %d SJ lain", len(list)-i)
+			break
+		}
+		days := int(now.Sub(s.MovementDate).Hours() / 24)
+		fmt.Fprintf(&b, "\n%d. %s / %s / %s (%d hari)", i+1, s.DocumentNo, s.Customer, valueOr(s.Driver, "-"), days)
+	}
+	return b.String(), nil
+}
+
+func (h *CommandHandler) reply(ctx context.Context, client *whatsmeow.Client, e *events.Message, text string) error {
+	_, err := client.SendMessage(ctx, e.Info.Chat, &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(text),
+			ContextInfo: &waE2E.ContextInfo{
+				StanzaID:      proto.String(e.Info.ID),
+				Participant:   proto.String(e.Info.Sender.String()),
+				QuotedMessage: e.Message,
+			},
+		},
+	})
+	if err != nil {
+		h.logger.Warn("whatsapp command reply failed", "chat", e.Info.Chat.String(), "error", err)
+	}
+	return err
+}
+
+func (h *CommandHandler) log(ctx context.Context, l CommandLog) {
+	if err := h.repo.LogCommand(ctx, l); err != nil {
+		h.logger.Error("whatsapp command log failed", "command", l.Command, "error", err)
+	}
+	h.logger.Info("whatsapp command", "command", l.Command, "args", l.Args, "phone", l.Phone, "status", l.Status)
+}
+
+const helpText = `Perintah STS:
+• status <No SJ> — posisi & status surat jalan
+• driver <nama> — SJ yang sedang dibawa driver
+• outstanding dpk — SJ yang masih di DPK`
+
+// parseCommand mengembalikan perintah dan argumennya; "" jika pesan bukan perintah / argumen tidak lengkap.
+// Awalan "/" atau "!" boleh dipakai.
+func parseCommand(text string) (string, string) {
+	fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(text), "/!"))
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case CmdStatus:
+		// Harus tepat satu nomor SJ yang mengandung angka, "status nya gimana?" diabaikan
+		if len(fields) == 2 && strings.IndexFunc(fields[1], unicode.IsDigit) >= 0 {
+			return CmdStatus, fields[1]
+		}
+	case CmdDriver:
+		name := strings.Join(fields[1:], " ")
+		if len([]rune(name)) >= 3 {
+			return CmdDriver, name
+		}
+	case "outstanding":
+		if len(fields) == 2 && strings.EqualFold(fields[1], "dpk") {
+			return CmdOutstandingDPK, ""
+		}
+	case CmdHelp, "bantuan":
+		if len(fields) == 1 {
+			return CmdHelp, ""
+		}
+	}
+	return "", ""
+}
+
+// senderPhones mengembalikan nomor pengirim (format 62xxx dan 0xxx). Pengirim di grup bisa berupa LID,
+// nomornya diambil dari SenderAlt atau mapping LID di store.
+func senderPhones(ctx context.Context, client *whatsmeow.Client, info types.MessageInfo) []string {
+	pn := info.Sender
+	if pn.Server != types.DefaultUserServer {
+		pn = info.SenderAlt
+	}
+	if pn.Server != types.DefaultUserServer && info.Sender.Server == types.HiddenUserServer {
+		if alt, err := client.Store.LIDs.GetPNForLID(ctx, info.Sender); err == nil {
+			pn = alt
+		}
+	}
+	if pn.Server != types.DefaultUserServer || pn.User == "" {
+		return nil
+	}
+
+	phones := []string{pn.User}
+	if local, ok := strings.CutPrefix(pn.User, "62"); ok {
+		phones = append(phones, "0"+local)
+	}
+	return phones
+}
+
+func valueOr(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
@@ -0,0 +1,78 @@
+package whatsapp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantCmd  string
+		wantArgs string
+	}{
+		{"status SJ/2026/00123", CmdStatus, "SJ/2026/00123"},
+		{"  STATUS   sj-001  ", CmdStatus, "sj-001"},
+		{"/status 12345", CmdStatus, "12345"},
+		{"!status 12345", CmdStatus, "12345"},
+		{"status", "", ""},
+		{"status nya gimana?", "", ""},   // argumen tanpa angka
+		{"status SJ-001 SJ-002", "", ""}, // lebih dari satu argumen
+		{"status SJ-001 tolong dicek", "", ""},
+
+		{"driver Budi", CmdDriver, "Budi"},
+		{"Driver  Budi   Santoso ", CmdDriver, "Budi Santoso"},
+		{"driver Bu", "", ""}, // nama minimal 3 huruf
+		{"driver", "", ""},
+		{"driver Ané", CmdDriver, "Ané"},
+
+		{"outstanding dpk", CmdOutstandingDPK, ""},
+		{"/Outstanding DPK", CmdOutstandingDPK, ""},
+		{"outstanding", "", ""},
+		{"outstanding dpk hari ini", "", ""},
+		{"outstanding delivery", "", ""},
+
+		{"help", CmdHelp, ""},
+		{"bantuan", CmdHelp, ""},
+		{"!HELP", CmdHelp, ""},
+		{"help saya", "", ""},
+
+		{"", "", ""},
+		{"   ", "", ""},
+		{"/", "", ""},
+		{"halo semua", "", ""},
+		{"statusnya SJ-001", "", ""},
+	}
+
+	for _, tt := range tests {
+		cmd, args := parseCommand(tt.text)
+		if cmd != tt.wantCmd || args != tt.wantArgs {
+			t.Errorf("parseCommand(%q) = %q, %q, want %q, %q", tt.text, cmd, args, tt.wantCmd, tt.wantArgs)
+		}
+	}
+}
+
+func TestSenderPhones(t *testing.T) {
+	pn := func(user string) types.JID { return types.NewJID(user, types.DefaultUserServer) }
+	lid := types.NewJID("123456789", types.HiddenUserServer)
+
+	tests := []struct {
+		name string
+		info types.MessageInfo
+		want []string
+	}{
+		{"nomor Indonesia", types.MessageInfo{MessageSource: types.MessageSource{Sender: pn("6281234567890")}}, []string{"6281234567890", "081234567890"}},
+		{"nomor luar negeri", types.MessageInfo{MessageSource: types.MessageSource{Sender: pn("60123456789")}}, []string{"60123456789"}},
+		{"LID dengan SenderAlt", types.MessageInfo{MessageSource: types.MessageSource{Sender: lid, SenderAlt: pn("6285600011122")}}, []string{"6285600011122", "085600011122"}},
+	}
+
+	for _, tt := range tests {
+		// Client hanya dipakai untuk mapping LID yang tidak punya SenderAlt
+		if got := senderPhones(context.Background(), nil, tt.info); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: senderPhones = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sts/web_service/internal/shared"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const sseHeartbeat = 15 * time.Second
+
+type APIResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type handler struct {
+	manager *Manager
+}
+
+func NewHandler(m *Manager) *handler {
+	return &handler{manager: m}
+}
+
+func (h *handler) RegisterProtectedRoutes(r chi.Router) {
+	r.Route("/whatsapp", func(r chi.Router) {
+		r.Use(shared.AdminOnly)
+		r.Get("/status", h.Status)
+		r.Post("/pair", h.StartPairing)
+		r.Get("/pair/qr.png", h.QRImage)
+		r.Get("/pair/events", h.PairEvents)
+		r.Post("/logout", h.Logout)
+		r.Post("/repair", h.Repair)
+	})
+}
+
+func (h *handler) Status(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Data:    h.manager.Status(),
+	})
+}
+
+func (h *handler) StartPairing(w http.ResponseWriter, r *http.Request) {
+	st, err := h.manager.StartPairing()
+	if err != nil {
+		renderError(w, r, err, st)
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Pairing dimulai, scan QR dari menu Perangkat Tertaut di WhatsApp",
+		Data:    st,
+	})
+}
+
+// QRImage mengembalikan QR aktif sebagai PNG (refresh setiap qr_expires_at)
+func (h *handler) QRImage(w http.ResponseWriter, r *http.Request) {
+	png, err := h.manager.QRPNG()
+	if err != nil {
+		renderError(w, r, err, h.manager.Status())
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// PairEvents stream Server-Sent Events: event "qr" (kode baru) dan "state" (perubahan status)
+func (h *handler) PairEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, APIResponse{
+			Success: false,
+			Message: "streaming tidak didukung",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	updates, unsubscribe := h.manager.Subscribe()
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case u, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(u)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", u.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.Logout(r.Context()); err != nil {
+		renderError(w, r, err, h.manager.Status())
+		return
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Sesi WhatsApp berhasil logout",
+		Data:    h.manager.Status(),
+	})
+}
+
+func (h *handler) Repair(w http.ResponseWriter, r *http.Request) {
+	st, err := h.manager.Repair(r.Context())
+	if err != nil {
+		renderError(w, r, err, st)
+		return
+	}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, APIResponse{
+		Success: true,
+		Message: "Sesi lama dihapus, scan QR baru untuk pairing ulang",
+		Data:    st,
+	})
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, err error, st Status) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrDisabled):
+		code = http.StatusServiceUnavailable
+	case errors.Is(err, ErrAlreadyPaired), errors.Is(err, ErrNotPaired):
+		code = http.StatusConflict
+	case errors.Is(err, ErrNoQR):
+		code = http.StatusNotFound
+	}
+	render.Status(r, code)
+	render.JSON(w, r, APIResponse{
+		Success: false,
+		Message: err.Error(),
+		Data:    st,
+	})
+}
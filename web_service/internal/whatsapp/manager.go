@@ -0,0 +1,455 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Status koneksi sesi WhatsApp
+const (
+	StateDisabled     = "DISABLED"     // WA_ENABLED=false
+	StateNotPaired    = "NOT_PAIRED"   // belum ada device, perlu pairing
+	StatePairing      = "PAIRING"      // QR aktif, menunggu scan
+	StateConnecting   = "CONNECTING"   // sudah paired, sedang (re)connect
+	StateConnected    = "CONNECTED"    // siap kirim / terima pesan
+	StateDisconnected = "DISCONNECTED" // paired tapi koneksi putus; watchdog akan reconnect
+	StateLoggedOut    = "LOGGED_OUT"   // device dihapus dari HP / server, perlu pairing ulang
+)
+
+const (
+	watchdogInterval = 30 * time.Second
+	pairingTimeout   = 3 * time.Minute
+	qrPNGSize        = 256
+)
+
+var (
+	ErrDisabled      = errors.New("integrasi WhatsApp tidak aktif (WA_ENABLED=false)")
+	ErrAlreadyPaired = errors.New("sesi WhatsApp sudah terhubung ke nomor, logout dulu untuk pairing ulang")
+	ErrNotPaired     = errors.New("sesi WhatsApp belum di-pairing")
+	ErrNoQR          = errors.New("tidak ada QR aktif, mulai pairing dulu")
+)
+
+// Status adalah snapshot kondisi sesi untuk endpoint admin
+type Status struct {
+	State       string     `json:"state"`
+	JID         string     `json:"jid,omitempty"`
+	PushName    string     `json:"push_name,omitempty"`
+	Connected   bool       `json:"connected"`
+	LoggedIn    bool       `json:"logged_in"`
+	QRExpiresAt *time.Time `json:"qr_expires_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	Since       time.Time  `json:"since"`
+}
+
+// Update dikirim ke subscriber SSE setiap kali QR atau state berubah
+type Update struct {
+	Type   string `json:"type"` // "qr" atau "state"
+	Code   string `json:"code,omitempty"`
+	Status Status `json:"status"`
+}
+
+// Manager mengelola satu sesi whatsmeow: pairing, reconnect otomatis, logout dan shutdown
+type Manager struct {
+	enabled   bool
+	container *sqlstore.Container
+	clientLog waLog.Logger
+	logger    *slog.Logger
+
+	mu          sync.Mutex
+	client      *whatsmeow.Client
+	state       string
+	since       time.Time
+	qrCode      string
+	qrExpires   time.Time
+	lastError   string
+	handlers    []whatsmeow.EventHandler
+	subscribers map[chan Update]struct{}
+	stopPairing context.CancelFunc
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager membuka store sesi (sqlite). Jika enabled=false manager tetap dibuat dengan state DISABLED.
+func NewManager(ctx context.Context, enabled bool, dsn string, logger *slog.Logger) (*Manager, error) {
+	m := &Manager{
+		enabled:     enabled,
+		logger:      logger,
+		state:       StateDisabled,
+		since:       time.Now(),
+		subscribers: make(map[chan Update]struct{}),
+	}
+	if !enabled {
+		return m, nil
+	}
+
+	container, err := sqlstore.New(ctx, "sqlite", dsn, waLog.Stdout("Database", "WARN", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wa-sqlstore: %w", err)
+	}
+	device, err := container.GetFirstDevice(ctx)
+	if err != nil {
+		container.Close()
+		return nil, fmt.Errorf("failed to get wa-device: %w", err)
+	}
+
+	m.container = container
+	m.clientLog = waLog.Stdout("WhatsApp", "WARN", true)
+	m.client = m.newClient(device)
+	m.state = StateNotPaired
+	return m, nil
+}
+
+// Start connect jika device sudah paired dan menjalankan watchdog reconnect
+func (m *Manager) Start() {
+	if !m.enabled {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	m.reconnect()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.reconnect()
+			}
+		}
+	}()
+}
+
+// Shutdown menghentikan watchdog & pairing, disconnect klien lalu menutup store
+func (m *Manager) Shutdown() {
+	if !m.enabled {
+		return
+	}
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+
+	m.mu.Lock()
+	if m.stopPairing != nil {
+		m.stopPairing()
+		m.stopPairing = nil
+	}
+	client := m.client
+	for ch := range m.subscribers {
+		close(ch)
+		delete(m.subscribers, ch)
+	}
+	m.mu.Unlock()
+
+	if client != nil {
+		client.Disconnect()
+	}
+	if err := m.container.Close(); err != nil {
+		m.logger.Warn("whatsapp store not closed cleanly", "error", err)
+	}
+	m.logger.Info("WhatsApp disconnected.")
+}
+
+// Client mengembalikan klien aktif (nil jika nonaktif). Klien bisa berganti setelah logout / re-pair.
+func (m *Manager) Client() *whatsmeow.Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.client
+}
+
+// AddEventHandler mendaftarkan handler event whatsmeow; ikut dipasang ulang ke klien baru setelah re-pair
+func (m *Manager) AddEventHandler(h whatsmeow.EventHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, h)
+	if m.client != nil {
+		m.client.AddEventHandler(h)
+	}
+}
+
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statusLocked()
+}
+
+func (m *Manager) statusLocked() Status {
+	st := Status{State: m.state, LastError: m.lastError, Since: m.since}
+	if m.client != nil {
+		st.Connected = m.client.IsConnected()
+		st.LoggedIn = m.client.IsLoggedIn()
+		if m.client.Store.ID != nil {
+			st.JID = m.client.Store.ID.String()
+			st.PushName = m.client.Store.PushName
+		}
+	}
+	if m.state == StatePairing && m.qrCode != "" {
+		exp := m.qrExpires
+		st.QRExpiresAt = &exp
+	}
+	return st
+}
+
+// StartPairing membuat QR baru. Jika pairing sedang berjalan, status yang ada dikembalikan.
+func (m *Manager) StartPairing() (Status, error) {
+	if !m.enabled {
+		return Status{State: StateDisabled}, ErrDisabled
+	}
+
+	m.mu.Lock()
+	if m.state == StatePairing {
+		st := m.statusLocked()
+		m.mu.Unlock()
+		return st, nil
+	}
+	if m.client.Store.ID != nil {
+		m.mu.Unlock()
+		return m.Status(), ErrAlreadyPaired
+	}
+
+	// Device yang pernah dihapus (logout) tidak bisa dipakai lagi, buat device & klien baru
+	m.client.Disconnect()
+	m.client.RemoveEventHandlers()
+	client := m.newClient(m.container.NewDevice())
+	m.client = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), pairingTimeout)
+	m.stopPairing = cancel
+	m.setStateLocked(StatePairing, "")
+	m.mu.Unlock()
+
+	qrChan, err := client.GetQRChannel(ctx)
+	if err == nil {
+		err = client.Connect()
+	}
+	if err != nil {
+		cancel()
+		m.setState(StateNotPaired, err.Error())
+		return m.Status(), fmt.Errorf("gagal memulai pairing: %w", err)
+	}
+
+	go m.watchQR(client, qrChan, cancel)
+	return m.Status(), nil
+}
+
+func (m *Manager) watchQR(client *whatsmeow.Client, qrChan <-chan whatsmeow.QRChannelItem, cancel context.CancelFunc) {
+	defer cancel()
+
+	for item := range qrChan {
+		switch item.Event {
+		case whatsmeow.QRChannelEventCode:
+			m.mu.Lock()
+			m.qrCode = item.Code
+			m.qrExpires = time.Now().Add(item.Timeout)
+			st := m.statusLocked()
+			m.broadcastLocked(Update{Type: "qr", Code: item.Code, Status: st})
+			m.mu.Unlock()
+
+		case whatsmeow.QRChannelSuccess.Event:
+			m.logger.Info("whatsapp pairing success")
+			m.clearQR()
+			// State CONNECTED di-set oleh event Connected
+
+		default:
+			reason := item.Event
+			if item.Error != nil {
+				reason = item.Error.Error()
+			}
+			m.logger.Warn("whatsapp pairing ended", "event", item.Event, "reason", reason)
+			m.clearQR()
+			if client.Store.ID == nil {
+				client.Disconnect()
+				m.setState(StateNotPaired, "pairing: "+reason)
+			}
+		}
+	}
+
+	// Channel ditutup karena timeout / dibatalkan tanpa pernah di-scan
+	if client.Store.ID == nil && m.Client() == client && m.Status().State == StatePairing {
+		client.Disconnect()
+		m.clearQR()
+		m.setState(StateNotPaired, "pairing timeout")
+	}
+}
+
+// QRPNG me-render QR aktif sebagai PNG
+func (m *Manager) QRPNG() ([]byte, error) {
+	if !m.enabled {
+		return nil, ErrDisabled
+	}
+	m.mu.Lock()
+	code := m.qrCode
+	m.mu.Unlock()
+	if code == "" {
+		return nil, ErrNoQR
+	}
+	return qrcode.Encode(code, qrcode.Medium, qrPNGSize)
+}
+
+// Logout melepas device dari nomor WhatsApp dan menghapus sesi lokal
+func (m *Manager) Logout(ctx context.Context) error {
+	if !m.enabled {
+		return ErrDisabled
+	}
+
+	m.mu.Lock()
+	if m.stopPairing != nil {
+		m.stopPairing()
+		m.stopPairing = nil
+	}
+	client := m.client
+	m.mu.Unlock()
+
+	if client.Store.ID == nil {
+		client.Disconnect()
+		m.clearQR()
+		m.setState(StateNotPaired, "")
+		return ErrNotPaired
+	}
+
+	if err := client.Logout(ctx); err != nil {
+		// Server tidak bisa dihubungi: paksa hapus sesi lokal supaya bisa pairing ulang
+		m.logger.Warn("whatsapp logout request failed, clearing local session", "error", err)
+		client.Disconnect()
+		if err := client.Store.Delete(ctx); err != nil {
+			return fmt.Errorf("gagal menghapus sesi lokal: %w", err)
+		}
+	}
+
+	m.clearQR()
+	m.setState(StateNotPaired, "")
+	return nil
+}
+
+// Repair logout (jika paired) lalu langsung memulai pairing baru
+func (m *Manager) Repair(ctx context.Context) (Status, error) {
+	if err := m.Logout(ctx); err != nil && !errors.Is(err, ErrNotPaired) {
+		return m.Status(), err
+	}
+	return m.StartPairing()
+}
+
+// Subscribe mendaftarkan listener SSE; panggil fungsi kembalian untuk berhenti
+func (m *Manager) Subscribe() (<-chan Update, func()) {
+	ch := make(chan Update, 8)
+
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	ch <- Update{Type: "state", Code: m.qrCode, Status: m.statusLocked()}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// reconnect dipanggil watchdog: device paired yang tidak terhubung di-connect ulang.
+// whatsmeow sudah auto-reconnect saat koneksi putus; ini menangani connect awal yang gagal.
+func (m *Manager) reconnect() {
+	m.mu.Lock()
+	client := m.client
+	skip := m.state == StatePairing || client.Store.ID == nil || client.IsConnected()
+	m.mu.Unlock()
+	if skip {
+		return
+	}
+
+	m.setState(StateConnecting, "")
+	if err := client.Connect(); err != nil {
+		m.logger.Warn("whatsapp connect failed", "error", err)
+		m.setState(StateDisconnected, err.Error())
+	}
+}
+
+func (m *Manager) newClient(device *store.Device) *whatsmeow.Client {
+	client := whatsmeow.NewClient(device, m.clientLog)
+	client.InitialAutoReconnect = true
+	client.AddEventHandler(func(evt any) { m.handleEvent(client, evt) })
+	for _, h := range m.handlers {
+		client.AddEventHandler(h)
+	}
+	return client
+}
+
+func (m *Manager) handleEvent(client *whatsmeow.Client, evt any) {
+	// Event dari klien lama (sebelum re-pair) diabaikan
+	if m.Client() != client {
+		return
+	}
+
+	switch e := evt.(type) {
+	case *events.Connected:
+		m.setState(StateConnected, "")
+	case *events.Disconnected:
+		if client.Store.ID != nil {
+			m.setState(StateDisconnected, "")
+		}
+	case *events.StreamReplaced:
+		m.setState(StateDisconnected, "sesi dipakai di koneksi lain")
+	case *events.LoggedOut:
+		m.logger.Warn("whatsapp logged out", "reason", e.Reason.String())
+		m.setState(StateLoggedOut, "logged out: "+e.Reason.String())
+	case *events.TemporaryBan:
+		m.setState(StateDisconnected, e.String())
+	}
+}
+
+func (m *Manager) clearQR() {
+	m.mu.Lock()
+	m.qrCode = ""
+	m.qrExpires = time.Time{}
+	m.mu.Unlock()
+}
+
+func (m *Manager) setState(state, lastError string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setStateLocked(state, lastError)
+}
+
+func (m *Manager) setStateLocked(state, lastError string) {
+	if m.state == state && m.lastError == lastError {
+		return
+	}
+	m.state = state
+	m.since = time.Now()
+	if lastError != "" || state == StateConnected {
+		m.lastError = lastError
+	}
+	m.broadcastLocked(Update{Type: "state", Status: m.statusLocked()})
+}
+
+// broadcastLocked mengirim update tanpa blocking; subscriber yang lambat melewatkan update
+func (m *Manager) broadcastLocked(u Update) {
+	for ch := range m.subscribers {
+		select {
+		case ch <- u:
+		default:
+		}
+	}
+}
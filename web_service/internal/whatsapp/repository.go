@@ -0,0 +1,76 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrUnknownSender dikembalikan jika nomor pengirim tidak terhubung ke AD_User aktif
+var ErrUnknownSender = errors.New("nomor tidak terdaftar di AD_User")
+
+type Repository interface {
+	FindUserByPhone(ctx context.Context, phones ...string) (*Sender, error)
+	LogCommand(ctx context.Context, l CommandLog) error
+}
+
+type oraRepo struct {
+	db *sqlx.DB
+}
+
+func NewOraRepository(db *sqlx.DB) Repository {
+	return &oraRepo{db: db}
+}
+
+// FindUserByPhone mencocokkan nomor (hanya digit) dengan AD_USER.PHONE / PHONE2 yang juga dinormalisasi ke digit
+func (r *oraRepo) FindUserByPhone(ctx context.Context, phones ...string) (*Sender, error) {
+	for _, phone := range phones {
+		if phone == "" {
+			continue
+		}
+
+		var u Sender
+		query := `
+			SELECT AD_USER_ID, NAME, TITLE FROM (
+				SELECT AD_USER_ID, NAME, TITLE
+				FROM AD_USER
+				WHERE ISACTIVE = 'Y'
+				  AND (REGEXP_REPLACE(PHONE, '[^0-9]', '') = :1
+				       OR REGEXP_REPLACE(PHONE2, '[^0-9]', '') = :2)
+				ORDER BY UPDATED DESC
+			) WHERE ROWNUM = 1`
+
+		err := r.db.GetContext(ctx, &u, query, phone, phone)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		u.Phone = phone
+		return &u, nil
+	}
+	return nil, ErrUnknownSender
+}
+
+func (r *oraRepo) LogCommand(ctx context.Context, l CommandLog) error {
+	query := `
+		INSERT INTO ADW_STS_WA_COMMAND_LOG (
+			ADW_STS_WA_COMMAND_LOG_ID, AD_USER_ID, PHONE, CHATJID, MESSAGEID,
+			COMMAND, ARGS, STATUS, REPLY, CREATED
+		) VALUES (ADW_STS_WA_COMMAND_LOG_SQ.NEXTVAL, :1, :2, :3, :4, :5, :6, :7, :8, SYSDATE)`
+
+	_, err := r.db.ExecContext(ctx, query, l.UserID, l.Phone, l.ChatJID, l.MessageID,
+		l.Command, truncate(l.Args, 255), l.Status, truncate(l.Reply, 2000))
+	return err
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
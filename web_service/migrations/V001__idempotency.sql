@@ -0,0 +1,13 @@
+-- [user-003] Idempotency-Key untuk /handover/init dan /handover/process.
+-- Satu baris per (key, user); RESPONSECODE = 0 artinya request masih diproses.
+
+CREATE TABLE ADW_STS_IDEMPOTENCY (
+    IDEMPOTENCYKEY  VARCHAR2(128)   NOT NULL,
+    AD_USER_ID      NUMBER(10)      NOT NULL,
+    REQUESTHASH     VARCHAR2(64)    NOT NULL,
+    RESPONSECODE    NUMBER(3)       DEFAULT 0 NOT NULL,
+    RESPONSEBODY    CLOB,
+    CREATED         DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATED         DATE            DEFAULT SYSDATE NOT NULL,
+    CONSTRAINT ADW_STS_IDEMPOTENCY_PK PRIMARY KEY (IDEMPOTENCYKEY, AD_USER_ID)
+);
@@ -0,0 +1,41 @@
+-- [user-005] Transactional outbox untuk side effect handover (PDF, notifikasi WA).
+
+CREATE SEQUENCE ADW_STS_OUTBOX_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_OUTBOX (
+    ADW_STS_OUTBOX_ID   NUMBER(10)      NOT NULL,
+    AD_CLIENT_ID        NUMBER(10)      NOT NULL,
+    AD_ORG_ID           NUMBER(10)      NOT NULL,
+    EVENTTYPE           VARCHAR2(60)    NOT NULL,
+    PAYLOAD             CLOB            NOT NULL,
+    STATUS              VARCHAR2(20)    NOT NULL,   -- PENDING, PROCESSING, DONE, FAILED
+    ATTEMPTS            NUMBER(5)       DEFAULT 0 NOT NULL,
+    NEXTATTEMPT         DATE            DEFAULT SYSDATE NOT NULL,
+    LOCKEDUNTIL         DATE,
+    LASTERROR           VARCHAR2(2000),
+    PROCESSED           DATE,
+    CREATED             DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATED             DATE            DEFAULT SYSDATE NOT NULL,
+    CONSTRAINT ADW_STS_OUTBOX_PK PRIMARY KEY (ADW_STS_OUTBOX_ID)
+);
+
+-- ClaimOutbox: STATUS + NEXTATTEMPT / LOCKEDUNTIL
+CREATE INDEX ADW_STS_OUTBOX_STATUS ON ADW_STS_OUTBOX (STATUS, NEXTATTEMPT);
+
+CREATE SEQUENCE ADW_STS_OUTBOX_ATTEMPT_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_OUTBOX_ATTEMPT (
+    ADW_STS_OUTBOX_ATTEMPT_ID   NUMBER(10)      NOT NULL,
+    ADW_STS_OUTBOX_ID           NUMBER(10)      NOT NULL,
+    ATTEMPTNO                   NUMBER(5)       NOT NULL,
+    STARTED                     DATE            NOT NULL,
+    FINISHED                    DATE            NOT NULL,
+    ISSUCCESS                   CHAR(1)         NOT NULL,
+    ERRORMSG                    VARCHAR2(2000),
+    CONSTRAINT ADW_STS_OUTBOX_ATTEMPT_PK PRIMARY KEY (ADW_STS_OUTBOX_ATTEMPT_ID),
+    CONSTRAINT ADW_STS_OUTBOX_ATTEMPT_FK FOREIGN KEY (ADW_STS_OUTBOX_ID)
+        REFERENCES ADW_STS_OUTBOX (ADW_STS_OUTBOX_ID),
+    CONSTRAINT ADW_STS_OUTBOX_ATTEMPT_SUCCESS CHECK (ISSUCCESS IN ('Y', 'N'))
+);
+
+CREATE INDEX ADW_STS_OUTBOX_ATTEMPT_OUTBOX ON ADW_STS_OUTBOX_ATTEMPT (ADW_STS_OUTBOX_ID);
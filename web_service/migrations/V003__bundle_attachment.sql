@@ -0,0 +1,21 @@
+-- [user-007] Riwayat versi PDF bundle. Versi terbaru tetap disalin ke ADW_STS_BUNDLE.ATTACHMENT.
+
+CREATE SEQUENCE plastik.ADW_STS_BUNDLE_ATTACHMENT_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE plastik.ADW_STS_BUNDLE_ATTACHMENT (
+    ADW_STS_BUNDLE_ATTACHMENT_ID    NUMBER(10)      NOT NULL,
+    AD_CLIENT_ID                    NUMBER(10)      NOT NULL,
+    AD_ORG_ID                       NUMBER(10)      NOT NULL,
+    ADW_STS_BUNDLE_ID               NUMBER(10)      NOT NULL,
+    VERSION                         NUMBER(5)       NOT NULL,
+    ATTACHMENT                      VARCHAR2(255)   NOT NULL,   -- storage key PDF
+    CREATED                         DATE            DEFAULT SYSDATE NOT NULL,
+    CREATEDBY                       NUMBER(10)      NOT NULL,
+    UPDATED                         DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY                       NUMBER(10)      NOT NULL,
+    ISACTIVE                        CHAR(1)         DEFAULT 'Y' NOT NULL,
+    CONSTRAINT ADW_STS_BUNDLE_ATTACHMENT_PK PRIMARY KEY (ADW_STS_BUNDLE_ATTACHMENT_ID),
+    CONSTRAINT ADW_STS_BUNDLE_ATTACHMENT_FK FOREIGN KEY (ADW_STS_BUNDLE_ID)
+        REFERENCES plastik.ADW_STS_BUNDLE (ADW_STS_BUNDLE_ID),
+    CONSTRAINT ADW_STS_BUNDLE_ATTACHMENT_VER UNIQUE (ADW_STS_BUNDLE_ID, VERSION)
+);
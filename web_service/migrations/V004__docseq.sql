@@ -0,0 +1,12 @@
+-- [user-008] Counter nomor dokumen bundle per prefix + periode (YYYY, YYYYMM, atau '-' untuk DOCNO_RESET=NEVER).
+-- Baris dibuat otomatis saat periode pertama kali dipakai.
+
+CREATE TABLE ADW_STS_DOCSEQ (
+    AD_CLIENT_ID    NUMBER(10)      NOT NULL,
+    PREFIX          VARCHAR2(30)    NOT NULL,
+    PERIOD          VARCHAR2(6)     NOT NULL,
+    CURRENTNEXT     NUMBER(10)      NOT NULL,
+    CREATED         DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATED         DATE            DEFAULT SYSDATE NOT NULL,
+    CONSTRAINT ADW_STS_DOCSEQ_PK PRIMARY KEY (PREFIX, PERIOD)
+);
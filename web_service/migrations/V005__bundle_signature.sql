@@ -0,0 +1,6 @@
+-- [user-010] Tanda tangan pemberi / penerima (PNG) pada bundle serah terima.
+
+ALTER TABLE plastik.ADW_STS_BUNDLE ADD (
+    GIVERSIGNATURE      BLOB,
+    RECEIVERSIGNATURE   BLOB
+);
@@ -0,0 +1,6 @@
+-- [user-011] ID file di token QR dan SHA-256 PDF untuk verifikasi dokumen publik.
+
+ALTER TABLE plastik.ADW_STS_BUNDLE_ATTACHMENT ADD (
+    VERIFYID        VARCHAR2(64),
+    CONTENTHASH     VARCHAR2(64)
+);
@@ -0,0 +1,28 @@
+-- [user-013] Foto bukti check-in / check-out driver. File ada di storage (PHOTOKEY / THUMBKEY).
+
+CREATE SEQUENCE ADW_STS_EVENT_PHOTO_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_EVENT_PHOTO (
+    ADW_STS_EVENT_PHOTO_ID  NUMBER(10)      NOT NULL,
+    AD_CLIENT_ID            NUMBER(10)      NOT NULL,
+    AD_ORG_ID               NUMBER(10)      NOT NULL,
+    ADW_STS_EVENT_ID        NUMBER(10)      NOT NULL,
+    LINE                    NUMBER(5)       NOT NULL,
+    PHOTOKEY                VARCHAR2(255)   NOT NULL,
+    THUMBKEY                VARCHAR2(255)   NOT NULL,
+    CONTENTTYPE             VARCHAR2(60)    NOT NULL,
+    FILESIZE                NUMBER(10)      NOT NULL,
+    WIDTH                   NUMBER(5)       NOT NULL,
+    HEIGHT                  NUMBER(5)       NOT NULL,
+    TAKENAT                 DATE,
+    ISACTIVE                CHAR(1)         DEFAULT 'Y' NOT NULL,
+    CREATED                 DATE            DEFAULT SYSDATE NOT NULL,
+    CREATEDBY               NUMBER(10)      NOT NULL,
+    UPDATED                 DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY               NUMBER(10)      NOT NULL,
+    CONSTRAINT ADW_STS_EVENT_PHOTO_PK PRIMARY KEY (ADW_STS_EVENT_PHOTO_ID),
+    CONSTRAINT ADW_STS_EVENT_PHOTO_FK FOREIGN KEY (ADW_STS_EVENT_ID)
+        REFERENCES ADW_STS_EVENT (ADW_STS_EVENT_ID)
+);
+
+CREATE INDEX ADW_STS_EVENT_PHOTO_EVENT ON ADW_STS_EVENT_PHOTO (ADW_STS_EVENT_ID);
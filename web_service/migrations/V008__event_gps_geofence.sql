@@ -0,0 +1,29 @@
+-- [user-014] Posisi GPS driver saat check-in / check-out dan hasil validasi geofence customer.
+-- LATITUDE / LONGITUDE NULL jika client tidak mengirim GPS (GEOFENCESTATUS = NO_GPS).
+
+ALTER TABLE ADW_STS_EVENT ADD (
+    LATITUDE                NUMBER(9,6),
+    LONGITUDE               NUMBER(9,6),
+    GPSACCURACY             NUMBER(10,2),   -- meter
+    C_BPARTNER_LOCATION_ID  NUMBER(10),     -- geofence terdekat
+    GEOFENCESTATUS          VARCHAR2(20),   -- INSIDE, OUTSIDE, NO_GEOFENCE, NO_GPS
+    GEOFENCEDISTANCE        NUMBER(10,2)    -- meter ke titik geofence terdekat
+);
+
+-- Titik + radius per lokasi customer (C_BPARTNER_LOCATION)
+CREATE TABLE ADW_STS_GEOFENCE (
+    C_BPARTNER_LOCATION_ID  NUMBER(10)      NOT NULL,
+    AD_CLIENT_ID            NUMBER(10)      NOT NULL,
+    AD_ORG_ID               NUMBER(10)      NOT NULL,
+    LATITUDE                NUMBER(9,6)     NOT NULL,
+    LONGITUDE               NUMBER(9,6)     NOT NULL,
+    RADIUS                  NUMBER(10,2)    NOT NULL,   -- meter
+    ISACTIVE                CHAR(1)         DEFAULT 'Y' NOT NULL,
+    CREATED                 DATE            DEFAULT SYSDATE NOT NULL,
+    CREATEDBY               NUMBER(10)      NOT NULL,
+    UPDATED                 DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY               NUMBER(10)      NOT NULL,
+    CONSTRAINT ADW_STS_GEOFENCE_PK PRIMARY KEY (C_BPARTNER_LOCATION_ID),
+    CONSTRAINT ADW_STS_GEOFENCE_LOC_FK FOREIGN KEY (C_BPARTNER_LOCATION_ID)
+        REFERENCES C_BPARTNER_LOCATION (C_BPARTNER_LOCATION_ID)
+);
@@ -0,0 +1,19 @@
+-- [user-016] Template pesan notifikasi (text/template) per event + bahasa.
+-- Tanpa baris aktif, service memakai template bawaan.
+
+CREATE SEQUENCE ADW_STS_NOTIF_TEMPLATE_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_NOTIF_TEMPLATE (
+    ADW_STS_NOTIF_TEMPLATE_ID   NUMBER(10)      NOT NULL,
+    EVENTTYPE                   VARCHAR2(60)    NOT NULL,
+    LANG                        VARCHAR2(5)     NOT NULL,
+    SUBJECT                     VARCHAR2(255),
+    BODY                        VARCHAR2(4000)  NOT NULL,
+    ISACTIVE                    CHAR(1)         DEFAULT 'Y' NOT NULL,
+    CREATED                     DATE            DEFAULT SYSDATE NOT NULL,
+    CREATEDBY                   NUMBER(10)      NOT NULL,
+    UPDATED                     DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY                   NUMBER(10)      NOT NULL,
+    CONSTRAINT ADW_STS_NOTIF_TEMPLATE_PK PRIMARY KEY (ADW_STS_NOTIF_TEMPLATE_ID),
+    CONSTRAINT ADW_STS_NOTIF_TEMPLATE_UK UNIQUE (EVENTTYPE, LANG)
+);
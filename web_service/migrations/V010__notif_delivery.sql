@@ -0,0 +1,28 @@
+-- [user-017] Antrian pengiriman notifikasi per channel + penerima, dengan retry dan dead-letter.
+
+CREATE SEQUENCE ADW_STS_NOTIF_DELIVERY_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_NOTIF_DELIVERY (
+    ADW_STS_NOTIF_DELIVERY_ID   NUMBER(10)      NOT NULL,
+    EVENTTYPE                   VARCHAR2(60)    NOT NULL,
+    CHANNEL                     VARCHAR2(20)    NOT NULL,
+    RECIPIENT                   VARCHAR2(255)   NOT NULL,
+    LANG                        VARCHAR2(5)     NOT NULL,
+    PAYLOAD                     CLOB            NOT NULL,   -- Message dalam JSON
+    STATUS                      VARCHAR2(20)    NOT NULL,   -- PENDING, SENDING, DELIVERED, DEAD
+    ATTEMPTS                    NUMBER(5)       DEFAULT 0 NOT NULL,
+    NEXTATTEMPT                 DATE            DEFAULT SYSDATE NOT NULL,
+    LOCKEDUNTIL                 DATE,
+    LASTERROR                   VARCHAR2(2000),
+    DELIVERED                   DATE,
+    CREATED                     DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATED                     DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY                   NUMBER(10),     -- diisi saat resend manual
+    DEDUPEKEY                   VARCHAR2(64),   -- sha256(sumber event|channel|penerima), NULL = tanpa dedupe
+    CONSTRAINT ADW_STS_NOTIF_DELIVERY_PK PRIMARY KEY (ADW_STS_NOTIF_DELIVERY_ID)
+);
+
+-- ClaimDeliveries: STATUS + NEXTATTEMPT / LOCKEDUNTIL
+CREATE INDEX ADW_STS_NOTIF_DELIVERY_STATUS ON ADW_STS_NOTIF_DELIVERY (STATUS, NEXTATTEMPT);
+-- Outbox yang diproses ulang tidak membuat notifikasi kedua ke penerima yang sama
+CREATE UNIQUE INDEX ADW_STS_NOTIF_DELIVERY_DEDUPE ON ADW_STS_NOTIF_DELIVERY (DEDUPEKEY);
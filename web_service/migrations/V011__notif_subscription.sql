@@ -0,0 +1,29 @@
+-- [user-018] Langganan notifikasi per customer / driver / TNKB. Filter NULL = semua.
+
+CREATE SEQUENCE ADW_STS_NOTIF_SUBSCRIPTION_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_NOTIF_SUBSCRIPTION (
+    ADW_STS_NOTIF_SUBSCRIPTION_ID   NUMBER(10)      NOT NULL,
+    NAME                            VARCHAR2(120)   NOT NULL,
+    CHANNEL                         VARCHAR2(20)    NOT NULL,
+    RECIPIENT                       VARCHAR2(255)   NOT NULL,   -- JID group/nomor, email atau URL webhook
+    LANG                            VARCHAR2(5)     NOT NULL,
+    EVENTTYPES                      VARCHAR2(1000),             -- dipisah koma, NULL = semua event
+    C_BPARTNER_ID                   NUMBER(10),
+    DRIVER_ID                       NUMBER(10),
+    TNKB_ID                         NUMBER(10),
+    EXCEPTIONSONLY                  CHAR(1)         DEFAULT 'N' NOT NULL,
+    QUIETSTART                      VARCHAR2(5),                -- HH:MM
+    QUIETEND                        VARCHAR2(5),
+    ISACTIVE                        CHAR(1)         DEFAULT 'Y' NOT NULL,
+    CREATED                         DATE            DEFAULT SYSDATE NOT NULL,
+    CREATEDBY                       NUMBER(10)      NOT NULL,
+    UPDATED                         DATE            DEFAULT SYSDATE NOT NULL,
+    UPDATEDBY                       NUMBER(10)      NOT NULL,
+    CONSTRAINT ADW_STS_NOTIF_SUBSCRIPTION_PK PRIMARY KEY (ADW_STS_NOTIF_SUBSCRIPTION_ID)
+);
+
+-- Delivery mencatat langganan asalnya (NULL = rute statis dari NOTIFY_ROUTES)
+ALTER TABLE ADW_STS_NOTIF_DELIVERY ADD (
+    ADW_STS_NOTIF_SUBSCRIPTION_ID   NUMBER(10)
+);
@@ -0,0 +1,17 @@
+-- [user-020] Log perintah WhatsApp (status, driver, outstanding dpk) beserta balasannya.
+
+CREATE SEQUENCE ADW_STS_WA_COMMAND_LOG_SQ START WITH 1 INCREMENT BY 1 NOCACHE;
+
+CREATE TABLE ADW_STS_WA_COMMAND_LOG (
+    ADW_STS_WA_COMMAND_LOG_ID   NUMBER(10)      NOT NULL,
+    AD_USER_ID                  NUMBER(10),                 -- NULL jika nomor pengirim tidak dikenal
+    PHONE                       VARCHAR2(30),
+    CHATJID                     VARCHAR2(100)   NOT NULL,
+    MESSAGEID                   VARCHAR2(100)   NOT NULL,
+    COMMAND                     VARCHAR2(30)    NOT NULL,
+    ARGS                        VARCHAR2(255),
+    STATUS                      VARCHAR2(20)    NOT NULL,   -- OK, ERROR, REJECTED
+    REPLY                       VARCHAR2(2000),
+    CREATED                     DATE            DEFAULT SYSDATE NOT NULL,
+    CONSTRAINT ADW_STS_WA_COMMAND_LOG_PK PRIMARY KEY (ADW_STS_WA_COMMAND_LOG_ID)
+);
@@ -0,0 +1,7 @@
+-- [user-003] Lease reservasi Idempotency-Key.
+-- RESERVED = waktu key diambil; baris RESPONSECODE = 0 yang lebih lama dari lease
+-- (request crash / koneksi DB putus) boleh diambil alih oleh retry dengan body yang sama.
+
+ALTER TABLE ADW_STS_IDEMPOTENCY ADD (
+    RESERVED        DATE            DEFAULT SYSDATE NOT NULL
+);